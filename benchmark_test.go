@@ -1,8 +1,10 @@
 package fox
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -317,3 +319,41 @@ func BenchmarkVeryLongPattern(b *testing.B) {
 		f.ServeHTTP(w, req)
 	}
 }
+
+func BenchmarkLoad(b *testing.B) {
+	f := MustRouter(AllowRegexpParam(true))
+	routes := make([]*Route, 0, 100000)
+	for i := range 10000 {
+		for _, pattern := range []string{
+			"/api/v%d/tenants/t%d/users",
+			"/api/v%d/tenants/t%d/users/{id}",
+			"/api/v%d/tenants/t%d/users/{id}/items/{item:[0-9]+}",
+			"/api/v%d/tenants/t%d/files/+{path}",
+			"t%d.example.com/v%d/health",
+		} {
+			route, err := f.NewRoute(MethodGet, fmt.Sprintf(pattern, i%10, i), emptyHandler)
+			require.NoError(b, err)
+			routes = append(routes, route)
+		}
+	}
+
+	b.Run("txn add route", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			f := MustRouter(AllowRegexpParam(true))
+			txn := f.Txn(true)
+			for _, route := range routes {
+				require.NoError(b, txn.AddRoute(route))
+			}
+			require.NoError(b, txn.Commit())
+		}
+	})
+
+	b.Run("load", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			f := MustRouter(AllowRegexpParam(true))
+			require.NoError(b, f.Load(slices.Values(routes)))
+		}
+	})
+}
@@ -0,0 +1,386 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+const (
+	paramInPath  = "path"
+	paramInQuery = "query"
+)
+
+var (
+	errMissingValue = errors.New("missing value")
+	errInvalidUUID  = errors.New("invalid UUID")
+)
+
+// ParamType is the set of types supported by [ParamAs].
+type ParamType interface {
+	string | bool |
+		int | int8 | int16 | int32 | int64 |
+		uint | uint8 | uint16 | uint32 | uint64 |
+		float32 | float64 |
+		UUID
+}
+
+// ParamAs returns the value of the named path parameter converted to T. If the parameter does not exist or cannot be
+// converted, it returns a [ParamError], which is handled as a "400 Bad Request" by the [DefaultErrorHandler] when
+// returned from a [HandlerFuncE]. As with [Context.Param], the value is not unescaped before the conversion. ParamAs
+// does not allocate for successful conversions.
+func ParamAs[T ParamType](c *Context, name string) (T, error) {
+	var v T
+	raw, ok := c.lookupParam(name)
+	if !ok {
+		return v, &ParamError{In: paramInPath, Name: name, Err: errMissingValue}
+	}
+	if err := parseParam(&v, raw); err != nil {
+		var zero T
+		return zero, &ParamError{In: paramInPath, Name: name, Value: raw, Err: err}
+	}
+	return v, nil
+}
+
+// ParamUUID returns the value of the named path parameter as a [UUID]. It is a shorthand for ParamAs[UUID].
+func (c *Context) ParamUUID(name string) (UUID, error) {
+	return ParamAs[UUID](c, name)
+}
+
+// BindParams populates the struct pointed to by dst with the path parameters. Fields are bound to the parameter named
+// by their "param" tag, e.g. `param:"id"`, and untagged fields are ignored. Supported field types are those of
+// [ParamType], their named variants, pointers and slices of them, and any type implementing
+// [encoding.TextUnmarshaler]. Fields without a matching parameter are left unchanged. Conversion errors are
+// aggregated into [ParamErrors], and do not prevent other fields from being bound. If dst is not a non-nil pointer to
+// a struct, or has a tagged field of an unsupported type, it returns an error that is [ErrInvalidBindDestination],
+// which is a programming error handled as a "500 Internal Server Error" by the [DefaultErrorHandler].
+func (c *Context) BindParams(dst any) error {
+	return bind(dst, "param", paramInPath, func(name string) []string {
+		if raw, ok := c.lookupParam(name); ok {
+			return []string{raw}
+		}
+		return nil
+	})
+}
+
+// BindQuery populates the struct pointed to by dst with the query parameters. Fields are bound to the parameter named
+// by their "query" tag, e.g. `query:"page"`. Slice fields receive every value of a repeated parameter, and other
+// fields the first one. See [Context.BindParams] for the supported field types and error handling.
+func (c *Context) BindQuery(dst any) error {
+	query := c.getQueries()
+	return bind(dst, "query", paramInQuery, func(name string) []string {
+		return query[name]
+	})
+}
+
+// lookupParam returns the value of the named path parameter, and reports whether it exists.
+func (c *Context) lookupParam(name string) (string, bool) {
+	for i := range *c.params {
+		if (*c.paramsKeys)[i] == name {
+			return (*c.params)[i], true
+		}
+	}
+	return "", false
+}
+
+// parseParam converts s to the type pointed to by v.
+func parseParam(v any, s string) error {
+	var err error
+	switch p := v.(type) {
+	case *string:
+		*p = s
+	case *bool:
+		*p, err = strconv.ParseBool(s)
+	case *int:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, strconv.IntSize)
+		*p = int(n)
+	case *int8:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 8)
+		*p = int8(n)
+	case *int16:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 16)
+		*p = int16(n)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*p = int32(n)
+	case *int64:
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *uint:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, strconv.IntSize)
+		*p = uint(n)
+	case *uint8:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 8)
+		*p = uint8(n)
+	case *uint16:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 16)
+		*p = uint16(n)
+	case *uint32:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 32)
+		*p = uint32(n)
+	case *uint64:
+		*p, err = strconv.ParseUint(s, 10, 64)
+	case *float32:
+		var n float64
+		n, err = strconv.ParseFloat(s, 32)
+		*p = float32(n)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *UUID:
+		*p, err = ParseUUID(s)
+	}
+	return numErrorCause(err)
+}
+
+// numErrorCause strips the function name and input of a [strconv.NumError], which are already reported by [ParamError].
+func numErrorCause(err error) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		return ne.Err
+	}
+	return err
+}
+
+// bindField is a struct field bound to a parameter.
+type bindField struct {
+	name  string
+	index []int
+}
+
+type bindKey struct {
+	typ reflect.Type
+	tag string
+}
+
+// bindCache caches the bound fields of struct types, per tag.
+var bindCache sync.Map // map[bindKey][]bindField
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// bind sets the fields of dst tagged with the provided key to the values returned by lookup.
+func bind(dst any, tag, in string, lookup func(name string) []string) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w %T: must be a non-nil pointer to a struct", ErrInvalidBindDestination, dst)
+	}
+	rv = rv.Elem()
+
+	fields, err := cachedFields(rv.Type(), tag)
+	if err != nil {
+		return err
+	}
+
+	var errs ParamErrors
+	for _, f := range fields {
+		values := lookup(f.name)
+		if len(values) == 0 {
+			continue
+		}
+		field := rv.FieldByIndex(f.index)
+		if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) &&
+			!reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+			slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+			for i, value := range values {
+				if err = setValue(slice.Index(i), value); err != nil {
+					errs = append(errs, &ParamError{In: in, Name: f.name, Value: value, Err: err})
+				}
+			}
+			field.Set(slice)
+			continue
+		}
+		if err = setValue(field, values[0]); err != nil {
+			errs = append(errs, &ParamError{In: in, Name: f.name, Value: values[0], Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// cachedFields returns the fields of the struct type t tagged with the provided key.
+func cachedFields(t reflect.Type, tag string) ([]bindField, error) {
+	key := bindKey{typ: t, tag: tag}
+	if fields, ok := bindCache.Load(key); ok {
+		return fields.([]bindField), nil
+	}
+
+	var fields []bindField
+	for _, sf := range reflect.VisibleFields(t) {
+		name, ok := sf.Tag.Lookup(tag)
+		if !ok || name == "-" || !sf.IsExported() || embeddedPointer(t, sf.Index) {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if !bindable(sf.Type, true) {
+			return nil, fmt.Errorf("%w %s: unsupported type %s for field %s", ErrInvalidBindDestination, t, sf.Type, sf.Name)
+		}
+		fields = append(fields, bindField{name: name, index: sf.Index})
+	}
+
+	bindCache.Store(key, fields)
+	return fields, nil
+}
+
+// embeddedPointer reports whether the field at index is promoted through an embedded pointer, which may be nil.
+func embeddedPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// bindable reports whether a value of type t can be set from a string.
+func bindable(t reflect.Type, allowSlice bool) bool {
+	if t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		return bindable(t.Elem(), false)
+	case reflect.Slice:
+		return allowSlice && bindable(t.Elem(), false)
+	default:
+		return false
+	}
+}
+
+// setValue converts s to the type of v and sets it.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return numErrorCause(err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return numErrorCause(err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return numErrorCause(err)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return numErrorCause(err)
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
+
+// UUID is a universally unique identifier, as defined by RFC 9562. It has the same representation as the UUID types
+// of the most common third-party packages, and can be converted to them.
+type UUID [16]byte
+
+// uuidHexOffsets holds the offset of each byte of a UUID in its canonical textual representation.
+var uuidHexOffsets = [16]int{0, 2, 4, 6, 9, 11, 14, 16, 19, 21, 24, 26, 28, 30, 32, 34}
+
+// ParseUUID parses a UUID in its canonical textual representation, e.g. "f47ac10b-58cc-4372-a567-0e02b2c3d479".
+// Hexadecimal digits are case-insensitive.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errInvalidUUID
+	}
+
+	for j, i := range uuidHexOffsets {
+		hi, ok1 := fromHexChar(s[i])
+		lo, ok2 := fromHexChar(s[i+1])
+		if !ok1 || !ok2 {
+			return UUID{}, errInvalidUUID
+		}
+		u[j] = hi<<4 | lo
+	}
+	return u, nil
+}
+
+// String returns the canonical textual representation of the UUID, in lowercase.
+func (u UUID) String() string {
+	buf, _ := u.MarshalText()
+	return string(buf)
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (u UUID) MarshalText() ([]byte, error) {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return buf, nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (u *UUID) UnmarshalText(text []byte) error {
+	v, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
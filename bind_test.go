@@ -0,0 +1,281 @@
+package fox
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBindContext(t testing.TB, pattern, target string) *Context {
+	t.Helper()
+	f := MustRouter()
+	var cc *Context
+	require.NoError(t, onlyError(f.Add(MethodGet, pattern, func(c *Context) {
+		cc = c.Clone()
+	})))
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	require.NotNil(t, cc)
+	return cc
+}
+
+func TestParamAs(t *testing.T) {
+	c := newBindContext(t, "/{id}/{n}/{f}/{b}/{uuid}", "/42/-7/1.5/true/F47AC10B-58CC-4372-A567-0E02B2C3D479")
+
+	id, err := ParamAs[int64](c, "id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	n, err := ParamAs[int8](c, "n")
+	require.NoError(t, err)
+	assert.Equal(t, int8(-7), n)
+
+	f, err := ParamAs[float64](c, "f")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+
+	b, err := ParamAs[bool](c, "b")
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	s, err := ParamAs[string](c, "id")
+	require.NoError(t, err)
+	assert.Equal(t, "42", s)
+
+	u, err := c.ParamUUID("uuid")
+	require.NoError(t, err)
+	assert.Equal(t, "f47ac10b-58cc-4372-a567-0e02b2c3d479", u.String())
+
+	_, err = ParamAs[uint](c, "n")
+	var pe *ParamError
+	require.ErrorAs(t, err, &pe)
+	assert.ErrorIs(t, err, ErrInvalidParam)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.Equal(t, &ParamError{In: "path", Name: "n", Value: "-7", Err: strconv.ErrSyntax}, pe)
+	assert.Equal(t, `invalid param: path "n" with value "-7": invalid syntax`, err.Error())
+
+	v, err := ParamAs[int8](c, "id")
+	require.NoError(t, err)
+	assert.Equal(t, int8(42), v)
+
+	c = newBindContext(t, "/{id}", "/300")
+	v, err = ParamAs[int8](c, "id")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+	assert.Zero(t, v)
+
+	_, err = ParamAs[int](c, "missing")
+	assert.ErrorIs(t, err, ErrInvalidParam)
+	assert.Equal(t, `invalid param: path "missing": missing value`, err.Error())
+
+	_, err = c.ParamUUID("id")
+	assert.ErrorIs(t, err, errInvalidUUID)
+
+	c = newBindContext(t, "/{uuid}", "/f47ac10b-58cc-4372-a567--0e02b2c3d47")
+	_, err = c.ParamUUID("uuid")
+	assert.ErrorIs(t, err, errInvalidUUID)
+}
+
+func TestParamAs_Allocs(t *testing.T) {
+	c := newBindContext(t, "/{id}/{uuid}", "/42/f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = ParamAs[int64](c, "id")
+		_, _ = ParamAs[int](c, "id")
+		_, _ = ParamAs[uint32](c, "id")
+		_, _ = c.ParamUUID("uuid")
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestParseUUID(t *testing.T) {
+	cases := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "f47ac10b-58cc-4372-a567-0e02b2c3d479"},
+		{in: "00000000-0000-0000-0000-000000000000"},
+		{in: "f47ac10b58cc4372a5670e02b2c3d479", wantErr: true},
+		{in: "f47ac10b-58cc-4372-a567-0e02b2c3d47", wantErr: true},
+		{in: "f47ac10b-58cc-4372-a567-0e02b2c3d47z", wantErr: true},
+		{in: "f47ac10b-58cc-4372-a5670-e02b2c3d479", wantErr: true},
+		{in: "f47ac10b-58cc-4372-a567--0e02b2c3d47", wantErr: true},
+		{in: "F47AC10B-58CC-4372-A567-0E02B2C3D479"},
+		{in: "", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			u, err := ParseUUID(tc.in)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, errInvalidUUID)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToLower(tc.in), u.String())
+		})
+	}
+}
+
+type page int
+
+type Pagination struct {
+	Page  page `query:"page"`
+	Limit *int `query:"limit"`
+}
+
+func TestContext_BindParams(t *testing.T) {
+	type params struct {
+		ID      int64  `param:"id"`
+		Name    string `param:"name"`
+		UUID    UUID   `param:"uuid"`
+		Missing string `param:"missing"`
+		Ignored string
+		Skipped string `param:"-"`
+	}
+
+	c := newBindContext(t, "/users/{id}/{name}/{uuid}", "/users/42/john/f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	dst := params{Missing: "default"}
+	require.NoError(t, c.BindParams(&dst))
+	assert.Equal(t, params{
+		ID:      42,
+		Name:    "john",
+		UUID:    UUID{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72, 0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79},
+		Missing: "default",
+	}, dst)
+
+	c = newBindContext(t, "/users/{id}/{name}/{uuid}", "/users/abc/john/xyz")
+	err := c.BindParams(&dst)
+	var errs ParamErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "id", errs[0].Name)
+	assert.Equal(t, "uuid", errs[1].Name)
+	assert.ErrorIs(t, err, ErrInvalidParam)
+	assert.ErrorIs(t, err, errInvalidUUID)
+	assert.Equal(t, `invalid param: path "id" with value "abc": invalid syntax; invalid param: path "uuid" with value "xyz": invalid UUID`, err.Error())
+	assert.Equal(t, "john", dst.Name)
+}
+
+func TestContext_BindQuery(t *testing.T) {
+	type query struct {
+		Pagination
+		Tags  []string   `query:"tag"`
+		IDs   []uint     `query:"id"`
+		Since *time.Time `query:"since"`
+		Debug bool       `query:"debug"`
+		Sort  string     `query:""`
+	}
+
+	c := newBindContext(t, "/search", "/search?page=2&limit=10&tag=a&tag=b&id=1&id=2&since=2024-01-02T15:04:05Z&debug=1&Sort=asc")
+	var dst query
+	require.NoError(t, c.BindQuery(&dst))
+	require.NotNil(t, dst.Limit)
+	require.NotNil(t, dst.Since)
+	assert.Equal(t, page(2), dst.Page)
+	assert.Equal(t, 10, *dst.Limit)
+	assert.Equal(t, []string{"a", "b"}, dst.Tags)
+	assert.Equal(t, []uint{1, 2}, dst.IDs)
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), *dst.Since)
+	assert.True(t, dst.Debug)
+	assert.Equal(t, "asc", dst.Sort)
+
+	c = newBindContext(t, "/search", "/search?page=x&id=1&id=-2&since=yesterday")
+	err := c.BindQuery(&dst)
+	var errs ParamErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 3)
+	assert.Equal(t, &ParamError{In: "query", Name: "page", Value: "x", Err: strconv.ErrSyntax}, errs[0])
+	assert.Equal(t, "id", errs[1].Name)
+	assert.Equal(t, "-2", errs[1].Value)
+	assert.Equal(t, "since", errs[2].Name)
+}
+
+func TestContext_BindInvalidDestination(t *testing.T) {
+	c := newBindContext(t, "/{id}", "/1")
+
+	var s struct {
+		ID int `param:"id"`
+	}
+	assert.ErrorContains(t, c.BindParams(s), "must be a non-nil pointer to a struct")
+	assert.ErrorContains(t, c.BindParams(nil), "must be a non-nil pointer to a struct")
+	var n int
+	assert.ErrorContains(t, c.BindParams(&n), "must be a non-nil pointer to a struct")
+
+	var unsupported struct {
+		ID map[string]string `param:"id"`
+	}
+	err := c.BindParams(&unsupported)
+	assert.ErrorContains(t, err, "unsupported type map[string]string for field ID")
+	assert.ErrorIs(t, err, ErrInvalidBindDestination)
+	assert.False(t, errors.Is(err, ErrInvalidParam))
+	assert.ErrorIs(t, c.BindParams(nil), ErrInvalidBindDestination)
+}
+
+func TestDefaultErrorHandler_InvalidParam(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/users/{id}", HandleE(func(c *Context) error {
+		id, err := ParamAs[int](c, "id")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, strconv.Itoa(id))
+	}))
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "42", w.Body.String())
+
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid parameter\n", w.Body.String())
+}
+
+func TestDefaultErrorHandler_InvalidParamProblem(t *testing.T) {
+	f := MustRouter(WithProblemDetails(true))
+	f.MustAdd(MethodGet, "/users/{id}", HandleE(func(c *Context) error {
+		var params struct {
+			ID   int  `query:"id"`
+			UUID UUID `query:"uuid"`
+		}
+		return c.BindQuery(&params)
+	}))
+	f.MustAdd(MethodGet, "/bind", HandleE(func(c *Context) error {
+		return c.BindQuery(nil)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?id=<script>&uuid=xyz", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationProblemJSON)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "invalid parameter",
+		"instance": "/users/1",
+		"invalid-params": [{"name": "id", "in": "query"}, {"name": "uuid", "in": "query"}]
+	}`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "script")
+
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bind", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func BenchmarkParamAs(b *testing.B) {
+	c := newBindContext(b, "/users/{id}", "/users/123456")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ParamAs[int64](c, "id")
+	}
+}
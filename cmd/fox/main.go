@@ -0,0 +1,494 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Command fox validates and tests route tables described in a [foxconfig] JSON document, entirely offline.
+//
+// Usage:
+//
+//	fox validate [flags] FILE
+//	fox routes [flags] FILE
+//	fox match [flags] FILE METHOD URL
+//
+// The validate command registers every route of the document and reports each error with its position in the file,
+// followed by the findings of [fox.Lint]. It exits with a non-zero status if a route is invalid or if an error
+// finding is reported.
+//
+// The routes command lists the routes in the order they are evaluated during lookup.
+//
+// The match command reports which route a request hits, with the captured params. The URL may be absolute to
+// test routes with a hostname, and may include a query string. Headers are provided with the repeatable -H flag.
+// The -explain flag prints every decision taken during the lookup. It exits with a non-zero status if no route
+// matches the request.
+//
+// Handlers and middlewares referenced in the document are replaced by stubs, so the document can be validated without
+// the application code. Router options that affect routing can be set with flags, run "fox help" for the full list.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/clientip"
+	"github.com/fox-toolkit/fox/foxconfig"
+)
+
+const usage = `Usage:
+  fox validate [flags] FILE
+  fox routes [flags] FILE
+  fox match [flags] FILE METHOD URL
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd := &command{name: args[0], stdout: stdout, stderr: stderr}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&cmd.allowRegexp, "allow-regexp", false, "allow regular expressions in params and wildcards")
+	fs.StringVar(&cmd.trailingSlash, "trailing-slash", "strict", "trailing slash option: strict, relaxed or redirect")
+	fs.StringVar(&cmd.fixedPath, "fixed-path", "strict", "fixed path option: strict, relaxed or redirect")
+	fs.BoolVar(&cmd.noMethod, "no-method", false, "enable 405 Method Not Allowed responses")
+	fs.BoolVar(&cmd.autoOptions, "auto-options", false, "enable automatic OPTIONS responses")
+	fs.BoolVar(&cmd.autoHEAD, "auto-head", false, "enable automatic HEAD responses from GET routes")
+
+	var run func(args []string) int
+	nargs := 1
+	switch cmd.name {
+	case "validate":
+		run = cmd.validate
+	case "routes":
+		run = cmd.routes
+	case "match":
+		fs.Var(&cmd.headers, "H", "request header as 'Key: Value', may be repeated")
+		fs.StringVar(&cmd.remoteAddr, "remote-addr", "192.0.2.1:1234", "request remote address")
+		fs.BoolVar(&cmd.explain, "explain", false, "print the lookup decisions")
+		run = cmd.match
+		nargs = 3
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		fmt.Fprintln(stdout, "\nFlags:")
+		fs.SetOutput(stdout)
+		fs.PrintDefaults()
+		return 0
+	default:
+		fmt.Fprintf(stderr, "fox: unknown command %q\n%s", cmd.name, usage)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != nargs {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	return run(fs.Args())
+}
+
+type command struct {
+	stdout        io.Writer
+	stderr        io.Writer
+	name          string
+	file          string
+	data          []byte
+	trailingSlash string
+	fixedPath     string
+	remoteAddr    string
+	headers       headers
+	allowRegexp   bool
+	noMethod      bool
+	autoOptions   bool
+	autoHEAD      bool
+	explain       bool
+}
+
+// table is a route table loaded from a document.
+type table struct {
+	router *fox.Router
+	doc    *foxconfig.Document
+	// configs maps registered routes to their configuration.
+	configs map[*fox.Route]foxconfig.RouteConfig
+}
+
+func (cmd *command) validate(args []string) int {
+	t, failed, ok := cmd.load(args[0])
+	if !ok {
+		return 1
+	}
+
+	status := 0
+	if failed > 0 {
+		status = 1
+	}
+	for _, finding := range fox.Lint(t.router.Iter()) {
+		fmt.Fprintf(cmd.stderr, "%s: %s\n", cmd.file, finding)
+		if finding.Severity == fox.SeverityError {
+			status = 1
+		}
+	}
+
+	if status == 0 {
+		fmt.Fprintf(cmd.stdout, "%s: %d routes ok\n", cmd.file, len(t.doc.Routes))
+	}
+	return status
+}
+
+func (cmd *command) routes(args []string) int {
+	t, failed, ok := cmd.load(args[0])
+	if !ok || failed > 0 {
+		return 1
+	}
+
+	w := tabwriter.NewWriter(cmd.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHODS\tPATTERN\tNAME\tHANDLER\tMATCHERS\tPRIORITY")
+	for route := range t.router.Iter().ByPriority() {
+		rc := t.configs[route]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			methods(rc.Methods), route.Pattern(), route.Name(), rc.Handler, matchers(route), route.MatchersPriority())
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (cmd *command) match(args []string) int {
+	t, failed, ok := cmd.load(args[0])
+	if !ok || failed > 0 {
+		return 1
+	}
+
+	req, err := cmd.request(args[1], args[2])
+	if err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return 2
+	}
+
+	e := t.router.Explain(req)
+	if cmd.explain {
+		fmt.Fprint(cmd.stdout, e.String())
+		fmt.Fprintln(cmd.stdout)
+	}
+
+	w := tabwriter.NewWriter(cmd.stdout, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(w, "outcome:\t%s\n", e.Outcome)
+	if e.Route != nil {
+		rc := t.configs[e.Route]
+		fmt.Fprintf(w, "route:\t%s %s\n", methods(rc.Methods), e.Route.Pattern())
+		if name := e.Route.Name(); name != "" {
+			fmt.Fprintf(w, "name:\t%s\n", name)
+		}
+		fmt.Fprintf(w, "handler:\t%s\n", rc.Handler)
+		if m := matchers(e.Route); m != "" {
+			fmt.Fprintf(w, "matchers:\t%s\n", m)
+		}
+		for _, param := range e.Params {
+			fmt.Fprintf(w, "param:\t%s=%s\n", param.Key, param.Value)
+		}
+	}
+	if len(e.Allow) > 0 {
+		fmt.Fprintf(w, "allow:\t%s\n", strings.Join(e.Allow, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return 1
+	}
+
+	switch e.Outcome {
+	case fox.OutcomeMatched, fox.OutcomeRelaxedSlash, fox.OutcomeRelaxedPath, fox.OutcomeAutoHEAD:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// load reads the document and registers its routes. Each invalid route is reported with its position in the file.
+// It returns the number of invalid routes, and false if the document cannot be loaded at all.
+func (cmd *command) load(file string) (*table, int, bool) {
+	cmd.file = file
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return nil, 0, false
+	}
+	cmd.data = data
+
+	doc, err := foxconfig.Decode(bytes.NewReader(data))
+	if err != nil {
+		var (
+			syntaxErr *json.SyntaxError
+			typeErr   *json.UnmarshalTypeError
+		)
+		switch {
+		case errors.As(err, &syntaxErr):
+			// The offset is past the offending character.
+			fmt.Fprintf(cmd.stderr, "%s: %s\n", cmd.position(max(int(syntaxErr.Offset)-1, 0)), err)
+		case errors.As(err, &typeErr):
+			fmt.Fprintf(cmd.stderr, "%s: %s\n", cmd.position(int(typeErr.Offset)), err)
+		default:
+			fmt.Fprintf(cmd.stderr, "%s: %s\n", file, err)
+		}
+		return nil, 0, false
+	}
+
+	opts, err := cmd.options()
+	if err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return nil, 0, false
+	}
+	f, err := fox.NewRouter(opts...)
+	if err != nil {
+		fmt.Fprintf(cmd.stderr, "fox: %s\n", err)
+		return nil, 0, false
+	}
+
+	t := &table{
+		router:  f,
+		doc:     doc,
+		configs: make(map[*fox.Route]foxconfig.RouteConfig, len(doc.Routes)),
+	}
+	reg := stubs(doc)
+	offsets := patternOffsets(data)
+	failed := 0
+	for i, rc := range doc.Routes {
+		handler, opts, err := reg.Options(rc)
+		var route *fox.Route
+		if err == nil {
+			route, err = f.Add(rc.Methods, rc.Pattern, handler, opts...)
+		}
+		if err != nil {
+			failed++
+			pos := cmd.file
+			if i < len(offsets) && offsets[i] >= 0 {
+				offset := offsets[i]
+				// Point at the offending character when the pattern is written without JSON escapes.
+				var patternErr *fox.PatternError
+				if errors.As(err, &patternErr) && bytes.HasPrefix(data[offset:], []byte(rc.Pattern)) {
+					offset += patternErr.Offset
+				}
+				pos = cmd.position(offset)
+			}
+			fmt.Fprintf(cmd.stderr, "%s: %s\n", pos, &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: err})
+			continue
+		}
+		t.configs[route] = rc
+	}
+
+	return t, failed, true
+}
+
+func (cmd *command) options() ([]fox.GlobalOption, error) {
+	opts := []fox.GlobalOption{
+		fox.AllowRegexpParam(cmd.allowRegexp),
+		fox.WithNoMethod(cmd.noMethod),
+		fox.WithAutoOptions(cmd.autoOptions),
+		fox.WithAutoHEAD(cmd.autoHEAD),
+		fox.WithClientIPResolver(clientip.NewRemoteAddr()),
+	}
+
+	switch cmd.trailingSlash {
+	case "strict":
+		opts = append(opts, fox.WithHandleTrailingSlash(fox.StrictSlash))
+	case "relaxed":
+		opts = append(opts, fox.WithHandleTrailingSlash(fox.RelaxedSlash))
+	case "redirect":
+		opts = append(opts, fox.WithHandleTrailingSlash(fox.RedirectSlash))
+	default:
+		return nil, fmt.Errorf("invalid trailing slash option %q", cmd.trailingSlash)
+	}
+
+	switch cmd.fixedPath {
+	case "strict":
+		opts = append(opts, fox.WithHandleFixedPath(fox.StrictPath))
+	case "relaxed":
+		opts = append(opts, fox.WithHandleFixedPath(fox.RelaxedPath))
+	case "redirect":
+		opts = append(opts, fox.WithHandleFixedPath(fox.RedirectPath))
+	default:
+		return nil, fmt.Errorf("invalid fixed path option %q", cmd.fixedPath)
+	}
+
+	return opts, nil
+}
+
+// request builds the request to match. The target is either an absolute URL or a path with an optional query string.
+func (cmd *command) request(method, target string) (*http.Request, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	req := &http.Request{
+		Method:     strings.ToUpper(method),
+		URL:        u,
+		Host:       u.Host,
+		RequestURI: u.RequestURI(),
+		RemoteAddr: cmd.remoteAddr,
+		Header:     make(http.Header),
+	}
+	for _, h := range cmd.headers {
+		key, value, _ := strings.Cut(h, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Add(key, value)
+	}
+	return req, nil
+}
+
+// position returns the "file:line:column" position of the byte offset in the file. Columns are counted in bytes,
+// starting at 1.
+func (cmd *command) position(offset int) string {
+	offset = min(offset, len(cmd.data))
+	line := 1 + bytes.Count(cmd.data[:offset], []byte{'\n'})
+	col := offset - bytes.LastIndexByte(cmd.data[:offset], '\n')
+	return fmt.Sprintf("%s:%d:%d", cmd.file, line, col)
+}
+
+// patternOffsets returns, for each route of the document, the byte offset of the first character of its pattern, or
+// -1 if the route has no pattern. It assumes the data is a valid document.
+func patternOffsets(data []byte) []int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var offsets []int
+
+	if !expectDelim(dec, '{') {
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return offsets
+		}
+		if key != "routes" {
+			skipValue(dec)
+			continue
+		}
+		if !expectDelim(dec, '[') {
+			return offsets
+		}
+		for dec.More() {
+			offsets = append(offsets, -1)
+			if !expectDelim(dec, '{') {
+				return offsets
+			}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return offsets
+				}
+				if key != "pattern" {
+					skipValue(dec)
+					continue
+				}
+				start := int(dec.InputOffset())
+				skipValue(dec)
+				if idx := bytes.IndexByte(data[start:], '"'); idx >= 0 {
+					offsets[len(offsets)-1] = start + idx + 1
+				}
+			}
+			if !expectDelim(dec, '}') {
+				return offsets
+			}
+		}
+		return offsets
+	}
+	return offsets
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) bool {
+	tok, err := dec.Token()
+	return err == nil && tok == delim
+}
+
+func skipValue(dec *json.Decoder) {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// stubs returns a registry resolving every handler and middleware referenced in the document to a no-op.
+func stubs(doc *foxconfig.Document) foxconfig.Registry {
+	reg := foxconfig.Registry{
+		Handlers:    make(map[string]fox.HandlerFunc),
+		Middlewares: make(map[string]fox.MiddlewareFunc),
+	}
+	for _, rc := range doc.Routes {
+		reg.Handlers[rc.Handler] = func(c *fox.Context) {}
+		for _, name := range rc.Middlewares {
+			reg.Middlewares[name] = func(next fox.HandlerFunc) fox.HandlerFunc { return next }
+		}
+	}
+	return reg
+}
+
+func methods(m []string) string {
+	if len(m) == 0 {
+		return "*"
+	}
+	return strings.Join(m, ",")
+}
+
+func matchers(route *fox.Route) string {
+	var parts []string
+	for m := range route.Matchers() {
+		if s, ok := m.(fmt.Stringer); ok {
+			parts = append(parts, s.String())
+		} else {
+			parts = append(parts, fmt.Sprintf("%T", m))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// headers is a repeatable flag.
+type headers []string
+
+func (h *headers) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headers) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("invalid header %q: expected 'Key: Value'", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validDoc = `{
+  "routes": [
+    {"methods": ["GET"], "pattern": "/users/{id}", "name": "user", "handler": "getUser"},
+    {"methods": ["GET"], "pattern": "/users/{id}", "handler": "getUserV2", "matchers": [{"type": "header", "key": "X-Version", "value": "2"}]},
+    {"methods": ["GET"], "pattern": "a.example.com/users/{id}", "handler": "tenant", "middlewares": ["auth"]},
+    {"pattern": "/static/*{filepath}", "handler": "static"}
+  ]
+}`
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "routes.json")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func runCmd(args ...string) (int, string, string) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	status := run(args, stdout, stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid document", func(t *testing.T) {
+		file := writeFile(t, validDoc)
+		status, stdout, stderr := runCmd("validate", file)
+		assert.Equal(t, 0, status)
+		assert.Equal(t, file+": 4 routes ok\n", stdout)
+		assert.Empty(t, stderr)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		file := writeFile(t, `{
+  "routes": [
+    {"pattern": "/foo", "handler": "foo"},
+    {
+      "methods": ["GET"],
+      "pattern": "/users/{id",
+      "handler": "bar"
+    },
+    {"pattern": "/foo/{id:[0-9]+}", "handler": "foo"}
+  ]
+}`)
+		status, _, stderr := runCmd("validate", file)
+		assert.Equal(t, 1, status)
+		lines := strings.Split(strings.TrimSpace(stderr), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, file+":6:26: route 1 (/users/{id): invalid route: unclosed '{param}'", lines[0])
+		assert.Equal(t, file+":9:27: route 2 (/foo/{id:[0-9]+}): invalid route: regexp not allowed", lines[1])
+
+		status, _, stderr = runCmd("validate", "-allow-regexp", file)
+		assert.Equal(t, 1, status)
+		assert.Equal(t, 1, strings.Count(stderr, "\n"))
+	})
+
+	t.Run("lint findings", func(t *testing.T) {
+		file := writeFile(t, `{"routes": [
+  {"pattern": "/foo", "handler": "foo", "matchers": [{"type": "query", "key": "a", "value": "1"}], "priority": 5},
+  {"pattern": "/foo", "handler": "foo", "matchers": [{"type": "query", "key": "a", "value": "1"}, {"type": "header", "key": "b", "value": "1"}]}
+]}`)
+		status, _, stderr := runCmd("validate", file)
+		assert.Equal(t, 1, status)
+		assert.Contains(t, stderr, "error: shadowed route")
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		file := writeFile(t, "{\n  \"routes\": [\n    {\"pattern\": \"/foo\",}\n  ]\n}")
+		status, _, stderr := runCmd("validate", file)
+		assert.Equal(t, 1, status)
+		assert.True(t, strings.HasPrefix(stderr, file+":3:24: "), stderr)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		status, _, stderr := runCmd("validate", filepath.Join(t.TempDir(), "missing.json"))
+		assert.Equal(t, 1, status)
+		assert.NotEmpty(t, stderr)
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	file := writeFile(t, validDoc)
+	status, stdout, stderr := runCmd("routes", file)
+	require.Equal(t, 0, status, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, []string{"METHODS", "PATTERN", "NAME", "HANDLER", "MATCHERS", "PRIORITY"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"GET", "a.example.com/users/{id}", "tenant", "0"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"*", "/static/*{filepath}", "static", "0"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"GET", "/users/{id}", "getUserV2", "h:X-Version=2", "1"}, strings.Fields(lines[3]))
+	assert.Equal(t, []string{"GET", "/users/{id}", "user", "getUser", "0"}, strings.Fields(lines[4]))
+}
+
+func TestMatch(t *testing.T) {
+	file := writeFile(t, validDoc)
+
+	cases := []struct {
+		name   string
+		args   []string
+		status int
+		want   []string
+	}{
+		{
+			name:   "hostname",
+			args:   []string{file, "GET", "https://a.example.com/users/42?x=1"},
+			want:   []string{"outcome: matched", "route: GET a.example.com/users/{id}", "handler: tenant", "param: id=42"},
+			status: 0,
+		},
+		{
+			name:   "header matcher",
+			args:   []string{"-H", "X-Version: 2", file, "GET", "/users/42"},
+			want:   []string{"outcome: matched", "route: GET /users/{id}", "handler: getUserV2", "matchers: h:X-Version=2", "param: id=42"},
+			status: 0,
+		},
+		{
+			name:   "host header",
+			args:   []string{"-H", "Host: a.example.com", file, "get", "/users/42"},
+			want:   []string{"outcome: matched", "route: GET a.example.com/users/{id}", "handler: tenant", "param: id=42"},
+			status: 0,
+		},
+		{
+			name:   "method not allowed",
+			args:   []string{"-no-method", file, "POST", "/users/42"},
+			want:   []string{"outcome: method not allowed", "allow: GET"},
+			status: 1,
+		},
+		{
+			name:   "auto head",
+			args:   []string{"-auto-head", file, "HEAD", "/users/42"},
+			want:   []string{"outcome: matched with GET", "route: GET /users/{id}", "name: user", "handler: getUser", "param: id=42"},
+			status: 0,
+		},
+		{
+			name:   "not found",
+			args:   []string{file, "GET", "/foo"},
+			want:   []string{"outcome: not found"},
+			status: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, stdout, stderr := runCmd(append([]string{"match"}, tc.args...)...)
+			assert.Equal(t, tc.status, status, stderr)
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+				got = append(got, strings.Join(strings.Fields(line), " "))
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("explain", func(t *testing.T) {
+		status, stdout, _ := runCmd("match", "-explain", file, "GET", "/static/a/b")
+		assert.Equal(t, 0, status)
+		assert.Contains(t, stdout, `wildcard "*" input="a/b" ok`)
+	})
+}
+
+func TestUsage(t *testing.T) {
+	status, _, stderr := runCmd()
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "Usage:")
+
+	status, _, stderr = runCmd("foo")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, `unknown command "foo"`)
+
+	status, _, _ = runCmd("match", "file.json")
+	assert.Equal(t, 2, status)
+
+	status, stdout, _ := runCmd("help")
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stdout, "-allow-regexp")
+}
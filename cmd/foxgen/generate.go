@@ -0,0 +1,411 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/foxconfig"
+)
+
+// handler is a method of the generated Handlers interface.
+type handler struct {
+	ident  string
+	name   string
+	params []param
+}
+
+// param is a field of a generated params struct.
+type param struct {
+	field string
+	name  string
+}
+
+type route struct {
+	rc      foxconfig.RouteConfig
+	pattern *fox.Pattern
+	handler *handler
+}
+
+type generator struct {
+	buf         bytes.Buffer
+	handlers    []*handler
+	middlewares []string
+	routes      []route
+	needURL     bool
+}
+
+// generate returns the formatted Go source generated from the document.
+func generate(pkg, source string, doc *foxconfig.Document) ([]byte, error) {
+	g := new(generator)
+	if err := g.collect(doc); err != nil {
+		return nil, err
+	}
+
+	g.printf("// Code generated by foxgen from %s. DO NOT EDIT.\n\n", source)
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n")
+	if g.needURL {
+		g.printf("%q\n\n", "net/url")
+	}
+	g.printf("%q\n%q\n)\n\n", "github.com/fox-toolkit/fox", "github.com/fox-toolkit/fox/foxconfig")
+
+	g.printf("// Registrar is implemented by [fox.Router] and [fox.Txn].\n")
+	g.printf("type Registrar interface {\n")
+	g.printf("Add(methods []string, pattern string, handler fox.HandlerFunc, opts ...fox.RouteOption) (*fox.Route, error)\n")
+	g.printf("}\n\n")
+
+	for _, h := range g.handlers {
+		if len(h.params) == 0 {
+			continue
+		}
+		g.printf("// %sParams holds the params captured for the %q handler.\n", h.ident, h.name)
+		g.printf("type %sParams struct {\n", h.ident)
+		for _, p := range h.params {
+			g.printf("%s string // {%s}\n", p.field, p.name)
+		}
+		g.printf("}\n\n")
+	}
+
+	g.printf("// Handlers implements the handlers referenced in %s.\n", source)
+	g.printf("type Handlers interface {\n")
+	for _, h := range g.handlers {
+		if len(h.params) == 0 {
+			g.printf("%s(c *fox.Context)\n", h.ident)
+			continue
+		}
+		g.printf("%s(c *fox.Context, p %sParams)\n", h.ident, h.ident)
+	}
+	g.printf("}\n\n")
+
+	if len(g.middlewares) > 0 {
+		g.printf("// Middlewares provides the middlewares referenced in %s.\n", source)
+		g.printf("type Middlewares interface {\n")
+		for _, name := range g.middlewares {
+			g.printf("%s() fox.MiddlewareFunc\n", exportedIdent(name))
+		}
+		g.printf("}\n\n")
+	}
+
+	for _, r := range g.routes {
+		if r.rc.Name != "" {
+			g.urlFor(r)
+		}
+	}
+
+	g.register(source)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// collect parses the routes of the document and resolves handlers, params and middlewares identifiers.
+func (g *generator) collect(doc *foxconfig.Document) error {
+	handlers := make(map[string]*handler)
+	idents := make(map[string]string)
+	mws := make(map[string]string)
+
+	for i, rc := range doc.Routes {
+		pattern, err := fox.ParsePattern(rc.Pattern, fox.ParseOptions{AllowRegexp: true})
+		if err != nil {
+			return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: err}
+		}
+		params, err := patternParams(pattern)
+		if err != nil {
+			return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: err}
+		}
+
+		h, ok := handlers[rc.Handler]
+		if !ok {
+			ident := exportedIdent(rc.Handler)
+			if ident == "" {
+				return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: fmt.Errorf("invalid handler name '%s'", rc.Handler)}
+			}
+			if other, ok := idents[ident]; ok {
+				return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: fmt.Errorf("handlers '%s' and '%s' have the same identifier %s", other, rc.Handler, ident)}
+			}
+			idents[ident] = rc.Handler
+			h = &handler{ident: ident, name: rc.Handler, params: params}
+			handlers[rc.Handler] = h
+			g.handlers = append(g.handlers, h)
+		} else if !slices.Equal(h.params, params) {
+			return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: fmt.Errorf("handler '%s' is shared by routes with different params", rc.Handler)}
+		}
+
+		for _, name := range rc.Middlewares {
+			ident := exportedIdent(name)
+			if ident == "" {
+				return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: fmt.Errorf("invalid middleware name '%s'", name)}
+			}
+			if other, ok := mws[ident]; !ok {
+				mws[ident] = name
+				g.middlewares = append(g.middlewares, name)
+			} else if other != name {
+				return &foxconfig.RouteError{Index: i, Pattern: rc.Pattern, Err: fmt.Errorf("middlewares '%s' and '%s' have the same identifier %s", other, name, ident)}
+			}
+		}
+
+		if rc.Name != "" {
+			for _, seg := range pattern.Path {
+				if seg.Kind != fox.SegmentStatic {
+					g.needURL = true
+				}
+			}
+		}
+
+		g.routes = append(g.routes, route{rc: rc, pattern: pattern, handler: h})
+	}
+
+	return nil
+}
+
+// urlFor generates the URLFor function of a named route.
+func (g *generator) urlFor(r route) {
+	ident := exportedIdent(r.rc.Name)
+	var exprs []string
+	literal := func(s string) {
+		if n := len(exprs); n > 0 && strings.HasPrefix(exprs[n-1], `"`) {
+			prev, _ := strconv.Unquote(exprs[n-1])
+			exprs[n-1] = strconv.Quote(prev + s)
+			return
+		}
+		exprs = append(exprs, strconv.Quote(s))
+	}
+
+	if len(r.pattern.Host) > 0 {
+		literal("//")
+	}
+	for _, seg := range r.pattern.Host {
+		if seg.Kind == fox.SegmentStatic {
+			literal(seg.Value)
+			continue
+		}
+		exprs = append(exprs, "p."+fieldOf(r.handler, seg.Value))
+	}
+	for _, seg := range r.pattern.Path {
+		switch seg.Kind {
+		case fox.SegmentStatic:
+			literal(seg.Value)
+		case fox.SegmentParam:
+			exprs = append(exprs, "url.PathEscape(p."+fieldOf(r.handler, seg.Value)+")")
+		default:
+			exprs = append(exprs, "(&url.URL{Path: p."+fieldOf(r.handler, seg.Value)+"}).EscapedPath()")
+		}
+	}
+
+	g.printf("// URLFor%s returns the URL of the %q route (%s).\n", ident, r.rc.Name, r.rc.Pattern)
+	if len(r.pattern.Host) > 0 {
+		g.printf("// The URL is scheme relative.\n")
+	}
+	if len(r.handler.params) == 0 {
+		g.printf("func URLFor%s() string {\n", ident)
+	} else {
+		g.printf("func URLFor%s(p %sParams) string {\n", ident, r.handler.ident)
+	}
+	g.printf("return %s\n}\n\n", strings.Join(exprs, " + "))
+}
+
+// register generates the Register function.
+func (g *generator) register(source string) {
+	g.printf("// Register registers all routes of %s on r, in order. It stops at the first error, which is wrapped in a\n", source)
+	g.printf("// [foxconfig.RouteError].\n")
+	if len(g.middlewares) > 0 {
+		g.printf("func Register(r Registrar, h Handlers, m Middlewares) error {\n")
+	} else {
+		g.printf("func Register(r Registrar, h Handlers) error {\n")
+	}
+
+	for i, r := range g.routes {
+		methods := "fox.MethodAny"
+		if len(r.rc.Methods) > 0 {
+			quoted := make([]string, 0, len(r.rc.Methods))
+			for _, m := range r.rc.Methods {
+				quoted = append(quoted, strconv.Quote(m))
+			}
+			methods = "[]string{" + strings.Join(quoted, ", ") + "}"
+		}
+
+		g.printf("if _, err := r.Add(%s, %q, func(c *fox.Context) {\n", methods, r.rc.Pattern)
+		if len(r.handler.params) == 0 {
+			g.printf("h.%s(c)\n", r.handler.ident)
+		} else {
+			g.printf("h.%s(c, %sParams{\n", r.handler.ident, r.handler.ident)
+			for _, p := range r.handler.params {
+				g.printf("%s: c.Param(%q),\n", p.field, p.name)
+			}
+			g.printf("})\n")
+		}
+		g.printf("}")
+		if opts := routeOptions(r.rc); len(opts) > 0 {
+			g.printf(",\n%s,\n", strings.Join(opts, ",\n"))
+		}
+		g.printf("); err != nil {\n")
+		g.printf("return &foxconfig.RouteError{Index: %d, Pattern: %q, Err: err}\n}\n", i, r.rc.Pattern)
+	}
+
+	g.printf("return nil\n}\n")
+}
+
+// routeOptions returns the route options expressions equivalent to the route configuration. The configuration is
+// assumed to be valid, since it is validated once registered.
+func routeOptions(rc foxconfig.RouteConfig) []string {
+	// Record the handler and middleware names, so that the routes can be exported with foxconfig.Export.
+	opts := []string{fmt.Sprintf("foxconfig.WithHandlerName(%q)", rc.Handler)}
+	if rc.Name != "" {
+		opts = append(opts, fmt.Sprintf("fox.WithName(%q)", rc.Name))
+	}
+
+	if len(rc.Middlewares) > 0 {
+		mws := make([]string, 0, len(rc.Middlewares))
+		for _, name := range rc.Middlewares {
+			mws = append(mws, "m."+exportedIdent(name)+"()")
+		}
+		names := make([]string, 0, len(rc.Middlewares))
+		for _, name := range rc.Middlewares {
+			names = append(names, strconv.Quote(name))
+		}
+		opts = append(opts, "fox.WithMiddleware("+strings.Join(mws, ", ")+")")
+		opts = append(opts, "foxconfig.WithMiddlewareNames("+strings.Join(names, ", ")+")")
+	}
+
+	for _, mc := range rc.Matchers {
+		switch strings.ToLower(mc.Type) {
+		case foxconfig.MatcherQuery:
+			opts = append(opts, fmt.Sprintf("fox.WithQueryMatcher(%q, %q)", mc.Key, mc.Value))
+		case foxconfig.MatcherQueryRegexp:
+			opts = append(opts, fmt.Sprintf("fox.WithQueryRegexpMatcher(%q, %q)", mc.Key, mc.Value))
+		case foxconfig.MatcherHeader:
+			opts = append(opts, fmt.Sprintf("fox.WithHeaderMatcher(%q, %q)", mc.Key, mc.Value))
+		case foxconfig.MatcherHeaderRegexp:
+			opts = append(opts, fmt.Sprintf("fox.WithHeaderRegexpMatcher(%q, %q)", mc.Key, mc.Value))
+		case foxconfig.MatcherClientIP:
+			opts = append(opts, fmt.Sprintf("fox.WithClientIPMatcher(%q)", mc.Value))
+		}
+	}
+
+	if rc.Priority > 0 {
+		opts = append(opts, fmt.Sprintf("fox.WithMatcherPriority(%d)", rc.Priority))
+	}
+
+	switch strings.ToLower(rc.TrailingSlash) {
+	case foxconfig.TrailingSlashStrict:
+		opts = append(opts, "fox.WithHandleTrailingSlash(fox.StrictSlash)")
+	case foxconfig.TrailingSlashRelaxed:
+		opts = append(opts, "fox.WithHandleTrailingSlash(fox.RelaxedSlash)")
+	case foxconfig.TrailingSlashRedirect:
+		opts = append(opts, "fox.WithHandleTrailingSlash(fox.RedirectSlash)")
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(rc.Annotations)) {
+		opts = append(opts, fmt.Sprintf("fox.WithAnnotation(foxconfig.AnnotationKey(%q), %s)", k, literal(rc.Annotations[k])))
+	}
+
+	return opts
+}
+
+// literal returns the Go expression of a value decoded from JSON. Numbers are typed as float64, as they are when the
+// document is loaded with foxconfig, rather than being left as untyped constants.
+func literal(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")"
+	case []any:
+		elems := make([]string, 0, len(v))
+		for _, e := range v {
+			elems = append(elems, literal(e))
+		}
+		return "[]any{" + strings.Join(elems, ", ") + "}"
+	case map[string]any:
+		elems := make([]string, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			elems = append(elems, strconv.Quote(k)+": "+literal(v[k]))
+		}
+		return "map[string]any{" + strings.Join(elems, ", ") + "}"
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// patternParams returns the params struct fields of a pattern.
+func patternParams(pattern *fox.Pattern) ([]param, error) {
+	var params []param
+	fields := make(map[string]string)
+	for _, name := range pattern.Params() {
+		field := exportedIdent(name)
+		if field == "" {
+			return nil, fmt.Errorf("invalid param name '%s'", name)
+		}
+		if other, ok := fields[field]; ok {
+			return nil, fmt.Errorf("params '%s' and '%s' have the same field name %s", other, name, field)
+		}
+		fields[field] = name
+		params = append(params, param{field: field, name: name})
+	}
+	return params, nil
+}
+
+func fieldOf(h *handler, name string) string {
+	for _, p := range h.params {
+		if p.name == name {
+			return p.field
+		}
+	}
+	return ""
+}
+
+// commonInitialisms are written in upper case in Go identifiers, following the Go naming conventions.
+var commonInitialisms = map[string]bool{
+	"api": true, "css": true, "dns": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "ssh": true, "tcp": true, "tls": true, "ttl": true, "udp": true, "ui": true,
+	"uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// exportedIdent converts a name such as "user_id" or "getUser" to an exported Go identifier ("UserID", "GetUser").
+// It returns an empty string if no valid identifier can be derived from the name.
+func exportedIdent(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, part := range parts {
+		if commonInitialisms[strings.ToLower(part)] {
+			sb.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(part[size:])
+	}
+
+	ident := sb.String()
+	if !token.IsIdentifier(ident) || !token.IsExported(ident) {
+		return ""
+	}
+	return ident
+}
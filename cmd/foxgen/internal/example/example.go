@@ -0,0 +1,9 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Package example holds the code generated by foxgen from routes.json. It is compiled with the rest of the module,
+// which ensures that the generated code builds.
+package example
+
+//go:generate go run github.com/fox-toolkit/fox/cmd/foxgen -o routes_gen.go routes.json
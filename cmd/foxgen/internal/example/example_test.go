@@ -0,0 +1,100 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package example
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/foxconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type handlers struct{}
+
+func (handlers) GetUser(c *fox.Context, p GetUserParams) {
+	_ = c.String(http.StatusOK, "user:"+p.UserID)
+}
+
+func (handlers) TenantFile(c *fox.Context, p TenantFileParams) {
+	_ = c.String(http.StatusOK, p.Tenant+":"+p.Path)
+}
+
+func (handlers) Health(c *fox.Context) {
+	_ = c.String(http.StatusOK, "ok")
+}
+
+type middlewares struct{}
+
+func (middlewares) Auth() fox.MiddlewareFunc {
+	return func(next fox.HandlerFunc) fox.HandlerFunc {
+		return func(c *fox.Context) {
+			c.SetHeader("X-Auth", "ok")
+			next(c)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	f := fox.MustRouter()
+	require.NoError(t, Register(f, handlers{}, middlewares{}))
+
+	cases := []struct {
+		target string
+		want   string
+	}{
+		{target: URLForUser(GetUserParams{UserID: "42"}), want: "user:42"},
+		{target: "http:" + URLForTenantFile(TenantFileParams{Tenant: "acme", Path: "a/b c.txt"}), want: "acme:a/b%20c.txt"},
+		{target: URLForHealth(), want: "ok"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, tc.target)
+		assert.Equal(t, tc.want, w.Body.String(), tc.target)
+	}
+
+	assert.Equal(t, "/users/a%2Fb", URLForUser(GetUserParams{UserID: "a/b"}))
+	assert.Equal(t, "//acme.example.com/files/a/b%20c.txt", URLForTenantFile(TenantFileParams{Tenant: "acme", Path: "a/b c.txt"}))
+	assert.Error(t, Register(f, handlers{}, middlewares{}))
+}
+
+func TestRegisterExport(t *testing.T) {
+	f := fox.MustRouter()
+	require.NoError(t, Register(f, handlers{}, middlewares{}))
+
+	route := f.Name("tenant_file")
+	require.NotNil(t, route)
+	assert.Equal(t, float64(2), route.Annotation(foxconfig.AnnotationKey("weight")))
+
+	// Routes registered by the generated code are exported as if they were loaded from the document.
+	exported, err := foxconfig.Export(f.Iter())
+	require.NoError(t, err)
+
+	file, err := os.Open("routes.json")
+	require.NoError(t, err)
+	defer file.Close()
+	doc, err := foxconfig.Decode(file)
+	require.NoError(t, err)
+
+	loaded := fox.MustRouter()
+	require.NoError(t, foxconfig.Load(loaded, doc, foxconfig.Registry{
+		Handlers: map[string]fox.HandlerFunc{
+			"getUser":    func(c *fox.Context) {},
+			"tenantFile": func(c *fox.Context) {},
+			"health":     func(c *fox.Context) {},
+		},
+		Middlewares: map[string]fox.MiddlewareFunc{"auth": middlewares{}.Auth()},
+	}))
+	want, err := foxconfig.Export(loaded.Iter())
+	require.NoError(t, err)
+	assert.Equal(t, want, exported)
+}
@@ -0,0 +1,105 @@
+// Code generated by foxgen from routes.json. DO NOT EDIT.
+
+package example
+
+import (
+	"net/url"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/foxconfig"
+)
+
+// Registrar is implemented by [fox.Router] and [fox.Txn].
+type Registrar interface {
+	Add(methods []string, pattern string, handler fox.HandlerFunc, opts ...fox.RouteOption) (*fox.Route, error)
+}
+
+// GetUserParams holds the params captured for the "getUser" handler.
+type GetUserParams struct {
+	UserID string // {user_id}
+}
+
+// TenantFileParams holds the params captured for the "tenantFile" handler.
+type TenantFileParams struct {
+	Tenant string // {tenant}
+	Path   string // {path}
+}
+
+// Handlers implements the handlers referenced in routes.json.
+type Handlers interface {
+	GetUser(c *fox.Context, p GetUserParams)
+	TenantFile(c *fox.Context, p TenantFileParams)
+	Health(c *fox.Context)
+}
+
+// Middlewares provides the middlewares referenced in routes.json.
+type Middlewares interface {
+	Auth() fox.MiddlewareFunc
+}
+
+// URLForUser returns the URL of the "user" route (/users/{user_id}).
+func URLForUser(p GetUserParams) string {
+	return "/users/" + url.PathEscape(p.UserID)
+}
+
+// URLForTenantFile returns the URL of the "tenant_file" route ({tenant}.example.com/files/+{path}).
+// The URL is scheme relative.
+func URLForTenantFile(p TenantFileParams) string {
+	return "//" + p.Tenant + ".example.com/files/" + (&url.URL{Path: p.Path}).EscapedPath()
+}
+
+// URLForHealth returns the URL of the "health" route (/health).
+func URLForHealth() string {
+	return "/health"
+}
+
+// Register registers all routes of routes.json on r, in order. It stops at the first error, which is wrapped in a
+// [foxconfig.RouteError].
+func Register(r Registrar, h Handlers, m Middlewares) error {
+	if _, err := r.Add([]string{"GET", "HEAD"}, "/users/{user_id}", func(c *fox.Context) {
+		h.GetUser(c, GetUserParams{
+			UserID: c.Param("user_id"),
+		})
+	},
+		foxconfig.WithHandlerName("getUser"),
+		fox.WithName("user"),
+		fox.WithMiddleware(m.Auth()),
+		foxconfig.WithMiddlewareNames("auth"),
+	); err != nil {
+		return &foxconfig.RouteError{Index: 0, Pattern: "/users/{user_id}", Err: err}
+	}
+	if _, err := r.Add([]string{"GET"}, "/users/{user_id}", func(c *fox.Context) {
+		h.GetUser(c, GetUserParams{
+			UserID: c.Param("user_id"),
+		})
+	},
+		foxconfig.WithHandlerName("getUser"),
+		fox.WithHeaderMatcher("X-Version", "2"),
+		fox.WithMatcherPriority(5),
+	); err != nil {
+		return &foxconfig.RouteError{Index: 1, Pattern: "/users/{user_id}", Err: err}
+	}
+	if _, err := r.Add([]string{"GET"}, "{tenant}.example.com/files/+{path}", func(c *fox.Context) {
+		h.TenantFile(c, TenantFileParams{
+			Tenant: c.Param("tenant"),
+			Path:   c.Param("path"),
+		})
+	},
+		foxconfig.WithHandlerName("tenantFile"),
+		fox.WithName("tenant_file"),
+		fox.WithHandleTrailingSlash(fox.RelaxedSlash),
+		fox.WithAnnotation(foxconfig.AnnotationKey("team"), "storage"),
+		fox.WithAnnotation(foxconfig.AnnotationKey("weight"), float64(2)),
+	); err != nil {
+		return &foxconfig.RouteError{Index: 2, Pattern: "{tenant}.example.com/files/+{path}", Err: err}
+	}
+	if _, err := r.Add(fox.MethodAny, "/health", func(c *fox.Context) {
+		h.Health(c)
+	},
+		foxconfig.WithHandlerName("health"),
+		fox.WithName("health"),
+	); err != nil {
+		return &foxconfig.RouteError{Index: 3, Pattern: "/health", Err: err}
+	}
+	return nil
+}
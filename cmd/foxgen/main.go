@@ -0,0 +1,101 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Command foxgen generates typed route handlers from a [foxconfig] JSON document, so that renaming a param in a
+// pattern breaks the build instead of making [fox.Context.Param] silently return an empty string.
+//
+// Usage:
+//
+//	foxgen [flags] FILE
+//
+// It is meant to be invoked with go generate:
+//
+//	//go:generate go run github.com/fox-toolkit/fox/cmd/foxgen -o routes_gen.go routes.json
+//
+// For every handler referenced in the document, foxgen generates a params struct with one string field per param and
+// wildcard, and a method in the Handlers interface receiving it. The fields hold the values returned by
+// [fox.Context.Param], which are not unescaped. Handlers shared by several routes must capture the same params.
+// Middlewares referenced in the document are resolved through the Middlewares interface. Named routes get a URLFor
+// function building their URL from the params struct. Finally, the Register function registers every route of the
+// document with its matchers and options, in order. Routes also record their handler and middleware names, so they
+// can be exported with [foxconfig.Export], and numeric annotations are registered as float64, as with [foxconfig.Load].
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fox-toolkit/fox/foxconfig"
+)
+
+const usage = `Usage:
+  foxgen [flags] FILE
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("foxgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file, defaults to $GOPACKAGE")
+	out := fs.String("o", "", "output file, defaults to FILE with a _gen.go suffix, or - for stdout")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fmt.Fprintln(stderr, "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *pkg == "" {
+		fmt.Fprintln(stderr, "foxgen: missing package name, use -pkg or run with go generate")
+		return 2
+	}
+
+	file := fs.Arg(0)
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "foxgen: %s\n", err)
+		return 1
+	}
+	doc, err := foxconfig.Decode(f)
+	_ = f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "foxgen: %s: %s\n", file, err)
+		return 1
+	}
+
+	src, err := generate(*pkg, filepath.Base(file), doc)
+	if err != nil {
+		fmt.Fprintf(stderr, "foxgen: %s: %s\n", file, err)
+		return 1
+	}
+
+	switch *out {
+	case "-":
+		_, err = stdout.Write(src)
+	case "":
+		*out = strings.TrimSuffix(file, filepath.Ext(file)) + "_gen.go"
+		fallthrough
+	default:
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "foxgen: %s\n", err)
+		return 1
+	}
+	return 0
+}
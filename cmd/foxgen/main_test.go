@@ -0,0 +1,134 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCmd(args ...string) (int, string, string) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	status := run(args, stdout, stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestGenerate_UpToDate(t *testing.T) {
+	status, stdout, stderr := runCmd("-pkg", "example", "-o", "-", filepath.Join("internal", "example", "routes.json"))
+	require.Equal(t, 0, status, stderr)
+
+	want, err := os.ReadFile(filepath.Join("internal", "example", "routes_gen.go"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), stdout, "generated code is out of date, run go generate ./...")
+}
+
+func TestGenerate_OutputFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "api.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"routes": [{"pattern": "/ping", "handler": "ping"}]}`), 0o600))
+
+	status, _, stderr := runCmd("-pkg", "api", file)
+	require.Equal(t, 0, status, stderr)
+	src, err := os.ReadFile(filepath.Join(dir, "api_gen.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "package api\n")
+	assert.Contains(t, string(src), "Ping(c *fox.Context)\n")
+	assert.Contains(t, string(src), "func Register(r Registrar, h Handlers) error {")
+	assert.NotContains(t, string(src), `"net/url"`)
+}
+
+func TestGenerate_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "invalid pattern",
+			doc:  `{"routes": [{"pattern": "/users/{id", "handler": "user"}]}`,
+			want: "route 0 (/users/{id): invalid route: unclosed '{param}'",
+		},
+		{
+			name: "shared handler with different params",
+			doc:  `{"routes": [{"pattern": "/users/{id}", "handler": "user"}, {"pattern": "/users/{name}/x", "handler": "user"}]}`,
+			want: "route 1 (/users/{name}/x): handler 'user' is shared by routes with different params",
+		},
+		{
+			name: "handler identifier collision",
+			doc:  `{"routes": [{"pattern": "/a", "handler": "get_user"}, {"pattern": "/b", "handler": "getUser"}]}`,
+			want: "route 1 (/b): handlers 'get_user' and 'getUser' have the same identifier GetUser",
+		},
+		{
+			name: "param field collision",
+			doc:  `{"routes": [{"pattern": "/{user_id}/{userID}", "handler": "user"}]}`,
+			want: "route 0 (/{user_id}/{userID}): params 'user_id' and 'userID' have the same field name UserID",
+		},
+		{
+			name: "invalid handler name",
+			doc:  `{"routes": [{"pattern": "/a", "handler": "42"}]}`,
+			want: "route 0 (/a): invalid handler name '42'",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "routes.json")
+			require.NoError(t, os.WriteFile(file, []byte(tc.doc), 0o600))
+			status, _, stderr := runCmd("-pkg", "routes", "-o", "-", file)
+			assert.Equal(t, 1, status)
+			assert.Equal(t, "foxgen: "+file+": "+tc.want+"\n", stderr)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	status, _, stderr := runCmd()
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "Usage:")
+
+	t.Setenv("GOPACKAGE", "")
+	status, _, stderr = runCmd("routes.json")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "missing package name")
+}
+
+func TestExportedIdent(t *testing.T) {
+	cases := map[string]string{
+		"id":          "ID",
+		"user_id":     "UserID",
+		"getUser":     "GetUser",
+		"tenant-file": "TenantFile",
+		"api.v2":      "APIV2",
+		"42":          "",
+		"_":           "",
+	}
+	for name, want := range cases {
+		assert.Equal(t, want, exportedIdent(name), name)
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	cases := []struct {
+		value any
+		want  string
+	}{
+		{value: nil, want: "nil"},
+		{value: "a\"b", want: `"a\"b"`},
+		{value: true, want: "true"},
+		{value: float64(2), want: "float64(2)"},
+		{value: 1.5e21, want: "float64(1.5e+21)"},
+		{value: []any{"a", float64(1)}, want: `[]any{"a", float64(1)}`},
+		{value: map[string]any{"b": float64(1), "a": []any{}}, want: `map[string]any{"a": []any{}, "b": float64(1)}`},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, literal(tc.value))
+	}
+}
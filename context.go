@@ -6,8 +6,11 @@ package fox
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"iter"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -71,11 +74,20 @@ type Context struct {
 	subPatterns   *[]string
 	skipStack     *skipStack
 	route         *Route
+	logger        *slog.Logger
+	loggerRoute   *Route  // the route the logger was created for
 	tree          *iTree  // no reset
 	fox           *Router // no reset
 	pattern       string
+	origPath      string
+	reqID         string
 	cachedQueries url.Values
 	rec           recorder
+	trace         *tracer // nil unless called from Router.Explain
+	err           error
+	store         []keyValue
+	errScope      int
+	reroutes      int
 	scope         HandlerScope
 }
 
@@ -89,6 +101,12 @@ func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
 	c.w = &c.rec
 	c.cachedQueries = nil
 	c.scope = RouteHandler
+	c.err = nil
+	c.errScope = 0
+	c.origPath = ""
+	c.reroutes = 0
+	c.resetLogger()
+	c.resetStore()
 	*c.params = (*c.params)[:0]
 	*c.subPatterns = (*c.subPatterns)[:0]
 }
@@ -98,6 +116,12 @@ func (c *Context) resetNil() {
 	c.w = nil
 	c.cachedQueries = nil
 	c.route = nil
+	c.err = nil
+	c.errScope = 0
+	c.origPath = ""
+	c.reroutes = 0
+	c.resetLogger()
+	c.resetStore()
 	*c.params = (*c.params)[:0]
 	*c.subPatterns = (*c.subPatterns)[:0]
 }
@@ -109,6 +133,7 @@ func (c *Context) resetWithRequest(r *http.Request) {
 	c.w = nil
 	c.cachedQueries = nil
 	c.route = nil
+	c.resetStore()
 	*c.params = (*c.params)[:0]
 }
 
@@ -119,6 +144,12 @@ func (c *Context) resetWithWriter(w ResponseWriter, r *http.Request) {
 	c.w = w
 	c.cachedQueries = nil
 	c.scope = RouteHandler
+	c.err = nil
+	c.errScope = 0
+	c.origPath = ""
+	c.reroutes = 0
+	c.resetLogger()
+	c.resetStore()
 	*c.params = (*c.params)[:0]
 	*c.subPatterns = (*c.subPatterns)[:0]
 }
@@ -169,14 +200,19 @@ func (c *Context) RemoteIP() *net.IPAddr {
 // The resolver used must be chosen and tuned for your network configuration. This should result
 // in a resolver never returning an error -- i.e., never failing to find a candidate for the "real" IP.
 // Consequently, getting an error result should be treated as an application error, perhaps even
-// worthy of panicking.
+// worthy of panicking. Errors returned by the resolver wrap [ErrInvalidClientIP], which the [DefaultErrorHandler]
+// turns into a "400 Bad Request".
 func (c *Context) ClientIP() (*net.IPAddr, error) {
+	resolver := c.fox.clientip
 	// We may be in a handler which does not match a route like NotFound handler.
-	if c.route == nil {
-		resolver := c.fox.clientip
-		return resolver.ClientIP(c)
+	if c.route != nil {
+		resolver = c.route.clientip
 	}
-	return c.route.clientip.ClientIP(c)
+	ip, err := resolver.ClientIP(c)
+	if err != nil && !errors.Is(err, ErrNoClientIPResolver) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidClientIP, err)
+	}
+	return ip, err
 }
 
 // Params returns an iterator over the matched wildcard parameters for the current route.
@@ -304,12 +340,18 @@ func (c *Context) Router() *Router {
 // Any attempt to write on the [ResponseWriter] will panic with the error [ErrDiscardedResponseWriter].
 func (c *Context) Clone() *Context {
 	cp := Context{
-		rec:     c.rec,
-		req:     c.req.Clone(c.req.Context()),
-		fox:     c.fox, // Note: no tree here so Context.Close is noop.
-		route:   c.route,
-		scope:   c.scope,
-		pattern: c.pattern,
+		rec:         c.rec,
+		req:         c.req.Clone(c.req.Context()),
+		fox:         c.fox, // Note: no tree here so Context.Close is noop.
+		route:       c.route,
+		scope:       c.scope,
+		pattern:     c.pattern,
+		origPath:    c.origPath,
+		reroutes:    c.reroutes,
+		logger:      c.logger,
+		loggerRoute: c.loggerRoute,
+		reqID:       c.reqID,
+		store:       slices.Clone(c.store),
 	}
 
 	cp.rec.ResponseWriter = noopWriter{c.rec.Header().Clone()}
@@ -343,6 +385,15 @@ func (c *Context) CloneWith(w ResponseWriter, r *http.Request) *Context {
 	cp.scope = c.scope
 	cp.pattern = c.pattern
 	cp.cachedQueries = nil // For safety, in case r is a different request than c.req
+	cp.err = c.err
+	cp.errScope = c.errScope
+	cp.origPath = c.origPath
+	cp.reroutes = c.reroutes
+	cp.logger = c.logger
+	cp.loggerRoute = c.loggerRoute
+	cp.reqID = c.reqID
+	cp.resetStore()
+	cp.store = append(cp.store, c.store...)
 
 	copyWithResize(cp.subPatterns, c.subPatterns)
 	copyWithResize(cp.paramsKeys, c.paramsKeys)
@@ -419,9 +470,12 @@ func (hw wrapH) handle(c *Context) {
 	defer func() { req.Pattern = p }()
 
 	req.Pattern = c.Pattern()
+	ctx := c.withStore(req.Context())
 	if route := c.Route(); route != nil && route.ParamsLen() > 0 {
 		params := slices.AppendSeq(make(Params, 0, route.ParamsLen()), c.Params())
-		ctx := context.WithValue(req.Context(), paramsKey, params)
+		ctx = context.WithValue(ctx, paramsKey, params)
+	}
+	if ctx != req.Context() {
 		hw.h.ServeHTTP(c.Writer(), req.WithContext(ctx))
 		return
 	}
@@ -441,9 +495,12 @@ func (mw wrapM) handle(c *Context) {
 	defer func() { req.Pattern = p }()
 
 	req.Pattern = c.Pattern()
+	ctx := c.withStore(req.Context())
 	if route := c.Route(); route != nil && route.ParamsLen() > 0 {
 		params := slices.AppendSeq(make(Params, 0, route.ParamsLen()), c.Params())
-		ctx := context.WithValue(c.Request().Context(), paramsKey, params)
+		ctx = context.WithValue(ctx, paramsKey, params)
+	}
+	if ctx != req.Context() {
 		req = req.WithContext(ctx)
 	}
 
@@ -457,6 +514,8 @@ func (mw wrapM) handle(c *Context) {
 		cc := c.CloneWith(rec, r)
 		defer cc.Close()
 		mw.next(cc)
+		// Propagate the error recorded by an error-returning handler.
+		c.err = cc.err
 	})).ServeHTTP(c.Writer(), req)
 }
 
@@ -0,0 +1,210 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Package debug provides an introspection handler which serves the live routing table of a Fox router as HTML and
+// JSON, along with the router configuration, tree statistics and a form to test-match an arbitrary request against
+// the current tree. The handler is typically mounted on a catch-all route:
+//
+//	f.MustAdd(fox.MethodGet, "/debug/fox/*{any}", debug.Handler())
+//
+// The following endpoints are served, relative to the mount point:
+//   - "/": the HTML page. If the "method", "host" or "path" query parameters are provided, the page also
+//     displays the result of matching this request.
+//   - "/routes.json": the routing table, router configuration and tree statistics as JSON.
+//   - "/match.json?method=GET&host=example.com&path=/foo": the match result as JSON.
+//
+// The routing table may disclose sensitive information, the handler should never be exposed publicly.
+package debug
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fox-toolkit/fox"
+)
+
+//go:embed debug.html
+var page string
+
+var tmpl = template.Must(template.New("debug").Parse(page))
+
+// Route describes a registered route.
+type Route struct {
+	Pattern       string            `json:"pattern"`
+	Methods       []string          `json:"methods,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Hostname      string            `json:"hostname,omitempty"`
+	Matchers      []string          `json:"matchers,omitempty"`
+	Priority      uint              `json:"priority"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Middlewares   int               `json:"middlewares"`
+	TrailingSlash string            `json:"trailing_slash"`
+}
+
+// Table describes the routing table of a router.
+type Table struct {
+	Info   fox.RouterInfo `json:"info"`
+	Stats  fox.TreeStats  `json:"stats"`
+	Routes []Route        `json:"routes"`
+}
+
+// MatchResult describes the result of matching a request against the routing tree with [fox.Router.Match].
+type MatchResult struct {
+	Method  string `json:"method"`
+	Host    string `json:"host"`
+	Path    string `json:"path"`
+	Matched bool   `json:"matched"`
+	// Tsr indicates that the route was matched by adding or removing a trailing slash.
+	Tsr   bool   `json:"tsr"`
+	Route *Route `json:"route,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Handler returns a [fox.HandlerFunc] serving the routing table of the router handling the request.
+// See [HandlerFor] to serve the routing table of another router.
+func Handler() fox.HandlerFunc {
+	return func(c *fox.Context) {
+		serve(c, c.Router())
+	}
+}
+
+// HandlerFor returns a [fox.HandlerFunc] serving the routing table of the provided router.
+func HandlerFor(f *fox.Router) fox.HandlerFunc {
+	return func(c *fox.Context) {
+		serve(c, f)
+	}
+}
+
+// Snapshot returns the current routing table of the router. Routes are sorted by pattern, then methods. Routes
+// sharing the same pattern and methods are kept in lookup order.
+func Snapshot(f *fox.Router) Table {
+	table := Table{
+		Info:   f.RouterInfo(),
+		Stats:  f.TreeStats(),
+		Routes: make([]Route, 0, f.Len()),
+	}
+	for route := range f.Iter().All() {
+		table.Routes = append(table.Routes, describe(route))
+	}
+	slices.SortStableFunc(table.Routes, func(a, b Route) int {
+		return cmp.Or(strings.Compare(a.Pattern, b.Pattern), slices.Compare(a.Methods, b.Methods))
+	})
+	return table
+}
+
+// Match matches the given method, host and path against the routing tree of the router. The path may include a
+// query string, which is used by query matchers.
+func Match(f *fox.Router, method, host, path string) MatchResult {
+	res := MatchResult{Method: method, Host: host, Path: path}
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	req := &http.Request{
+		Method:     method,
+		Host:       host,
+		URL:        u,
+		RequestURI: path,
+		Header:     make(http.Header),
+	}
+	route, tsr := f.Match(method, req)
+	if route != nil {
+		desc := describe(route)
+		res.Matched, res.Tsr, res.Route = true, tsr, &desc
+	}
+	return res
+}
+
+func describe(route *fox.Route) Route {
+	r := Route{
+		Pattern:     route.Pattern(),
+		Methods:     slices.Collect(route.Methods()),
+		Name:        route.Name(),
+		Hostname:    route.Hostname(),
+		Priority:    route.MatchersPriority(),
+		Middlewares: route.MiddlewaresLen(),
+	}
+
+	for m := range route.Matchers() {
+		if s, ok := m.(fmt.Stringer); ok {
+			r.Matchers = append(r.Matchers, s.String())
+		} else {
+			r.Matchers = append(r.Matchers, reflect.TypeOf(m).String())
+		}
+	}
+
+	for k, v := range route.Annotations() {
+		if r.Annotations == nil {
+			r.Annotations = make(map[string]string)
+		}
+		key := fmt.Sprintf("%T", k)
+		if rk := reflect.ValueOf(k); rk.Kind() == reflect.String {
+			key = rk.String()
+		}
+		r.Annotations[key] = fmt.Sprint(v)
+	}
+
+	switch route.TrailingSlashOption() {
+	case fox.StrictSlash:
+		r.TrailingSlash = "strict"
+	case fox.RelaxedSlash:
+		r.TrailingSlash = "relaxed"
+	case fox.RedirectSlash:
+		r.TrailingSlash = "redirect"
+	}
+
+	return r
+}
+
+func serve(c *fox.Context, f *fox.Router) {
+	path := c.Path()
+	switch {
+	case strings.HasSuffix(path, "/routes.json"):
+		writeJSON(c, Snapshot(f))
+	case strings.HasSuffix(path, "/match.json"):
+		writeJSON(c, match(c, f))
+	default:
+		data := struct {
+			Table
+			Match *MatchResult
+		}{
+			Table: Snapshot(f),
+		}
+		if c.QueryParam("method") != "" || c.QueryParam("host") != "" || c.QueryParam("path") != "" {
+			res := match(c, f)
+			data.Match = &res
+		}
+
+		c.SetHeader(fox.HeaderContentType, fox.MIMETextHTMLCharsetUTF8)
+		c.SetHeader(fox.HeaderCacheControl, "no-store")
+		c.Writer().WriteHeader(http.StatusOK)
+		_ = tmpl.Execute(c.Writer(), data)
+	}
+}
+
+func match(c *fox.Context, f *fox.Router) MatchResult {
+	method := cmp.Or(c.QueryParam("method"), http.MethodGet)
+	path := cmp.Or(c.QueryParam("path"), "/")
+	return Match(f, method, c.QueryParam("host"), path)
+}
+
+func writeJSON(c *fox.Context, v any) {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(c.Writer(), err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.SetHeader(fox.HeaderCacheControl, "no-store")
+	_ = c.Blob(http.StatusOK, fox.MIMEApplicationJSONCharsetUTF8, buf)
+}
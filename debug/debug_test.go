@@ -0,0 +1,113 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type teamKey struct{}
+
+func newRouter(t *testing.T) *fox.Router {
+	t.Helper()
+	f := fox.MustRouter(fox.AllowRegexpParam(true), fox.WithMiddleware(func(next fox.HandlerFunc) fox.HandlerFunc {
+		return next
+	}))
+	f.MustAdd(fox.MethodGet, "/users/{id:[0-9]+}", func(c *fox.Context) {}, fox.WithName("user"), fox.WithAnnotation(teamKey{}, "identity"))
+	f.MustAdd(fox.MethodGet, "/users/{id:[0-9]+}", func(c *fox.Context) {}, fox.WithQueryMatcher("v", "2"))
+	f.MustAdd(fox.MethodGet, "example.com/foo", func(c *fox.Context) {}, fox.WithAnnotation(teamKey{}, "<script>"))
+	f.MustAdd(fox.MethodGet, "/debug/fox/*{any}", Handler())
+	return f
+}
+
+func TestHandler_RoutesJSON(t *testing.T) {
+	f := newRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/fox/routes.json", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fox.MIMEApplicationJSONCharsetUTF8, w.Header().Get(fox.HeaderContentType))
+
+	var table Table
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &table))
+	assert.Equal(t, 4, table.Stats.Routes)
+	assert.True(t, table.Info.AllowRegexp)
+	require.Len(t, table.Routes, 4)
+
+	assert.Equal(t, Route{
+		Pattern:       "/users/{id:[0-9]+}",
+		Methods:       []string{http.MethodGet},
+		Name:          "user",
+		Annotations:   map[string]string{"debug.teamKey": "identity"},
+		Middlewares:   1,
+		TrailingSlash: "strict",
+	}, table.Routes[2])
+	assert.Equal(t, []string{"q:v=2"}, table.Routes[1].Matchers)
+	assert.Equal(t, uint(1), table.Routes[1].Priority)
+	assert.Equal(t, "example.com", table.Routes[3].Hostname)
+}
+
+func TestHandler_MatchJSON(t *testing.T) {
+	f := newRouter(t)
+
+	cases := []struct {
+		name    string
+		target  string
+		matched bool
+		pattern string
+	}{
+		{name: "matched", target: "/debug/fox/match.json?path=/users/42", matched: true, pattern: "/users/{id:[0-9]+}"},
+		{name: "matched with matchers", target: "/debug/fox/match.json?path=/users/42%3Fv%3D2", matched: true, pattern: "/users/{id:[0-9]+}"},
+		{name: "matched with host", target: "/debug/fox/match.json?host=example.com&path=/foo", matched: true, pattern: "example.com/foo"},
+		{name: "not matched", target: "/debug/fox/match.json?method=POST&path=/users/42"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var res MatchResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tc.matched, res.Matched)
+			if tc.matched {
+				assert.Equal(t, tc.pattern, res.Route.Pattern)
+			}
+		})
+	}
+
+	res := Match(f, http.MethodGet, "", "/users/42?v=2")
+	require.True(t, res.Matched)
+	assert.Equal(t, []string{"q:v=2"}, res.Route.Matchers)
+}
+
+func TestHandler_HTML(t *testing.T) {
+	f := newRouter(t)
+	other := fox.MustRouter()
+	other.MustAdd(fox.MethodGet, "/other", func(c *fox.Context) {})
+	f.MustAdd(fox.MethodGet, "/debug/other/*{any}", HandlerFor(other))
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/fox/?path=/users/42", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fox.MIMETextHTMLCharsetUTF8, w.Header().Get(fox.HeaderContentType))
+	assert.Contains(t, w.Body.String(), "Routes (5)")
+	assert.Contains(t, w.Body.String(), "Matched <code>/users/{id:[0-9]&#43;}</code> (user)")
+	assert.Contains(t, w.Body.String(), "debug.teamKey=&lt;script&gt;")
+	assert.NotContains(t, w.Body.String(), "<script>")
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/other/", nil)
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), "Routes (1)")
+	assert.Contains(t, w.Body.String(), "<code>/other</code>")
+}
@@ -0,0 +1,217 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DumpFormat is the output format of [Router.Dump].
+type DumpFormat uint8
+
+const (
+	// DumpDOT produces a Graphviz DOT diagram.
+	DumpDOT DumpFormat = iota
+	// DumpMermaid produces a Mermaid flowchart.
+	DumpMermaid
+)
+
+// Dump writes a diagram of the patterns and names trees to w, in the given format. Each node is annotated with its
+// type, key, regexp, host flag and attached routes with their methods, matchers and priority. Edges are labeled with
+// the order in which children are evaluated during lookup. This is intended for debugging and the output format is not
+// part of the API compatibility guarantee. This function is safe for concurrent use by multiple goroutine and while
+// mutation on routes are ongoing.
+func (fox *Router) Dump(w io.Writer, format DumpFormat) error {
+	tree := fox.getTree()
+
+	var d dumper
+	switch format {
+	case DumpDOT:
+		d = &dotDumper{}
+	case DumpMermaid:
+		d = &mermaidDumper{}
+	default:
+		return fmt.Errorf("%w: unknown dump format", ErrInvalidConfig)
+	}
+
+	d.begin()
+	d.beginTree("patterns")
+	walkDump(d, "p", tree.patterns, "root")
+	d.endTree()
+	d.beginTree("names")
+	walkDump(d, "n", tree.names, "root")
+	d.endTree()
+	d.end()
+
+	_, err := io.WriteString(w, d.String())
+	return err
+}
+
+type dumper interface {
+	begin()
+	beginTree(name string)
+	node(id string, lines []string)
+	edge(from, to string, order int)
+	endTree()
+	end()
+	String() string
+}
+
+// walkDump emits n and all its descendants in lookup order.
+func walkDump(d dumper, id string, n *node, kind string) {
+	d.node(id, dumpLines(n, kind))
+
+	order := 0
+	emit := func(children []*node, kind string) {
+		for _, child := range children {
+			childID := id + "_" + strconv.Itoa(order)
+			d.edge(id, childID, order)
+			walkDump(d, childID, child, kind)
+			order++
+		}
+	}
+
+	emit(n.statics, "static")
+	emit(n.params, "param")
+	emit(n.wildcards, "wildcard")
+}
+
+func dumpLines(n *node, kind string) []string {
+	var tags []string
+	if n.regexp != nil {
+		tags = append(tags, "regexp")
+	}
+	if kind == "wildcard" {
+		if len(n.statics) > 0 || len(n.params) > 0 || len(n.wildcards) > 0 {
+			tags = append(tags, "infix")
+		}
+		if n.isLeaf() {
+			tags = append(tags, "suffix")
+		}
+	}
+	if n.host {
+		tags = append(tags, "host")
+	}
+
+	title := kind
+	if len(tags) > 0 {
+		title += " (" + strings.Join(tags, ", ") + ")"
+	}
+	lines := []string{title}
+	if n.key != "" {
+		lines = append(lines, "key: "+n.key)
+	}
+	if n.regexp != nil {
+		lines = append(lines, "regexp: "+n.regexp.String())
+	}
+
+	for _, route := range n.routes {
+		var sb strings.Builder
+		sb.WriteString("route: ")
+		if len(route.methods) > 0 {
+			sb.WriteString(strings.Join(route.methods, ","))
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(route.pattern)
+		if route.name != "" {
+			sb.WriteString(" name:")
+			sb.WriteString(route.name)
+		}
+		if len(route.matchers) > 0 {
+			sb.WriteString(" matchers:{")
+			for i, matcher := range route.matchers {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				if m, ok := matcher.(fmt.Stringer); ok {
+					sb.WriteString(m.String())
+				} else {
+					sb.WriteString(reflect.TypeOf(matcher).String())
+				}
+			}
+			sb.WriteString("} priority:")
+			sb.WriteString(strconv.FormatUint(uint64(route.priority), 10))
+		}
+		lines = append(lines, sb.String())
+	}
+	return lines
+}
+
+type dotDumper struct {
+	strings.Builder
+}
+
+func (d *dotDumper) begin() {
+	d.WriteString("digraph fox {\n")
+	d.WriteString("  rankdir=LR;\n")
+	d.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+}
+
+func (d *dotDumper) beginTree(name string) {
+	d.WriteString("  subgraph cluster_" + name + " {\n")
+	d.WriteString("    label=\"" + name + "\";\n")
+}
+
+func (d *dotDumper) node(id string, lines []string) {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = dotEscape(line)
+	}
+	d.WriteString("    " + id + " [label=\"" + strings.Join(escaped, "\\l") + "\\l\"];\n")
+}
+
+func (d *dotDumper) edge(from, to string, order int) {
+	d.WriteString("    " + from + " -> " + to + " [label=\"" + strconv.Itoa(order) + "\"];\n")
+}
+
+func (d *dotDumper) endTree() {
+	d.WriteString("  }\n")
+}
+
+func (d *dotDumper) end() {
+	d.WriteString("}\n")
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+type mermaidDumper struct {
+	strings.Builder
+}
+
+func (d *mermaidDumper) begin() {
+	d.WriteString("flowchart LR\n")
+}
+
+func (d *mermaidDumper) beginTree(name string) {
+	d.WriteString("  subgraph " + name + "\n")
+}
+
+func (d *mermaidDumper) node(id string, lines []string) {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = mermaidEscape(line)
+	}
+	d.WriteString("    " + id + "[\"" + strings.Join(escaped, "<br/>") + "\"]\n")
+}
+
+func (d *mermaidDumper) edge(from, to string, order int) {
+	d.WriteString("    " + from + " -->|" + strconv.Itoa(order) + "| " + to + "\n")
+}
+
+func (d *mermaidDumper) endTree() {
+	d.WriteString("  end\n")
+}
+
+func (d *mermaidDumper) end() {}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
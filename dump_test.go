@@ -0,0 +1,87 @@
+package fox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Dump(t *testing.T) {
+	f := MustRouter(AllowRegexpParam(true))
+	f.MustAdd(MethodGet, "/foo/{id:[0-9]+}", emptyHandler, WithName("foo"))
+	f.MustAdd(MethodGet, "/foo/{id}", emptyHandler, WithQueryMatcher("a", "b"))
+	f.MustAdd(MethodGet, "/foo/+{any}/bar", emptyHandler)
+	f.MustAdd(MethodGet, "/foo/+{any}", emptyHandler)
+	f.MustAdd(MethodAny, `example.com/"x"`, emptyHandler)
+
+	t.Run("dot", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, f.Dump(buf, DumpDOT))
+		want := `digraph fox {
+  rankdir=LR;
+  node [shape=box, fontname="monospace"];
+  subgraph cluster_patterns {
+    label="patterns";
+    p [label="root\l"];
+    p -> p_0 [label="0"];
+    p_0 [label="static\lkey: /foo/\l"];
+    p_0 -> p_0_0 [label="0"];
+    p_0_0 [label="param (regexp)\lkey: [0-9]+\lregexp: ^[0-9]+$\lroute: GET /foo/{id:[0-9]+} name:foo\l"];
+    p_0 -> p_0_1 [label="1"];
+    p_0_1 [label="param\lkey: ?\lroute: GET /foo/{id} matchers:{q:a=b} priority:1\l"];
+    p_0 -> p_0_2 [label="2"];
+    p_0_2 [label="wildcard (infix, suffix)\lkey: *\lroute: GET /foo/+{any}\l"];
+    p_0_2 -> p_0_2_0 [label="0"];
+    p_0_2_0 [label="static\lkey: /bar\lroute: GET /foo/+{any}/bar\l"];
+    p -> p_1 [label="1"];
+    p_1 [label="static (host)\lkey: example.com\l"];
+    p_1 -> p_1_0 [label="0"];
+    p_1_0 [label="static\lkey: /\"x\"\lroute: example.com/\"x\"\l"];
+  }
+  subgraph cluster_names {
+    label="names";
+    n [label="root\l"];
+    n -> n_0 [label="0"];
+    n_0 [label="static\lkey: foo\lroute: GET /foo/{id:[0-9]+} name:foo\l"];
+  }
+}
+`
+		assert.Equal(t, want, buf.String())
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, f.Dump(buf, DumpMermaid))
+		want := `flowchart LR
+  subgraph patterns
+    p["root"]
+    p -->|0| p_0
+    p_0["static<br/>key: /foo/"]
+    p_0 -->|0| p_0_0
+    p_0_0["param (regexp)<br/>key: [0-9]+<br/>regexp: ^[0-9]+$<br/>route: GET /foo/{id:[0-9]+} name:foo"]
+    p_0 -->|1| p_0_1
+    p_0_1["param<br/>key: ?<br/>route: GET /foo/{id} matchers:{q:a=b} priority:1"]
+    p_0 -->|2| p_0_2
+    p_0_2["wildcard (infix, suffix)<br/>key: *<br/>route: GET /foo/+{any}"]
+    p_0_2 -->|0| p_0_2_0
+    p_0_2_0["static<br/>key: /bar<br/>route: GET /foo/+{any}/bar"]
+    p -->|1| p_1
+    p_1["static (host)<br/>key: example.com"]
+    p_1 -->|0| p_1_0
+    p_1_0["static<br/>key: /#quot;x#quot;<br/>route: example.com/#quot;x#quot;"]
+  end
+  subgraph names
+    n["root"]
+    n -->|0| n_0
+    n_0["static<br/>key: foo<br/>route: GET /foo/{id:[0-9]+} name:foo"]
+  end
+`
+		assert.Equal(t, want, buf.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		assert.ErrorIs(t, f.Dump(new(bytes.Buffer), DumpFormat(42)), ErrInvalidConfig)
+	})
+}
@@ -17,6 +17,7 @@ var (
 	ErrInvalidRoute            = errors.New("invalid route")
 	ErrDiscardedResponseWriter = errors.New("discarded response writer")
 	ErrNoClientIPResolver      = errors.New("no client ip resolver")
+	ErrInvalidClientIP         = errors.New("invalid client ip")
 	ErrReadOnlyTxn             = errors.New("write on read-only transaction")
 	ErrSettledTxn              = errors.New("transaction settled")
 	ErrParamKeyTooLarge        = errors.New("parameter key too large")
@@ -25,6 +26,12 @@ var (
 	ErrRegexpNotAllowed        = errors.New("regexp not allowed")
 	ErrInvalidConfig           = errors.New("invalid config")
 	ErrInvalidMatcher          = errors.New("invalid matcher")
+	ErrRouteNotTracked         = errors.New("route not tracked")
+	ErrInvalidSavepoint        = errors.New("invalid savepoint")
+	ErrInvalidParam            = errors.New("invalid param")
+	ErrTooManyReroutes         = errors.New("too many reroutes")
+	ErrEventStreamClosed       = errors.New("event stream closed")
+	ErrInvalidBindDestination  = errors.New("invalid bind destination")
 )
 
 // RouteConflictError represents a conflict that occurred during route registration.
@@ -96,3 +103,91 @@ func newRouteNotFoundError(route *Route) error {
 	sb.WriteString("\nis not registered")
 	return fmt.Errorf("%w: %s", ErrRouteNotFound, sb.String())
 }
+
+// PatternError represents a syntax or validation error in a route pattern. It is returned by [ParsePattern], and by
+// every function registering or creating a route, such as [Router.Add] or [Router.NewRoute].
+type PatternError struct {
+	// Pattern is the invalid route pattern.
+	Pattern string
+	// Reason describes the error.
+	Reason string
+	// Offset is the byte offset in Pattern where the error was detected.
+	Offset int
+	// cause is the underlying error, if any (e.g. ErrRegexpNotAllowed).
+	cause error
+}
+
+func newPatternError(pattern string, offset int, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	e := &PatternError{
+		Pattern: pattern,
+		Reason:  err.Error(),
+		Offset:  offset,
+	}
+	if errors.Unwrap(err) != nil {
+		e.cause = err
+	}
+	return e
+}
+
+func (e *PatternError) Error() string {
+	return ErrInvalidRoute.Error() + ": " + e.Reason
+}
+
+// Unwrap returns the sentinel value [ErrInvalidRoute] and the underlying error, if any.
+func (e *PatternError) Unwrap() []error {
+	if e.cause != nil {
+		return []error{ErrInvalidRoute, e.cause}
+	}
+	return []error{ErrInvalidRoute}
+}
+
+// ParamError represents a path or query parameter that cannot be converted to the expected type. It is returned by
+// [ParamAs], [Context.BindParams] and [Context.BindQuery], and handled by the [DefaultErrorHandler] as a
+// "400 Bad Request".
+type ParamError struct {
+	// Err is the underlying cause.
+	Err error
+	// In is the location of the parameter, either "path" or "query".
+	In string
+	// Name is the name of the parameter.
+	Name string
+	// Value is the raw value of the parameter.
+	Value string
+}
+
+func (e *ParamError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s %q: %s", ErrInvalidParam, e.In, e.Name, e.Err)
+	}
+	return fmt.Sprintf("%s: %s %q with value %q: %s", ErrInvalidParam, e.In, e.Name, e.Value, e.Err)
+}
+
+// Unwrap returns the sentinel value [ErrInvalidParam] and the underlying error.
+func (e *ParamError) Unwrap() []error {
+	return []error{ErrInvalidParam, e.Err}
+}
+
+// ParamErrors aggregates every [ParamError] reported while binding a request with [Context.BindParams] or
+// [Context.BindQuery].
+type ParamErrors []*ParamError
+
+func (e ParamErrors) Error() string {
+	sb := new(strings.Builder)
+	for i, err := range e {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the aggregated errors.
+func (e ParamErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HandlerFuncE is a function type for handlers that return an error instead of writing their own error response.
+// It is registered with [HandleE], which passes a non-nil error to the [ErrorHandlerFunc] configured with
+// [WithErrorHandler]. Apart from the returned error, the same rules as [HandlerFunc] apply.
+type HandlerFuncE func(c *Context) error
+
+// MiddlewareFuncE is a function type for implementing [HandlerFuncE] middleware. Unlike [MiddlewareFunc], it can
+// observe, replace or recover from the error returned by the next handler before it is handled. It is applied
+// with [WrapME].
+type MiddlewareFuncE func(next HandlerFuncE) HandlerFuncE
+
+// ErrorHandlerFunc is a function type that writes the response for an error returned by a [HandlerFuncE]. It
+// is configured with [WithErrorHandler], and defaults to [DefaultErrorHandler].
+type ErrorHandlerFunc func(c *Context, err error)
+
+// HTTPError is an error that carries the HTTP response to send to the client. It can be returned by a [HandlerFuncE]
+// as is, or wrapped with other errors.
+type HTTPError struct {
+	// Err is the underlying cause, if any. It is never sent to the client by the [DefaultErrorHandler].
+	Err error
+	// Header holds optional headers to set on the response.
+	Header http.Header
+	// Message is the message sent to the client. If empty, the status text is used.
+	Message string
+	// Status is the HTTP status code.
+	Status int
+}
+
+// NewHTTPError returns an [HTTPError] with the provided status code and cause, which may be nil.
+func NewHTTPError(status int, err error) *HTTPError {
+	return &HTTPError{Status: status, Err: err}
+}
+
+// Error returns a formatted error message.
+func (e *HTTPError) Error() string {
+	msg := e.message()
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HTTPError) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.Status)
+}
+
+// HandleE is an adapter for using a [HandlerFuncE] as a [HandlerFunc], so it can be registered with [Router.Add] or
+// any other method expecting a [HandlerFunc]. A non-nil error is recorded on the [Context], so middlewares such as
+// [Logger] can observe it with [Context.Err], and passed to the [ErrorHandlerFunc] of the route. When called within
+// a [MiddlewareFuncE] applied with [WrapME], the error is returned to the middleware instead, and handled once it
+// leaves the outermost error-aware middleware.
+func HandleE(h HandlerFuncE) HandlerFunc {
+	return func(c *Context) {
+		c.handleError(h(c))
+	}
+}
+
+// WrapME is an adapter for using a [MiddlewareFuncE] as a [MiddlewareFunc]. Within the wrapped chain, errors
+// returned by [HandleE] handlers or nested error-aware middlewares are passed up to the [MiddlewareFuncE], and only
+// handled when it returns them. Note that a [MiddlewareFunc] applied between the error-aware middleware and the
+// handler sees the response before the error is handled.
+func WrapME(m MiddlewareFuncE) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return HandleE(m(func(c *Context) error {
+			c.errScope++
+			// Restored even if next panics, so that errors handled after a recovery are not deferred.
+			defer func() { c.errScope-- }()
+			next(c)
+			err := c.err
+			c.err = nil
+			return err
+		}))
+	}
+}
+
+// DefaultErrorHandler is the default [ErrorHandlerFunc]. If the error is or wraps an [HTTPError], it replies with its
+// status, headers and message. If the error wraps [ErrInvalidParam], such as a [ParamError], it replies with a "400 Bad
+// Request" and a fixed "invalid parameter" message. The names of the invalid parameters, but not their values, are
+// listed in the "invalid-params" extension member of problem details documents. If the error wraps
+// [ErrInvalidClientIP], it replies with a "400 Bad Request" and a fixed "invalid client IP" message. Otherwise, it
+// replies with a generic "500 Internal Server Error", so the error is never leaked to the client. Nothing is written if
+// the handler has already written the response.
+func DefaultErrorHandler(c *Context, err error) {
+	if c.Writer().Written() {
+		return
+	}
+
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		if errors.Is(err, ErrInvalidParam) {
+			replyProblem(c, http.StatusBadRequest, invalidParamMessage, invalidParamMessage, invalidParams(err))
+			return
+		}
+		if errors.Is(err, ErrInvalidClientIP) {
+			replyError(c, http.StatusBadRequest, invalidClientIPMessage, invalidClientIPMessage)
+			return
+		}
+		replyError(c, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), "")
+		return
+	}
+
+	for k, v := range he.Header {
+		c.Writer().Header()[k] = v
+	}
+	replyError(c, he.Status, he.message(), he.Message)
+}
+
+const (
+	invalidParamMessage    = "invalid parameter"
+	invalidClientIPMessage = "invalid client IP"
+)
+
+// invalidParams returns the "invalid-params" problem extension member, listing the name and location of each
+// [ParamError] wrapped by err. Values and causes are omitted, since they echo client input.
+func invalidParams(err error) map[string]any {
+	var errs ParamErrors
+	if !errors.As(err, &errs) {
+		var pe *ParamError
+		if !errors.As(err, &pe) {
+			return nil
+		}
+		errs = ParamErrors{pe}
+	}
+
+	params := make([]map[string]string, 0, len(errs))
+	for _, pe := range errs {
+		params = append(params, map[string]string{"name": pe.Name, "in": pe.In})
+	}
+	return map[string]any{"invalid-params": params}
+}
+
+// Err returns the error returned by the [HandlerFuncE] serving the request, once it has been handled, or nil.
+// It allows [MiddlewareFunc] to observe failures, e.g. for logging.
+func (c *Context) Err() error {
+	return c.err
+}
+
+// handleError records and handles a non-nil error, or defers it to the enclosing error-aware middleware.
+func (c *Context) handleError(err error) {
+	if err == nil {
+		return
+	}
+	c.err = err
+	if c.errScope > 0 {
+		return
+	}
+
+	handler := c.fox.errorHandler
+	if c.route != nil && c.route.errorHandler != nil {
+		handler = c.route.errorHandler
+	}
+	handler(c, err)
+}
@@ -0,0 +1,198 @@
+package fox
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleE(t *testing.T) {
+	errBoom := errors.New("boom")
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/ok", HandleE(func(c *Context) error {
+		return c.String(http.StatusOK, "ok")
+	}))
+	f.MustAdd(MethodGet, "/internal", HandleE(func(c *Context) error {
+		return errBoom
+	}))
+	f.MustAdd(MethodGet, "/http", HandleE(func(c *Context) error {
+		return &HTTPError{
+			Status:  http.StatusTooManyRequests,
+			Header:  http.Header{"Retry-After": {"30"}},
+			Message: "slow down",
+			Err:     errBoom,
+		}
+	}))
+	f.MustAdd(MethodGet, "/wrapped", HandleE(func(c *Context) error {
+		return errors.Join(errBoom, NewHTTPError(http.StatusNotFound, nil))
+	}))
+	f.MustAdd(MethodGet, "/written", HandleE(func(c *Context) error {
+		_ = c.String(http.StatusAccepted, "partial")
+		return errBoom
+	}))
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+		header string
+	}{
+		{path: "/ok", status: http.StatusOK, body: "ok"},
+		{path: "/internal", status: http.StatusInternalServerError, body: "Internal Server Error\n"},
+		{path: "/http", status: http.StatusTooManyRequests, body: "slow down\n", header: "30"},
+		{path: "/wrapped", status: http.StatusNotFound, body: "Not Found\n"},
+		{path: "/written", status: http.StatusAccepted, body: "partial"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.body, w.Body.String())
+			assert.Equal(t, tc.header, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	handler := func(name string) ErrorHandlerFunc {
+		return func(c *Context, err error) {
+			_ = c.String(http.StatusTeapot, name+":"+err.Error())
+		}
+	}
+	failing := HandleE(func(c *Context) error {
+		return errors.New("boom")
+	})
+
+	f := MustRouter(WithErrorHandler(handler("global")))
+	f.MustAdd(MethodGet, "/global", failing)
+	f.MustAdd(MethodGet, "/route", failing, WithErrorHandler(handler("route")))
+
+	for _, name := range []string{"global", "route"} {
+		req := httptest.NewRequest(http.MethodGet, "/"+name, nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		assert.Equal(t, name+":boom", w.Body.String())
+	}
+
+	_, err := NewRouter(WithErrorHandler(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = f.Add(MethodGet, "/nil", failing, WithErrorHandler(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestWrapME(t *testing.T) {
+	errNotFound := errors.New("not found")
+	var observed []error
+
+	// Maps the application error to an HTTP error before it is handled.
+	mapping := WrapME(func(next HandlerFuncE) HandlerFuncE {
+		return func(c *Context) error {
+			err := next(c)
+			if errors.Is(err, errNotFound) {
+				return NewHTTPError(http.StatusNotFound, err)
+			}
+			return err
+		}
+	})
+	// Recovers from the error by writing its own response.
+	fallback := WrapME(func(next HandlerFuncE) HandlerFuncE {
+		return func(c *Context) error {
+			if err := next(c); err != nil {
+				return c.String(http.StatusOK, "fallback")
+			}
+			return nil
+		}
+	})
+	// Observes the handled error.
+	observer := func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			next(c)
+			observed = append(observed, c.Err())
+		}
+	}
+	// Wraps the response writer, which clones the context.
+	wrapper := WrapM(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	failing := HandleE(func(c *Context) error {
+		return errNotFound
+	})
+
+	f := MustRouter(WithMiddleware(observer))
+	f.MustAdd(MethodGet, "/mapping", failing, WithMiddleware(mapping, wrapper))
+	f.MustAdd(MethodGet, "/fallback", failing, WithMiddleware(fallback, mapping))
+	f.MustAdd(MethodGet, "/ok", HandleE(func(c *Context) error {
+		return c.String(http.StatusOK, "ok")
+	}), WithMiddleware(mapping))
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+		err    error
+	}{
+		{path: "/mapping", status: http.StatusNotFound, body: "Not Found\n", err: errNotFound},
+		{path: "/fallback", status: http.StatusOK, body: "fallback"},
+		{path: "/ok", status: http.StatusOK, body: "ok"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			observed = nil
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.body, w.Body.String())
+			require.Len(t, observed, 1)
+			if tc.err == nil {
+				assert.NoError(t, observed[0])
+				return
+			}
+			var he *HTTPError
+			require.ErrorAs(t, observed[0], &he)
+			assert.ErrorIs(t, observed[0], tc.err)
+		})
+	}
+}
+
+func TestWrapMEPanic(t *testing.T) {
+	// The recovery handler replies through a HandlerFuncE, whose error must be handled right away.
+	recovery := RecoveryWithFunc(slog.DiscardHandler, func(c *Context, _ any) {
+		HandleE(func(c *Context) error {
+			return NewHTTPError(http.StatusServiceUnavailable, nil)
+		})(c)
+	})
+	passthrough := WrapME(func(next HandlerFuncE) HandlerFuncE {
+		return next
+	})
+
+	f, err := NewRouter(WithMiddleware(recovery, passthrough))
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("boom")
+	assert.Equal(t, "Bad Request: boom", NewHTTPError(http.StatusBadRequest, cause).Error())
+	assert.Equal(t, "invalid id", (&HTTPError{Status: http.StatusBadRequest, Message: "invalid id"}).Error())
+	assert.ErrorIs(t, NewHTTPError(http.StatusBadRequest, cause), cause)
+}
@@ -0,0 +1,421 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcome is the final decision taken by the router for a request, as reported by [Router.Explain].
+type Outcome uint8
+
+const (
+	// OutcomeNotFound indicates that no route matched and the [WithNoRouteHandler] handler is called.
+	OutcomeNotFound Outcome = iota
+	// OutcomeMatched indicates that a route matched and its handler is called.
+	OutcomeMatched
+	// OutcomeRelaxedSlash indicates that a route matched by adding or removing a trailing slash and its handler is
+	// called.
+	OutcomeRelaxedSlash
+	// OutcomeRedirectSlash indicates that a route matched by adding or removing a trailing slash and the client is
+	// redirected.
+	OutcomeRedirectSlash
+	// OutcomeRelaxedPath indicates that a route matched the cleaned path and its handler is called.
+	OutcomeRelaxedPath
+	// OutcomeRedirectPath indicates that a route matched the cleaned path and the client is redirected.
+	OutcomeRedirectPath
+	// OutcomeSystemWideOptions indicates that the request is a system-wide OPTIONS request.
+	OutcomeSystemWideOptions
+	// OutcomeOptions indicates that the [WithOptionsHandler] handler is called.
+	OutcomeOptions
+	// OutcomeMethodNotAllowed indicates that the [WithNoMethodHandler] handler is called.
+	OutcomeMethodNotAllowed
+	// OutcomeAutoHEAD indicates that no HEAD route matched and the handler of the matching GET route is called with the
+	// body discarded. See [WithAutoHEAD].
+	OutcomeAutoHEAD
+	// OutcomeFallback indicates that a fallback handler is called in place of the [WithNoRouteHandler] or
+	// [WithNoMethodHandler] handler. See [Router.AddFallback].
+	OutcomeFallback
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeNotFound:
+		return "not found"
+	case OutcomeMatched:
+		return "matched"
+	case OutcomeRelaxedSlash:
+		return "matched with trailing slash"
+	case OutcomeRedirectSlash:
+		return "redirect trailing slash"
+	case OutcomeRelaxedPath:
+		return "matched with fixed path"
+	case OutcomeRedirectPath:
+		return "redirect fixed path"
+	case OutcomeSystemWideOptions:
+		return "system-wide options"
+	case OutcomeOptions:
+		return "options"
+	case OutcomeMethodNotAllowed:
+		return "method not allowed"
+	case OutcomeAutoHEAD:
+		return "matched with GET"
+	case OutcomeFallback:
+		return "fallback"
+	default:
+		return "unknown"
+	}
+}
+
+// StepKind is the kind of an [ExplainStep].
+type StepKind uint8
+
+const (
+	// StepPhase indicates the start of a lookup phase. The key is either "hostname" or "path" and the input is
+	// the searched string. The step is matched if the phase found a route, possibly by adding or removing a trailing
+	// slash.
+	StepPhase StepKind = iota
+	// StepStatic indicates that a static node is visited. The input is the remaining string to search.
+	StepStatic
+	// StepParam indicates that a param node is visited. The input is the captured segment.
+	StepParam
+	// StepWildcard indicates that a wildcard node is visited. The input is the captured segment.
+	StepWildcard
+	// StepRegexp indicates that a regexp constraint is tested against the input.
+	StepRegexp
+	// StepBacktrack indicates that the lookup resumes from a node saved on the skip stack. The input is the
+	// remaining string to search from this node.
+	StepBacktrack
+	// StepTrailingSlash indicates that routes are evaluated by adding or removing a trailing slash.
+	StepTrailingSlash
+	// StepExpired indicates that the route is skipped because it has expired.
+	StepExpired
+	// StepMethod indicates that the route methods are tested against the request method.
+	StepMethod
+	// StepMatcher indicates that a route matcher is evaluated.
+	StepMatcher
+	// StepFixedPath indicates that the lookup is retried with the cleaned path.
+	StepFixedPath
+)
+
+func (k StepKind) String() string {
+	switch k {
+	case StepPhase:
+		return "phase"
+	case StepStatic:
+		return "static"
+	case StepParam:
+		return "param"
+	case StepWildcard:
+		return "wildcard"
+	case StepRegexp:
+		return "regexp"
+	case StepBacktrack:
+		return "backtrack"
+	case StepTrailingSlash:
+		return "trailing slash"
+	case StepExpired:
+		return "expired"
+	case StepMethod:
+		return "method"
+	case StepMatcher:
+		return "matcher"
+	case StepFixedPath:
+		return "fixed path"
+	default:
+		return "unknown"
+	}
+}
+
+// ExplainStep is a single decision taken during the lookup.
+type ExplainStep struct {
+	// Route is the evaluated route for [StepExpired], [StepMethod] and [StepMatcher] steps.
+	Route *Route
+	// Matcher is the evaluated matcher for [StepMatcher] steps.
+	Matcher Matcher
+	// Key is the node key for node related steps, the regular expression for [StepRegexp] steps or the phase name
+	// for [StepPhase] steps. Param and wildcard nodes without regexp have respectively the "?" and "*" key.
+	Key string
+	// Input is the string evaluated at this step.
+	Input string
+	// Kind is the kind of step.
+	Kind StepKind
+	// Matched reports whether the evaluation succeeded.
+	Matched bool
+}
+
+func (s ExplainStep) String() string {
+	sb := new(strings.Builder)
+	sb.WriteString(s.Kind.String())
+	switch s.Kind {
+	case StepExpired, StepMethod, StepMatcher:
+		sb.WriteString(" ")
+		sb.WriteString(strings.Join(s.Route.methods, ","))
+		if len(s.Route.methods) > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(s.Route.pattern)
+		if s.Matcher != nil {
+			sb.WriteString(" ")
+			if m, ok := s.Matcher.(fmt.Stringer); ok {
+				sb.WriteString(m.String())
+			} else {
+				fmt.Fprintf(sb, "%T", s.Matcher)
+			}
+		}
+	default:
+		if s.Key != "" {
+			sb.WriteString(" ")
+			sb.WriteString(strconv.Quote(s.Key))
+		}
+	}
+	if s.Input != "" {
+		sb.WriteString(" input=")
+		sb.WriteString(strconv.Quote(s.Input))
+	}
+	if s.Matched {
+		sb.WriteString(" ok")
+	} else {
+		sb.WriteString(" ko")
+	}
+	return sb.String()
+}
+
+// Explanation is a structured trace of the lookup of a request, as returned by [Router.Explain].
+type Explanation struct {
+	// Route is the matched route, or the fallback route for [OutcomeFallback], if any.
+	Route *Route
+	// Method is the request method.
+	Method string
+	// Host is the request host.
+	Host string
+	// Path is the routing path of the request.
+	Path string
+	// Params holds the params captured for the matched route.
+	Params Params
+	// Allow holds the allowed methods for [OutcomeOptions] and [OutcomeMethodNotAllowed] outcomes, and for
+	// [OutcomeFallback] outcomes when the fallback replaces the [WithNoMethodHandler] handler.
+	Allow []string
+	// Steps holds every decision taken during the lookup, in order.
+	Steps []ExplainStep
+	// Outcome is the final decision taken by the router.
+	Outcome Outcome
+	// Tsr reports whether the route was matched by adding or removing a trailing slash.
+	Tsr bool
+}
+
+func (e *Explanation) String() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "%s %s%s\n", e.Method, e.Host, e.Path)
+	for i, step := range e.Steps {
+		fmt.Fprintf(sb, "%3d. %s\n", i+1, step.String())
+	}
+	sb.WriteString("outcome: ")
+	sb.WriteString(e.Outcome.String())
+	if e.Route != nil {
+		sb.WriteString(" ")
+		sb.WriteString(e.Route.pattern)
+	}
+	if len(e.Allow) > 0 {
+		sb.WriteString(" allow:")
+		sb.WriteString(strings.Join(e.Allow, ","))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Explain performs a lookup for the given [http.Request] and returns a structured trace of every decision taken
+// by the router: the hostname and path-only phases, each node visited, the regexp constraints tested, the backtracks,
+// the method filtering and matchers evaluated for each route, the trailing slash and fixed path attempts, the lookup
+// of a fallback handler, and the final outcome, which follows the same logic as [Router.ServeHTTP]. No handler is
+// called. This is intended for debugging and is significantly slower than a regular lookup. This function is safe for
+// concurrent use by multiple goroutine and while mutation on routes are ongoing.
+//
+// Explain only covers the lookup. The middleware registered for the [PreRoutingHandler] scope are not run, so any
+// rewrite of the request they perform, as well as any reroute done by handlers (see [Context.Reroute]), is not
+// reflected. To explain a rewritten request, call Explain with the request as rewritten.
+func (fox *Router) Explain(r *http.Request) *Explanation {
+	tree := fox.getTree()
+	c := tree.pool.Get().(*Context)
+	c.resetWithRequest(r)
+	c.trace = new(tracer)
+	defer func() {
+		c.trace = nil
+		tree.pool.Put(c)
+	}()
+
+	path := routingPath(r)
+	e := &Explanation{Method: r.Method, Host: r.Host, Path: path}
+
+	record := func(n *node, idx int, tsr bool, outcome Outcome) *Explanation {
+		e.Route = n.routes[idx]
+		e.Tsr = tsr
+		e.Outcome = outcome
+		for i, value := range *c.params {
+			e.Params = append(e.Params, Param{Key: e.Route.params[i], Value: value})
+		}
+		e.Steps = c.trace.steps
+		return e
+	}
+
+	idx, n, tsr := tree.lookup(r.Method, r.Host, path, c, false)
+	if !tsr && n != nil {
+		return record(n, idx, false, OutcomeMatched)
+	}
+
+	if r.Method != http.MethodConnect && r.URL.Path != "/" {
+		if tsr && n != nil {
+			switch n.routes[idx].handleSlash {
+			case RelaxedSlash:
+				return record(n, idx, true, OutcomeRelaxedSlash)
+			case RedirectSlash:
+				return record(n, idx, true, OutcomeRedirectSlash)
+			default:
+			}
+		}
+
+		if fox.handlePath != StrictPath {
+			cleaned := CleanPath(path)
+			c.trace.add(ExplainStep{Kind: StepFixedPath, Input: cleaned, Matched: true})
+			*c.params = (*c.params)[:0]
+			if idx, n, tsr := tree.lookup(r.Method, r.Host, cleaned, c, false); n != nil {
+				route := n.routes[idx]
+				if fox.handlePath == RelaxedPath && (!tsr || route.handleSlash == RelaxedSlash) {
+					return record(n, idx, tsr, OutcomeRelaxedPath)
+				}
+				if fox.handlePath == RedirectPath && (!tsr || route.handleSlash != StrictSlash) {
+					return record(n, idx, tsr, OutcomeRedirectPath)
+				}
+			}
+		}
+	}
+
+	if fox.autoHEAD && r.Method == http.MethodHead {
+		*c.params = (*c.params)[:0]
+		if idx, n, tsr := tree.lookup(http.MethodGet, r.Host, path, c, false); n != nil && (!tsr || n.routes[idx].handleSlash == RelaxedSlash) {
+			return record(n, idx, tsr, OutcomeAutoHEAD)
+		}
+	}
+
+	*c.params = (*c.params)[:0]
+	e.Steps = c.trace.steps
+	// Lookups for other methods are not part of the trace.
+	c.trace = nil
+
+	isOPTIONS := r.Method == http.MethodOptions
+	if fox.systemWideOPTIONS && isOPTIONS && path == "*" {
+		e.Outcome = OutcomeSystemWideOptions
+		return e
+	}
+
+	if fox.handleOPTIONS && isOPTIONS {
+		_, foundOrigin := firstHeader(r.Header, HeaderOrigin)
+		_, foundAcrm := firstHeader(r.Header, HeaderAccessControlRequestMethod)
+		if foundOrigin && foundAcrm {
+			e.Outcome = OutcomeOptions
+			return e
+		}
+		if e.Allow = fox.allowed(tree, c, r.Host, path, r.Method); len(e.Allow) > 0 {
+			e.Allow = append([]string{http.MethodOptions}, e.Allow...)
+			e.Outcome = OutcomeOptions
+		}
+	} else if fox.handleMethodNotAllowed {
+		if e.Allow = fox.allowed(tree, c, r.Host, path, r.Method); len(e.Allow) > 0 {
+			if fox.handleOPTIONS && !slices.Contains(e.Allow, http.MethodOptions) {
+				e.Allow = append(e.Allow, http.MethodOptions)
+			}
+			e.Outcome = OutcomeMethodNotAllowed
+		}
+	}
+
+	// A fallback replaces the NotFound and MethodNotAllowed handlers.
+	if e.Outcome == OutcomeNotFound || e.Outcome == OutcomeMethodNotAllowed {
+		c.trace = &tracer{steps: e.Steps}
+		if route := matchFallback(tree, c, path, false); route != nil {
+			e.Route = route
+			e.Outcome = OutcomeFallback
+			for i, value := range *c.params {
+				e.Params = append(e.Params, Param{Key: route.params[i], Value: value})
+			}
+		}
+		e.Steps = c.trace.steps
+	}
+
+	return e
+}
+
+// allowed returns the sorted list of methods, other than the request method, for which a route match the request.
+func (fox *Router) allowed(tree *iTree, c *Context, host, path, reqMethod string) []string {
+	var allowed []string
+	for method := range tree.methods {
+		if method == reqMethod || slices.Contains(allowed, method) {
+			continue
+		}
+		if idx, n, tsr := tree.lookup(method, host, path, c, true); n != nil && (!tsr || n.routes[idx].handleSlash == RelaxedSlash) {
+			for _, m := range n.routes[idx].methods {
+				if m != reqMethod && !slices.Contains(allowed, m) {
+					allowed = append(allowed, m)
+				}
+			}
+		}
+	}
+	if fox.autoHEAD && reqMethod != http.MethodHead && slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	slices.Sort(allowed)
+	return allowed
+}
+
+// tracer records the lookup decisions for [Router.Explain].
+type tracer struct {
+	steps []ExplainStep
+}
+
+func (t *tracer) add(step ExplainStep) {
+	t.steps = append(t.steps, step)
+}
+
+// phase records the start of a lookup phase and returns its position, so that its outcome can be set once the
+// lookup completes.
+func (t *tracer) phase(key, input string) int {
+	t.steps = append(t.steps, ExplainStep{Kind: StepPhase, Key: key, Input: input})
+	return len(t.steps) - 1
+}
+
+func (t *tracer) node(kind StepKind, n *node, input string) {
+	t.steps = append(t.steps, ExplainStep{Kind: kind, Key: n.key, Input: input, Matched: true})
+}
+
+func (t *tracer) regexp(n *node, input string, matched bool) {
+	t.steps = append(t.steps, ExplainStep{Kind: StepRegexp, Key: n.regexp.String(), Input: input, Matched: matched})
+}
+
+// explainMatch is the traced equivalent of [Route.match].
+func (r *Route) explainMatch(method string, c *Context) bool {
+	if r.expiry > 0 && time.Now().UnixNano() >= r.expiry {
+		c.trace.add(ExplainStep{Kind: StepExpired, Route: r})
+		return false
+	}
+
+	matched := len(r.methods) == 0 || slices.Contains(r.methods, method)
+	c.trace.add(ExplainStep{Kind: StepMethod, Route: r, Input: method, Matched: matched})
+	if !matched {
+		return false
+	}
+
+	for _, m := range r.matchers {
+		matched = m.Match(c)
+		c.trace.add(ExplainStep{Kind: StepMatcher, Route: r, Matcher: m, Matched: matched})
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
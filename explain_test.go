@@ -0,0 +1,263 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Explain(t *testing.T) {
+	f := MustRouter(AllowRegexpParam(true), WithNoMethod(true), WithHandleFixedPath(RelaxedPath))
+	f.MustAdd(MethodGet, "/users/{id:[0-9]+}", emptyHandler)
+	f.MustAdd(MethodGet, "/users/{name}", emptyHandler, WithQueryMatcher("v", "1"))
+	f.MustAdd(MethodPost, "/users/{name}", emptyHandler)
+	f.MustAdd(MethodGet, "/files/", emptyHandler, WithHandleTrailingSlash(RedirectSlash))
+	f.MustAdd(MethodGet, "exemple.com/files/*{filepath}", emptyHandler)
+
+	cases := []struct {
+		name    string
+		target  string
+		method  string
+		outcome Outcome
+		pattern string
+		params  Params
+		allow   []string
+		steps   []ExplainStep
+	}{
+		{
+			name:    "regexp param",
+			target:  "/users/42",
+			method:  http.MethodGet,
+			outcome: OutcomeMatched,
+			pattern: "/users/{id:[0-9]+}",
+			params:  Params{{Key: "id", Value: "42"}},
+		},
+		{
+			name:    "regexp rejected and matcher evaluated",
+			target:  "/users/john?v=1",
+			method:  http.MethodGet,
+			outcome: OutcomeMatched,
+			pattern: "/users/{name}",
+			params:  Params{{Key: "name", Value: "john"}},
+			steps: []ExplainStep{
+				{Kind: StepPhase, Key: "path", Input: "/users/john", Matched: true},
+				{Kind: StepStatic, Key: "/", Input: "users/john", Matched: true},
+				{Kind: StepStatic, Key: "users/", Input: "john", Matched: true},
+				{Kind: StepRegexp, Key: "^[0-9]+$", Input: "john"},
+				{Kind: StepParam, Key: "?", Input: "john", Matched: true},
+				{Kind: StepMethod, Input: http.MethodGet, Matched: true},
+				{Kind: StepMatcher, Matched: true},
+			},
+		},
+		{
+			name:    "method not allowed",
+			target:  "/users/john",
+			method:  http.MethodGet,
+			outcome: OutcomeMethodNotAllowed,
+			allow:   []string{http.MethodPost},
+		},
+		{
+			name:    "redirect trailing slash",
+			target:  "/files",
+			method:  http.MethodGet,
+			outcome: OutcomeRedirectSlash,
+			pattern: "/files/",
+		},
+		{
+			name:    "relaxed fixed path",
+			target:  "/users/../users/42",
+			method:  http.MethodGet,
+			outcome: OutcomeRelaxedPath,
+			pattern: "/users/{id:[0-9]+}",
+		},
+		{
+			name:    "hostname",
+			target:  "https://exemple.com/files/a/b",
+			method:  http.MethodGet,
+			outcome: OutcomeMatched,
+			pattern: "exemple.com/files/*{filepath}",
+			params:  Params{{Key: "filepath", Value: "a/b"}},
+			steps: []ExplainStep{
+				{Kind: StepPhase, Key: "hostname", Input: "exemple.com", Matched: true},
+				{Kind: StepStatic, Key: "exemple.com", Matched: true},
+				{Kind: StepPhase, Key: "path", Input: "/files/a/b", Matched: true},
+				{Kind: StepStatic, Key: "/files/", Input: "a/b", Matched: true},
+				{Kind: StepWildcard, Key: "*", Input: "a/b", Matched: true},
+				{Kind: StepMethod, Input: http.MethodGet, Matched: true},
+			},
+		},
+		{
+			name:    "fallback to path",
+			target:  "https://exemple.com/files",
+			method:  http.MethodGet,
+			outcome: OutcomeRedirectSlash,
+			pattern: "/files/",
+		},
+		{
+			name:    "not found",
+			target:  "/foo",
+			method:  http.MethodGet,
+			outcome: OutcomeNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if req.URL.Host == "" {
+				req.Host = ""
+			}
+			e := f.Explain(req)
+			assert.Equal(t, tc.outcome, e.Outcome)
+			if tc.pattern != "" {
+				require.NotNil(t, e.Route)
+				assert.Equal(t, tc.pattern, e.Route.Pattern())
+			} else {
+				assert.Nil(t, e.Route)
+			}
+			if tc.params != nil {
+				assert.Equal(t, tc.params, e.Params)
+			}
+			assert.Equal(t, tc.allow, e.Allow)
+			assert.NotEmpty(t, e.String())
+			if tc.steps != nil {
+				require.Len(t, e.Steps, len(tc.steps))
+				for i := range tc.steps {
+					e.Steps[i].Route = nil
+					e.Steps[i].Matcher = nil
+				}
+				assert.Equal(t, tc.steps, e.Steps)
+			}
+		})
+	}
+}
+
+func TestRouter_ExplainAutoHEAD(t *testing.T) {
+	f := MustRouter(WithAutoHEAD(true), WithNoMethod(true))
+	f.MustAdd(MethodGet, "/users/{id}", emptyHandler)
+	f.MustAdd(MethodPost, "/posts", emptyHandler)
+
+	req := httptest.NewRequest(http.MethodHead, "/users/42", nil)
+	req.Host = ""
+	e := f.Explain(req)
+	require.Equal(t, OutcomeAutoHEAD, e.Outcome)
+	assert.Equal(t, "/users/{id}", e.Route.Pattern())
+	assert.Equal(t, Params{{Key: "id", Value: "42"}}, e.Params)
+	assert.Contains(t, e.String(), "outcome: matched with GET /users/{id}")
+
+	req = httptest.NewRequest(http.MethodPost, "/users/42", nil)
+	req.Host = ""
+	e = f.Explain(req)
+	require.Equal(t, OutcomeMethodNotAllowed, e.Outcome)
+	assert.Equal(t, []string{http.MethodGet, http.MethodHead}, e.Allow)
+
+	req = httptest.NewRequest(http.MethodHead, "/posts", nil)
+	req.Host = ""
+	e = f.Explain(req)
+	require.Equal(t, OutcomeMethodNotAllowed, e.Outcome)
+	assert.Equal(t, []string{http.MethodPost}, e.Allow)
+}
+
+func TestRouter_ExplainBacktrack(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/foo/bar", emptyHandler)
+	f.MustAdd(MethodGet, "/foo/{name}/baz", emptyHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/bar/baz", nil)
+	req.Host = ""
+	e := f.Explain(req)
+	require.Equal(t, OutcomeMatched, e.Outcome)
+	assert.Equal(t, "/foo/{name}/baz", e.Route.Pattern())
+	assert.Contains(t, e.Steps, ExplainStep{Kind: StepBacktrack, Key: "/foo/", Input: "bar/baz", Matched: true})
+}
+
+func TestRouter_ExplainDoesNotLeakTrace(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/foo", emptyHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	_ = f.Explain(req)
+
+	tree := f.getTree()
+	c := tree.pool.Get().(*Context)
+	assert.Nil(t, c.trace)
+	tree.pool.Put(c)
+}
+
+func TestRouter_ExplainFallback(t *testing.T) {
+	f := MustRouter(WithNoMethod(true))
+	f.MustAdd(MethodGet, "/v1/users", emptyHandler)
+	require.NoError(t, onlyError(f.AddFallback("/v1/*{any}", emptyHandler)))
+	require.NoError(t, onlyError(f.AddFallback("api.example.com/v1/*{any}", emptyHandler)))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/missing", nil)
+	req.Host = "example.com"
+	e := f.Explain(req)
+	require.Equal(t, OutcomeFallback, e.Outcome)
+	assert.Equal(t, "/v1/*{any}", e.Route.Pattern())
+	assert.True(t, e.Route.Fallback())
+	assert.Equal(t, Params{{Key: "any", Value: "missing"}}, e.Params)
+	assert.Empty(t, e.Allow)
+	assert.Contains(t, e.String(), "outcome: fallback /v1/*{any}")
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/missing", nil)
+	req.Host = "api.example.com"
+	e = f.Explain(req)
+	require.Equal(t, OutcomeFallback, e.Outcome)
+	assert.Equal(t, "api.example.com/v1/*{any}", e.Route.Pattern())
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/users", nil)
+	req.Host = "example.com"
+	e = f.Explain(req)
+	require.Equal(t, OutcomeFallback, e.Outcome)
+	assert.Equal(t, "/v1/*{any}", e.Route.Pattern())
+	assert.Equal(t, []string{http.MethodGet}, e.Allow)
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/missing", nil)
+	req.Host = "example.com"
+	e = f.Explain(req)
+	require.Equal(t, OutcomeNotFound, e.Outcome)
+	assert.Nil(t, e.Route)
+	assert.Empty(t, e.Params)
+}
+
+func TestRouter_ExplainPhaseOutcome(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/foo", emptyHandler)
+	f.MustAdd(MethodGet, "example.com/bar", emptyHandler)
+
+	phases := func(e *Explanation) []ExplainStep {
+		var steps []ExplainStep
+		for _, step := range e.Steps {
+			if step.Kind == StepPhase {
+				steps = append(steps, step)
+			}
+		}
+		return steps
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Host = "example.com"
+	e := f.Explain(req)
+	require.Equal(t, OutcomeMatched, e.Outcome)
+	assert.Equal(t, []ExplainStep{
+		{Kind: StepPhase, Key: "hostname", Input: "example.com", Matched: false},
+		{Kind: StepPhase, Key: "path", Input: "/foo", Matched: false},
+		{Kind: StepPhase, Key: "path", Input: "/foo", Matched: true},
+	}, phases(e))
+
+	req = httptest.NewRequest(http.MethodGet, "/baz", nil)
+	req.Host = ""
+	e = f.Explain(req)
+	require.Equal(t, OutcomeNotFound, e.Outcome)
+	assert.Equal(t, []ExplainStep{
+		{Kind: StepPhase, Key: "path", Input: "/baz", Matched: false},
+	}, phases(e))
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AddFallback registers a fallback handler for the given pattern and matchers. When no route matches a request,
+// or no route matches its method, the fallback whose pattern matches the request replaces the router's
+// NotFound and MethodNotAllowed handlers. The pattern follows the same syntax and priority rules as routes, so a
+// catch-all pattern such as "api.example.com/v1/*{any}" matches the whole subtree, and the most specific
+// (longest) matching pattern wins. Fallbacks are not bound to any method, cannot be named and cannot expire.
+// On success, it returns the newly registered fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteConflict]: If the fallback conflict with others.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidConfig]: If the provided route options are invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//
+// The handler is called with the [HandlerScope] of the handler it replaces, [NoRouteHandler] or [NoMethodHandler],
+// and the middleware registered for that scope are applied, followed by the route-specific middleware. The
+// [Context] exposes the fallback route, its pattern and params. It's safe to add a fallback while the router is
+// serving requests. This function is safe for concurrent use by multiple goroutine. To override an existing
+// fallback, use [Router.UpdateFallback].
+func (fox *Router) AddFallback(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	txn := fox.Txn(true)
+	defer txn.Abort()
+	rte, err := txn.AddFallback(pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
+	return rte, nil
+}
+
+// UpdateFallback override an existing fallback for the given pattern and matchers. On success, it returns the newly
+// registered fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteNotFound]: If the fallback does not exist.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidConfig]: If the provided route options are invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//
+// It's safe to update a fallback while the router is serving requests. This function is safe for concurrent use by
+// multiple goroutine. To add a new fallback, use [Router.AddFallback].
+func (fox *Router) UpdateFallback(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	txn := fox.Txn(true)
+	defer txn.Abort()
+	rte, err := txn.UpdateFallback(pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
+	return rte, nil
+}
+
+// DeleteFallback deletes an existing fallback for the given pattern and matchers. On success, it returns the deleted
+// fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteNotFound]: If the fallback does not exist.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//
+// It's safe to delete a fallback while the router is serving requests. This function is safe for concurrent use by
+// multiple goroutine.
+func (fox *Router) DeleteFallback(pattern string, opts ...MatcherOption) (*Route, error) {
+	txn := fox.Txn(true)
+	defer txn.Abort()
+	rte, err := txn.DeleteFallback(pattern, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
+	return rte, nil
+}
+
+// Fallback performs a lookup for a registered fallback matching the given pattern and matchers. It returns the
+// fallback [Route] if a match is found or nil otherwise. This function is safe for concurrent use by multiple
+// goroutine and while mutation on routes are ongoing.
+func (fox *Router) Fallback(pattern string, matchers ...Matcher) *Route {
+	tree := fox.getTree()
+	return findFallback(tree.fallbacks, pattern, matchers)
+}
+
+// AddFallback registers a fallback handler for the given pattern and matchers. On success, it returns the newly
+// registered fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteConflict]: If the fallback conflict with others.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidConfig]: If the provided route options are invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//   - [ErrReadOnlyTxn]: On write in a read-only transaction.
+//
+// This function is NOT thread-safe and should be run serially, along with all other [Txn] APIs. See
+// [Router.AddFallback] for the fallback semantics. To override an existing fallback, use [Txn.UpdateFallback].
+func (txn *Txn) AddFallback(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+	if !txn.write {
+		return nil, ErrReadOnlyTxn
+	}
+
+	rte, err := txn.fox.newFallback(pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = txn.rootTxn.insertFallback(rte, modeInsert); err != nil {
+		return nil, err
+	}
+	return rte, nil
+}
+
+// UpdateFallback override an existing fallback for the given pattern and matchers. On success, it returns the newly
+// registered fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteNotFound]: If the fallback does not exist.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidConfig]: If the provided route options are invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//   - [ErrReadOnlyTxn]: On write in a read-only transaction.
+//
+// This function is NOT thread-safe and should be run serially, along with all other [Txn] APIs.
+// To add a new fallback, use [Txn.AddFallback].
+func (txn *Txn) UpdateFallback(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+	if !txn.write {
+		return nil, ErrReadOnlyTxn
+	}
+
+	rte, err := txn.fox.newFallback(pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = txn.rootTxn.insertFallback(rte, modeUpdate); err != nil {
+		return nil, err
+	}
+	return rte, nil
+}
+
+// DeleteFallback deletes an existing fallback for the given pattern and matchers. On success, it returns the deleted
+// fallback [Route]. If an error occurs, it returns one of the following:
+//   - [ErrRouteNotFound]: If the fallback does not exist.
+//   - [ErrInvalidRoute]: If the provided pattern is invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+//   - [ErrReadOnlyTxn]: On write in a read-only transaction.
+//
+// This function is NOT thread-safe and should be run serially, along with all other [Txn] APIs.
+func (txn *Txn) DeleteFallback(pattern string, opts ...MatcherOption) (*Route, error) {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+	if !txn.write {
+		return nil, ErrReadOnlyTxn
+	}
+
+	parsed, err := txn.fox.parseRoute(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	rte := &Route{
+		pattern:  pattern,
+		hostEnd:  parsed.endHost,
+		tokens:   parsed.token,
+		fallback: true,
+	}
+
+	for _, opt := range opts {
+		if err = opt.applyMatcher(sealedOption{route: rte}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rte.matchers) > txn.fox.maxMatchers {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrTooManyMatchers)
+	}
+
+	route, deleted := txn.rootTxn.deleteFallback(rte)
+	if !deleted {
+		return nil, newRouteNotFoundError(rte)
+	}
+
+	return route, nil
+}
+
+// Fallback performs a lookup for a registered fallback matching the given pattern and matchers. It returns the
+// fallback [Route] if a match is found or nil otherwise. This function is NOT thread-safe and should be run serially,
+// along with all other [Txn] APIs.
+func (txn *Txn) Fallback(pattern string, matchers ...Matcher) *Route {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+
+	return findFallback(txn.rootTxn.fallbacks, pattern, matchers)
+}
+
+// findFallback returns the fallback registered in the tree rooted at root for the given pattern and matchers, or nil.
+func findFallback(root *node, pattern string, matchers []Matcher) *Route {
+	matched := root.searchPattern(pattern)
+	if matched == nil || !matched.isLeaf() {
+		return nil
+	}
+	idx := slices.IndexFunc(matched.routes, func(r *Route) bool {
+		return r.pattern == pattern && r.matchersEqual(matchers)
+	})
+	if idx < 0 {
+		return nil
+	}
+	return matched.routes[idx]
+}
+
+// matchFallback returns the fallback of the tree matching the request, if any, and captures its params in the
+// [Context]. If pathOnly is true, the hostname is ignored, as for sub-routers.
+func matchFallback(tree *iTree, c *Context, path string, pathOnly bool) *Route {
+	root := tree.fallbacks
+	if len(root.statics) == 0 && len(root.params) == 0 && len(root.wildcards) == 0 {
+		return nil
+	}
+
+	idx, n, tsr := tree.lookupFallback(c.req.Method, c.req.Host, path, c, pathOnly)
+	if n == nil || (tsr && n.routes[idx].handleSlash != RelaxedSlash) {
+		*c.params = (*c.params)[:0]
+		return nil
+	}
+	return n.routes[idx]
+}
+
+// newFallback creates a new fallback [Route], configured with the provided options.
+func (fox *Router) newFallback(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	rte, err := fox.NewRoute(nil, pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if rte.name != "" {
+		return nil, fmt.Errorf("%w: fallback cannot be named", ErrInvalidRoute)
+	}
+	if rte.expiry > 0 {
+		return nil, fmt.Errorf("%w: fallback cannot expire", ErrInvalidRoute)
+	}
+
+	rte.fallback = true
+	h := applyMiddleware(RouteHandler, rte.mws, handler)
+	rte.hnoRoute = applyMiddleware(NoRouteHandler, fox.mws, h)
+	rte.hnoMethod = applyMiddleware(NoMethodHandler, fox.mws, h)
+	if rte.lc != nil {
+		rte.hnoRoute, rte.hnoMethod = rte.track(rte.hnoRoute), rte.track(rte.hnoMethod)
+	}
+	return rte, nil
+}
+
+// serveFallback calls the fallback matching the request, if any, in place of the handler of the provided scope,
+// and reports whether the request was handled. If pathOnly is true, the hostname is ignored, as for sub-routers.
+func (fox *Router) serveFallback(c *Context, path string, scope HandlerScope, pathOnly bool) bool {
+	route := matchFallback(c.tree, c, path, pathOnly)
+	if route == nil {
+		return false
+	}
+
+	c.route = route
+	c.pattern = c.route.pattern
+	*c.paramsKeys = c.route.params
+	c.scope = scope
+	if scope == NoMethodHandler {
+		c.route.hnoMethod(c)
+	} else {
+		c.route.hnoRoute(c)
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package fox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_AddFallback(t *testing.T) {
+	report := func(name string) HandlerFunc {
+		return func(c *Context) {
+			_ = c.String(http.StatusTeapot, name+" "+scopeToString(c.Scope())+" "+c.Pattern()+" "+c.Param("any"))
+		}
+	}
+
+	f, err := NewRouter(WithNoMethod(true))
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/v1/users", emptyHandler)
+	f.MustAdd(MethodGet, "api.example.com/v1/users", emptyHandler)
+	require.NoError(t, onlyError(f.AddFallback("/v1/*{any}", report("v1"))))
+	require.NoError(t, onlyError(f.AddFallback("/v1/admin/*{any}", report("admin"))))
+	require.NoError(t, onlyError(f.AddFallback("api.example.com/v1/*{any}", report("api"))))
+
+	cases := []struct {
+		name   string
+		method string
+		host   string
+		target string
+		want   string
+		code   int
+	}{
+		{
+			name:   "not found in subtree",
+			method: http.MethodGet,
+			host:   "example.com",
+			target: "/v1/missing",
+			want:   "v1 NoRouteHandler /v1/*{any} missing",
+			code:   http.StatusTeapot,
+		},
+		{
+			name:   "longest prefix wins",
+			method: http.MethodGet,
+			host:   "example.com",
+			target: "/v1/admin/missing",
+			want:   "admin NoRouteHandler /v1/admin/*{any} missing",
+			code:   http.StatusTeapot,
+		},
+		{
+			name:   "method not allowed in subtree",
+			method: http.MethodPost,
+			host:   "example.com",
+			target: "/v1/users",
+			want:   "v1 NoMethodHandler /v1/*{any} users",
+			code:   http.StatusTeapot,
+		},
+		{
+			name:   "hostname fallback",
+			method: http.MethodGet,
+			host:   "api.example.com",
+			target: "/v1/missing",
+			want:   "api NoRouteHandler api.example.com/v1/*{any} missing",
+			code:   http.StatusTeapot,
+		},
+		{
+			name:   "outside of any fallback",
+			method: http.MethodGet,
+			host:   "example.com",
+			target: "/v2/missing",
+			want:   "404 page not found\n",
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "matching route is served",
+			method: http.MethodGet,
+			host:   "example.com",
+			target: "/v1/users",
+			want:   "",
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			req.Host = tc.host
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+
+	t.Run("allow header is set on method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, "GET", w.Header().Get(HeaderAllow))
+	})
+}
+
+func TestRouter_AddFallbackHostParam(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "{tenant}.example.com/users", emptyHandler)
+	require.NoError(t, onlyError(f.AddFallback("{tenant}.example.com/*{any}", func(c *Context) {
+		_ = c.String(http.StatusTeapot, c.Pattern()+" "+c.Param("tenant")+" "+c.Param("any"))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Host = "acme.example.com"
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "{tenant}.example.com/*{any} acme x", w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Host = "example.org"
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouter_AddFallbackMiddleware(t *testing.T) {
+	var scopes []string
+	m := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				scopes = append(scopes, name+":"+scopeToString(c.Scope()))
+				next(c)
+			}
+		}
+	}
+
+	f, err := NewRouter(
+		WithNoMethod(true),
+		WithMiddlewareFor(NoRouteHandler, m("noroute")),
+		WithMiddlewareFor(NoMethodHandler, m("nomethod")),
+		WithMiddlewareFor(RouteHandler, m("route")),
+	)
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/foo/bar", emptyHandler)
+	require.NoError(t, onlyError(f.AddFallback("/foo/*{any}", emptyHandler, WithMiddleware(m("fallback")))))
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/baz", nil)
+	f.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{"noroute:NoRouteHandler", "fallback:NoRouteHandler"}, scopes)
+
+	scopes = nil
+	req = httptest.NewRequest(http.MethodPost, "/foo/bar", nil)
+	f.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{"nomethod:NoMethodHandler", "fallback:NoMethodHandler"}, scopes)
+}
+
+func TestRouter_AddFallbackHandleNoRoute(t *testing.T) {
+	f := MustRouter()
+	require.NoError(t, onlyError(f.AddFallback("/foo/*{any}", func(c *Context) {
+		c.SetHeader("X-Fallback", "true")
+		c.Router().HandleNoRoute(c)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/bar", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Fallback"))
+}
+
+func TestRouter_AddFallbackSubRouter(t *testing.T) {
+	sub := MustRouter()
+	sub.MustAdd(MethodGet, "/users", emptyHandler)
+	require.NoError(t, onlyError(sub.AddFallback("/*{any}", func(c *Context) {
+		_ = c.String(http.StatusTeapot, c.Pattern()+" "+c.Param("any"))
+	})))
+
+	f := MustRouter()
+	f.MustAdd(MethodAny, "/api/*{mount}", Sub(sub))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/missing", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "/api/*{any} missing", w.Body.String())
+}
+
+func TestRouter_FallbackManagement(t *testing.T) {
+	f := MustRouter()
+
+	rte, err := f.AddFallback("/foo/*{any}", emptyHandler)
+	require.NoError(t, err)
+	assert.True(t, rte.Fallback())
+	assert.Equal(t, rte, f.Fallback("/foo/*{any}"))
+	assert.Nil(t, f.Route(nil, "/foo/*{any}"))
+	assert.Equal(t, 0, f.Len())
+
+	assert.ErrorIs(t, onlyError(f.AddFallback("/foo/*{any}", emptyHandler)), ErrRouteConflict)
+	assert.ErrorIs(t, onlyError(f.UpdateFallback("/bar/*{any}", emptyHandler)), ErrRouteNotFound)
+	assert.ErrorIs(t, onlyError(f.AddFallback("/bar/*{any}", emptyHandler, WithName("bar"))), ErrInvalidRoute)
+	assert.ErrorIs(t, onlyError(f.AddFallback("/bar/*{any}", emptyHandler, WithTTL(time.Minute))), ErrInvalidRoute)
+	assert.ErrorIs(t, onlyError(f.AddFallback("/bar/{", emptyHandler)), ErrInvalidRoute)
+	assert.ErrorIs(t, f.AddRoute(rte), ErrInvalidRoute)
+
+	updated, err := f.UpdateFallback("/foo/*{any}", emptyHandler)
+	require.NoError(t, err)
+	assert.Equal(t, updated, f.Fallback("/foo/*{any}"))
+
+	deleted, err := f.DeleteFallback("/foo/*{any}")
+	require.NoError(t, err)
+	assert.Equal(t, updated, deleted)
+	assert.Nil(t, f.Fallback("/foo/*{any}"))
+	assert.ErrorIs(t, onlyError(f.DeleteFallback("/foo/*{any}")), ErrRouteNotFound)
+}
+
+func TestTxn_Fallback(t *testing.T) {
+	f := MustRouter()
+	require.NoError(t, onlyError(f.AddFallback("/foo/*{any}", emptyHandler)))
+
+	txn := f.Txn(true)
+	defer txn.Abort()
+
+	require.NoError(t, onlyError(txn.AddFallback("/bar/*{any}", emptyHandler)))
+	sp := txn.Savepoint()
+	require.NoError(t, onlyError(txn.DeleteFallback("/foo/*{any}")))
+	assert.Nil(t, txn.Fallback("/foo/*{any}"))
+	require.NoError(t, txn.RollbackTo(sp))
+	assert.NotNil(t, txn.Fallback("/foo/*{any}"))
+	assert.NotNil(t, txn.Fallback("/bar/*{any}"))
+
+	// Not visible until committed.
+	assert.Nil(t, f.Fallback("/bar/*{any}"))
+	require.NoError(t, txn.Commit())
+	assert.NotNil(t, f.Fallback("/bar/*{any}"))
+
+	require.NoError(t, f.Updates(func(txn *Txn) error {
+		return txn.Truncate()
+	}))
+	assert.Nil(t, f.Fallback("/foo/*{any}"))
+	assert.Nil(t, f.Fallback("/bar/*{any}"))
+
+	rtxn := f.Txn(false)
+	assert.ErrorIs(t, onlyError(rtxn.AddFallback("/baz/*{any}", emptyHandler)), ErrReadOnlyTxn)
+	assert.ErrorIs(t, onlyError(rtxn.UpdateFallback("/baz/*{any}", emptyHandler)), ErrReadOnlyTxn)
+	assert.ErrorIs(t, onlyError(rtxn.DeleteFallback("/baz/*{any}")), ErrReadOnlyTxn)
+}
+
+func TestRouter_FallbackInflightTracking(t *testing.T) {
+	f, err := NewRouter(WithInflightTracking(true))
+	require.NoError(t, err)
+
+	var inflight int
+	rte, err := f.AddFallback("/foo/*{any}", func(c *Context) {
+		inflight = c.Route().Inflight()
+	})
+	require.NoError(t, err)
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo/bar", nil))
+	assert.Equal(t, 1, inflight)
+	assert.Equal(t, 0, rte.Inflight())
+}
@@ -7,7 +7,9 @@ package fox
 import (
 	"cmp"
 	"fmt"
+	"iter"
 	"log"
+	"log/slog"
 	"math"
 	"net"
 	"net/http"
@@ -17,6 +19,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fox-toolkit/fox/internal/slicesutil"
 	"github.com/fox-toolkit/fox/internal/stringsutil"
@@ -86,7 +89,11 @@ const (
 	RedirectPathHandler
 	// OptionsHandler scope applies to the automatic OPTIONS handler, which handles pre-flight or cross-origin requests.
 	OptionsHandler
-	// AllHandlers is a combination of all the above scopes, which can be used to apply middlewares to all types of handlers.
+	// PreRoutingHandler scope applies to the request dispatch, before the route lookup. Middleware in this scope may
+	// rewrite the request used for matching. See [WithPreRouting].
+	PreRoutingHandler
+	// AllHandlers is a combination of all the above scopes except [PreRoutingHandler], which can be used to apply
+	// middlewares to all types of handlers.
 	AllHandlers = RouteHandler | NoRouteHandler | NoMethodHandler | RedirectSlashHandler | RedirectPathHandler | OptionsHandler
 )
 
@@ -100,18 +107,33 @@ type Router struct {
 	tsrRedirect            HandlerFunc
 	pathRedirect           HandlerFunc
 	autoOPTIONS            HandlerFunc
+	preRouting             HandlerFunc
+	errorHandler           ErrorHandlerFunc
+	problemFunc            ProblemFunc
+	reqLogHandler          slog.Handler
+	reqLogAttrs            func(c *Context) []slog.Attr
 	tree                   atomic.Pointer[iTree]
+	janitor                *time.Timer
 	mws                    []middleware
+	commitHooks            []func(txn *Txn) error
+	onRetire               []func(route *Route)
 	maxParams              int
 	maxParamKeyBytes       int
 	maxMatchers            int
+	janitorAt              int64
+	janitorBackoff         time.Duration
 	mu                     sync.Mutex
+	tracked                atomic.Bool
 	handleSlash            TrailingSlashOption
 	handlePath             FixedPathOption
 	handleMethodNotAllowed bool
 	handleOPTIONS          bool
+	autoHEAD               bool
 	systemWideOPTIONS      bool
 	allowRegexp            bool
+	tracking               bool
+	problems               bool
+	closed                 bool
 }
 
 func initRouter() *Router {
@@ -119,6 +141,7 @@ func initRouter() *Router {
 	r.noRouteBase = DefaultNotFoundHandler
 	r.noMethod = DefaultMethodNotAllowedHandler
 	r.autoOPTIONS = DefaultOptionsHandler
+	r.errorHandler = DefaultErrorHandler
 	r.tsrRedirect = internalTrailingSlashHandler
 	r.pathRedirect = internalFixedPathHandler
 	r.clientip = noClientIPResolver{}
@@ -140,6 +163,7 @@ type RouterInfo struct {
 	FixedPathOption       FixedPathOption
 	MethodNotAllowed      bool
 	AutoOptions           bool
+	AutoHEAD              bool
 	SystemWideOptions     bool
 	ClientIP              bool
 	AllowRegexp           bool
@@ -178,6 +202,9 @@ func NewRouter(opts ...GlobalOption) (*Router, error) {
 	router.tsrRedirect = applyMiddleware(RedirectSlashHandler, router.mws, router.tsrRedirect)
 	router.pathRedirect = applyMiddleware(RedirectPathHandler, router.mws, router.pathRedirect)
 	router.autoOPTIONS = applyMiddleware(OptionsHandler, router.mws, router.autoOPTIONS)
+	if slices.ContainsFunc(router.mws, func(m middleware) bool { return m.scope&PreRoutingHandler != 0 }) {
+		router.preRouting = applyMiddleware(PreRoutingHandler, router.mws, router.dispatch)
+	}
 
 	router.tree.Store(router.newTree())
 	return router, nil
@@ -210,7 +237,9 @@ func (fox *Router) Add(methods []string, pattern string, handler HandlerFunc, op
 	if err != nil {
 		return nil, err
 	}
-	txn.Commit()
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
 	return rte, nil
 }
 
@@ -227,8 +256,27 @@ func (fox *Router) AddRoute(route *Route) error {
 	if err := txn.AddRoute(route); err != nil {
 		return err
 	}
-	txn.Commit()
-	return nil
+	return txn.Commit()
+}
+
+// Load registers all routes yielded by the provided sequence in a single transaction. This is the preferred way to
+// register very large route tables, typically at startup. It produces exactly the same tree, and fails with exactly
+// the same errors, as registering each route in sequence order with [Router.AddRoute]. If the router has no route,
+// the tree is built bottom-up in a single pass from the sorted patterns, instead of inserting routes one at a time.
+// If an error occurs, no route is registered and it returns one of the following:
+//   - [ErrRouteConflict]: If the route conflict with others.
+//   - [ErrRouteNameExist]: If the route name is already registered.
+//   - [ErrInvalidRoute]: If the route is missing.
+//
+// It's safe to load routes while the router is serving requests. This function is safe for concurrent use by
+// multiple goroutine. See also [Txn.Load] and [NewBulkLoader].
+func (fox *Router) Load(routes iter.Seq[*Route]) error {
+	txn := fox.Txn(true)
+	defer txn.Abort()
+	if err := txn.Load(routes); err != nil {
+		return err
+	}
+	return txn.Commit()
 }
 
 // Update override an existing route for the given methods, pattern and matchers. On success, it returns the newly registered [Route].
@@ -250,7 +298,9 @@ func (fox *Router) Update(methods []string, pattern string, handler HandlerFunc,
 	if err != nil {
 		return nil, err
 	}
-	txn.Commit()
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
 	return rte, nil
 }
 
@@ -268,8 +318,7 @@ func (fox *Router) UpdateRoute(route *Route) error {
 	if err := txn.UpdateRoute(route); err != nil {
 		return err
 	}
-	txn.Commit()
-	return nil
+	return txn.Commit()
 }
 
 // Delete deletes an existing route for the given methods, pattern and matchers. On success, it returns the deleted [Route].
@@ -286,7 +335,9 @@ func (fox *Router) Delete(methods []string, pattern string, opts ...MatcherOptio
 	if err != nil {
 		return nil, err
 	}
-	txn.Commit()
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
 	return route, nil
 }
 
@@ -304,7 +355,9 @@ func (fox *Router) DeleteRoute(route *Route) (*Route, error) {
 	if err != nil {
 		return nil, err
 	}
-	txn.Commit()
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
 	return route, nil
 }
 
@@ -418,13 +471,20 @@ func (fox *Router) NewRoute(methods []string, pattern string, handler HandlerFun
 	}
 
 	rte := &Route{
-		clientip:    fox.clientip,
-		hbase:       handler,
-		pattern:     pattern,
-		handleSlash: fox.handleSlash,
-		hostEnd:     parsed.endHost,
-		tokens:      parsed.token,
-		catchEmpty:  parsed.startCatchAll > 0 && pattern[parsed.startCatchAll] == starDelim,
+		clientip:     fox.clientip,
+		errorHandler: fox.errorHandler,
+		problemFunc:  fox.problemFunc,
+		hbase:        handler,
+		pattern:      pattern,
+		handleSlash:  fox.handleSlash,
+		problems:     fox.problems,
+		hostEnd:      parsed.endHost,
+		tokens:       parsed.token,
+		catchEmpty:   parsed.startCatchAll > 0 && pattern[parsed.startCatchAll] == starDelim,
+		onRetire:     slices.Clip(fox.onRetire),
+	}
+	if fox.tracking {
+		rte.lc = newLifecycle()
 	}
 
 	rte.params = make([]string, 0, parsed.paramCnt)
@@ -440,6 +500,11 @@ func (fox *Router) NewRoute(methods []string, pattern string, handler HandlerFun
 		}
 	}
 
+	// Retire callbacks only fire for tracked routes, regardless of the order of the options.
+	if len(rte.onRetire) > 0 && rte.lc == nil {
+		rte.lc = newLifecycle()
+	}
+
 	if len(rte.matchers) > fox.maxMatchers {
 		return nil, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrTooManyMatchers)
 	}
@@ -448,7 +513,16 @@ func (fox *Router) NewRoute(methods []string, pattern string, handler HandlerFun
 	}
 
 	rte.priority = cmp.Or(rte.priority, uint(len(rte.matchers)))
-	rte.hself, rte.hall = applyRouteMiddleware(append(fox.mws, rte.mws...), handler)
+	mws := append(fox.mws, rte.mws...)
+	rte.hself, rte.hall = applyRouteMiddleware(mws, handler)
+	for _, mw := range mws {
+		if mw.scope&RouteHandler != 0 {
+			rte.mwsLen++
+		}
+	}
+	if rte.lc != nil {
+		rte.hself, rte.hall = rte.track(rte.hself), rte.track(rte.hall)
+	}
 
 	if len(methods) > 0 {
 		// As a defensive mesure, keep our own copy of the provided slice.
@@ -462,9 +536,14 @@ func (fox *Router) NewRoute(methods []string, pattern string, handler HandlerFun
 }
 
 // HandleNoRoute calls the no route handler with the provided [Context].
-// Note that this bypasses any middleware attached to the no route handler.
+// Note that this bypasses any middleware attached to the no route handler. When called from a fallback handler
+// registered with [Router.AddFallback], the request is served as if no fallback had matched.
 func (fox *Router) HandleNoRoute(c *Context) {
-	if c.scope == NoRouteHandler {
+	if c.route != nil && c.route.fallback {
+		c.route = nil
+		c.pattern = ""
+		*c.params = (*c.params)[:0]
+	} else if c.scope == NoRouteHandler {
 		caller := relevantCaller()
 		log.Printf("fox: recursive call to router.HandleNoRoute from %s (%s:%d)", caller.Function, path.Base(caller.File), caller.Line)
 		return
@@ -495,8 +574,8 @@ func (fox *Router) Iter() Iter {
 
 // Updates executes a function within the context of a read-write managed transaction. If no error is returned from the
 // function then the transaction is committed. If an error is returned then the entire transaction is aborted.
-// Updates returns any error returned by fn. This function is safe for concurrent use by multiple goroutine and while
-// the router is serving request. However [Txn] itself is NOT tread-safe.
+// Updates returns any error returned by fn or by a hook registered with [WithCommitHook]. This function is safe for
+// concurrent use by multiple goroutine and while the router is serving request. However [Txn] itself is NOT tread-safe.
 // See also [Router.Txn] for unmanaged transaction and [Router.View] for managed read-only transaction.
 func (fox *Router) Updates(fn func(txn *Txn) error) error {
 	txn := fox.Txn(true)
@@ -510,8 +589,7 @@ func (fox *Router) Updates(fn func(txn *Txn) error) error {
 	if err := fn(txn); err != nil {
 		return err
 	}
-	txn.Commit()
-	return nil
+	return txn.Commit()
 }
 
 // View executes a function within the context of a read-only managed transaction. View returns any error returned
@@ -539,6 +617,7 @@ func (fox *Router) RouterInfo() RouterInfo {
 		MaxRouteMatchers:      fox.maxMatchers,
 		MethodNotAllowed:      fox.handleMethodNotAllowed,
 		AutoOptions:           fox.handleOPTIONS,
+		AutoHEAD:              fox.autoHEAD,
 		TrailingSlashOption:   fox.handleSlash,
 		FixedPathOption:       fox.handlePath,
 		ClientIP:              !ok,
@@ -547,6 +626,31 @@ func (fox *Router) RouterInfo() RouterInfo {
 	}
 }
 
+// TreeStats hold statistics on the routing tree.
+type TreeStats struct {
+	// Routes is the number of registered routes.
+	Routes int
+	// Nodes is the number of nodes in the patterns tree.
+	Nodes int
+	// MaxDepth is the maximum number of nodes with params or wildcards children, i.e. backtracking points,
+	// traversed to reach a route.
+	MaxDepth int
+	// MaxParams is the maximum number of params of a registered route.
+	MaxParams int
+}
+
+// TreeStats returns statistics on the current routing tree. This function is safe for concurrent use by multiple
+// goroutine and while mutation on routes are ongoing.
+func (fox *Router) TreeStats() TreeStats {
+	tree := fox.getTree()
+	return TreeStats{
+		Routes:    tree.size,
+		Nodes:     countNodes(tree.patterns),
+		MaxDepth:  tree.maxDepth,
+		MaxParams: tree.maxParams,
+	}
+}
+
 // Txn create a new read-write or read-only transaction. Each [Txn] must be finalized with [Txn.Commit] or [Txn.Abort].
 // It's safe to create transaction from multiple goroutine and while the router is serving request.
 // However, the returned [Txn] itself is NOT tread-safe.
@@ -563,12 +667,103 @@ func (fox *Router) Txn(write bool) *Txn {
 	}
 }
 
+// Close stops the background janitor that collects expired routes. Expired routes still stop matching requests
+// immediately, but are no longer removed from the tree. Close does not affect the requests being served, nor the
+// router ability to register routes. It is safe to call Close multiple times.
+func (fox *Router) Close() error {
+	fox.mu.Lock()
+	defer fox.mu.Unlock()
+	fox.closed = true
+	if fox.janitor != nil {
+		fox.janitor.Stop()
+		fox.janitor = nil
+		fox.janitorAt = 0
+	}
+	return nil
+}
+
+// scheduleJanitor arms the janitor to collect expired routes at the given time (unix nano), unless it is already
+// scheduled to run earlier. It must be called with the write lock held.
+func (fox *Router) scheduleJanitor(at int64) {
+	if fox.closed {
+		return
+	}
+	if fox.janitor != nil {
+		if fox.janitorAt <= at {
+			return
+		}
+		fox.janitor.Stop()
+	}
+	fox.janitorAt = at
+	fox.janitor = time.AfterFunc(time.Until(time.Unix(0, at)), fox.collectExpired)
+}
+
+// collectExpired deletes all expired routes within a single write transaction and re-arms the janitor for the next
+// route to expire, if any. Expired routes never match a request, so this only reclaims them from the tree. The
+// collection goes through the regular commit path, so commit hooks observe it. If a hook rejects it, the collection
+// is retried with an exponential backoff.
+func (fox *Router) collectExpired() {
+	txn := fox.Txn(true)
+	defer txn.Abort()
+
+	if fox.closed {
+		return
+	}
+	fox.janitor = nil
+	fox.janitorAt = 0
+
+	now := time.Now().UnixNano()
+	var next int64
+	expired := make([]*Route, 0)
+	for route := range txn.Iter().All() {
+		if route.expiry == 0 {
+			continue
+		}
+		if route.expiry <= now {
+			expired = append(expired, route)
+			continue
+		}
+		if next == 0 || route.expiry < next {
+			next = route.expiry
+		}
+	}
+
+	if len(expired) == 0 {
+		if next > 0 {
+			fox.scheduleJanitor(next)
+		}
+		return
+	}
+
+	for _, route := range expired {
+		txn.rootTxn.delete(route)
+	}
+	txn.rootTxn.expiry = next
+	backoff := fox.janitorBackoff
+	fox.janitorBackoff = 0
+	if err := txn.Commit(); err != nil {
+		// A commit hook rejected the collection and the transaction has been aborted. Expired routes never match a
+		// request in the meantime.
+		fox.mu.Lock()
+		fox.janitorBackoff = min(max(2*backoff, janitorMinBackoff), janitorMaxBackoff)
+		fox.scheduleJanitor(time.Now().Add(fox.janitorBackoff).UnixNano())
+		fox.mu.Unlock()
+	}
+}
+
+const (
+	janitorMinBackoff = 100 * time.Millisecond
+	janitorMaxBackoff = time.Minute
+)
+
 func (fox *Router) newTree() *iTree {
 	tree := &iTree{
-		fox:      fox,
-		patterns: new(node),
-		names:    new(node),
-		methods:  make(map[string]uint),
+		fox:       fox,
+		patterns:  new(node),
+		names:     new(node),
+		fallbacks: new(node),
+		epoch:     newEpoch(1),
+		methods:   make(map[string]uint),
 	}
 	tree.pool = sync.Pool{
 		New: func() any {
@@ -587,10 +782,36 @@ func (fox *Router) getTree() *iTree {
 // ServeHTTP is the main entry point to serve a request. It handles all incoming HTTP requests and dispatches them
 // to the appropriate handler function based on the request's method and path.
 func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-
-	tree := fox.getTree()
+	tree, e := fox.enter()
+	if e != nil {
+		defer e.release()
+	}
 	c := tree.pool.Get().(*Context)
 	c.reset(w, r)
+	if fox.preRouting != nil {
+		c.route = nil
+		c.pattern = ""
+		c.scope = PreRoutingHandler
+		fox.preRouting(c)
+	} else {
+		fox.serve(c)
+	}
+	tree.pool.Put(c)
+}
+
+// dispatch is the final handler of the pre-routing middleware chain, which serves the possibly rewritten request.
+func (fox *Router) dispatch(c *Context) {
+	c.scope = RouteHandler
+	*c.params = (*c.params)[:0]
+	fox.serve(c)
+}
+
+// serve dispatches the request of the [Context] to the matching handler of its tree.
+func (fox *Router) serve(c *Context) {
+
+	tree := c.tree
+	r := c.req
+	w := c.w
 
 	path := routingPath(r)
 
@@ -600,7 +821,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		c.pattern = c.route.pattern
 		*c.paramsKeys = c.route.params
 		c.route.hall(c)
-		tree.pool.Put(c)
 		return
 	}
 
@@ -612,7 +832,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				c.pattern = c.route.pattern
 				*c.paramsKeys = c.route.params
 				route.hall(c)
-				tree.pool.Put(c)
 				return
 			}
 
@@ -622,7 +841,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				c.pattern = ""
 				c.scope = RedirectSlashHandler
 				fox.tsrRedirect(c)
-				tree.pool.Put(c)
 				return
 			}
 		}
@@ -635,7 +853,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				c.pattern = c.route.pattern
 				*c.paramsKeys = c.route.params
 				c.route.hall(c)
-				tree.pool.Put(c)
 				return
 			}
 		case RedirectPath:
@@ -645,7 +862,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				c.pattern = ""
 				c.scope = RedirectPathHandler
 				fox.pathRedirect(c)
-				tree.pool.Put(c)
 				return
 			}
 		default:
@@ -653,6 +869,19 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	*c.params = (*c.params)[:0]
+
+	// Serve HEAD requests with the matching GET route, see https://www.rfc-editor.org/rfc/rfc9110#section-9.3.2.
+	if fox.autoHEAD && r.Method == http.MethodHead {
+		if idx, n, tsr := tree.lookup(http.MethodGet, r.Host, path, c, false); n != nil && (!tsr || n.routes[idx].handleSlash == RelaxedSlash) {
+			c.route = n.routes[idx]
+			c.pattern = c.route.pattern
+			*c.paramsKeys = c.route.params
+			serveHEAD(c, c.route.hall)
+			return
+		}
+		*c.params = (*c.params)[:0]
+	}
+
 	c.route = nil
 	c.pattern = ""
 
@@ -677,6 +906,14 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			sb.WriteString(method)
 		}
 
+		_, hasGET := tree.methods[http.MethodGet]
+		if _, hasHEAD := tree.methods[http.MethodHead]; fox.autoHEAD && hasGET && !hasHEAD {
+			if sb.Len() > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(http.MethodHead)
+		}
+
 		// Include OPTIONS in Allow only if explicitly registered or if auto-OPTIONS is enabled
 		// with at least one route. A server responding solely to OPTIONS * doesn't meaningfully
 		// "support" OPTIONS for resource access.
@@ -689,7 +926,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set(HeaderAllow, sb.String())
 		}
 		w.WriteHeader(http.StatusOK)
-		tree.pool.Put(c)
 		return
 	}
 
@@ -706,7 +942,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if foundOrigin && foundAcrm {
 			c.scope = OptionsHandler
 			fox.autoOPTIONS(c)
-			tree.pool.Put(c)
 			return
 		}
 
@@ -722,6 +957,7 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		fox.addAutoHEAD(seen)
 
 		if len(seen) > 0 {
 			var sb strings.Builder
@@ -734,7 +970,6 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set(HeaderAllow, sb.String())
 			c.scope = OptionsHandler
 			fox.autoOPTIONS(c)
-			tree.pool.Put(c)
 			return
 		}
 	} else if fox.handleMethodNotAllowed {
@@ -752,6 +987,7 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		fox.addAutoHEAD(seen)
 
 		if len(seen) > 1 {
 			var sb strings.Builder
@@ -775,16 +1011,20 @@ func (fox *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 
 			w.Header().Set(HeaderAllow, sb.String())
+			if fox.serveFallback(c, path, NoMethodHandler, false) {
+				return
+			}
 			c.scope = NoMethodHandler
 			fox.noMethod(c)
-			tree.pool.Put(c)
 			return
 		}
 	}
 
+	if fox.serveFallback(c, path, NoRouteHandler, false) {
+		return
+	}
 	c.scope = NoRouteHandler
 	fox.noRoute(c)
-	tree.pool.Put(c)
 }
 
 func (fox *Router) serveSubRouter(c *Context, path string) {
@@ -847,6 +1087,17 @@ func (fox *Router) serveSubRouter(c *Context, path string) {
 		}
 	}
 
+	if fox.autoHEAD && r.Method == http.MethodHead {
+		*c.params = (*c.params)[:paramsOffset]
+		if idx, n, tsr := tree.lookupByPath(http.MethodGet, path, c, false); n != nil && (!tsr || n.routes[idx].handleSlash == RelaxedSlash) {
+			c.route = n.routes[idx]
+			*c.paramsKeys = append(*c.paramsKeys, c.route.params...)
+			c.pattern = c.route.pattern
+			serveHEAD(c, c.route.hall)
+			return
+		}
+	}
+
 	*c.params = (*c.params)[:0]
 	c.route = nil
 	c.pattern = ""
@@ -882,6 +1133,7 @@ func (fox *Router) serveSubRouter(c *Context, path string) {
 				}
 			}
 		}
+		fox.addAutoHEAD(seen)
 
 		if len(seen) > 0 {
 			var sb strings.Builder
@@ -912,6 +1164,7 @@ func (fox *Router) serveSubRouter(c *Context, path string) {
 				}
 			}
 		}
+		fox.addAutoHEAD(seen)
 
 		if len(seen) > 1 {
 			var sb strings.Builder
@@ -935,6 +1188,9 @@ func (fox *Router) serveSubRouter(c *Context, path string) {
 			}
 
 			w.Header().Set(HeaderAllow, sb.String())
+			if fox.serveFallback(c, path, NoMethodHandler, true) {
+				return
+			}
 			c.scope = NoMethodHandler
 			fox.noMethod(c)
 			tree.pool.Put(c)
@@ -942,6 +1198,9 @@ func (fox *Router) serveSubRouter(c *Context, path string) {
 		}
 	}
 
+	if fox.serveFallback(c, path, NoRouteHandler, true) {
+		return
+	}
 	c.scope = NoRouteHandler
 	fox.noRoute(c)
 }
@@ -957,7 +1216,8 @@ const (
 // route prefix are delegated to the sub-router which handles the remaining path. The parent route pattern
 // should end with a catch-all. Parameters captured by the parent route are preserved and accessible alongside
 // any parameters matched by the sub-router. Similarly, [http.Request.Pattern] is the concatenation of the
-// parent and sub-router patterns. See also [Router.Add] for registering the handler.
+// parent and sub-router patterns, and values stored with [Context.Set] are visible to the sub-router handlers. See also
+// [Router.Add] for registering the handler.
 func Sub(router *Router) HandlerFunc {
 	return func(c *Context) {
 		route := c.Route()
@@ -965,12 +1225,17 @@ func Sub(router *Router) HandlerFunc {
 			panic("fox: invalid use of Sub in non-RouteHandler scope")
 		}
 
-		tree := router.getTree()
+		tree, e := router.enter()
+		if e != nil {
+			defer e.release()
+		}
 		subCtx := tree.pool.Get().(*Context)
 		subCtx.resetWithWriter(c.Writer(), c.Request())
 		// Any recovery middleware would probably be before the mounted route, so let's defer this one for safety.
 		defer tree.pool.Put(subCtx)
 
+		subCtx.store = append(subCtx.store, c.store...)
+		subCtx.reqID = c.reqID
 		*subCtx.subPatterns = append(*subCtx.subPatterns, *c.subPatterns...)
 
 		lastTkType := route.tokens[len(route.tokens)-1].typ
@@ -1025,15 +1290,26 @@ func Sub(router *Router) HandlerFunc {
 }
 
 // DefaultNotFoundHandler is a simple [HandlerFunc] that replies to each request
-// with a “404 page not found” reply.
+// with a “404 page not found” reply, or a problem details document if enabled with [WithProblemDetails].
 func DefaultNotFoundHandler(c *Context) {
-	http.Error(c.Writer(), "404 page not found", http.StatusNotFound)
+	replyError(c, http.StatusNotFound, "404 page not found", "")
 }
 
 // DefaultMethodNotAllowedHandler is a simple [HandlerFunc] that replies to each request
-// with a “405 Method Not Allowed” reply.
+// with a “405 Method Not Allowed” reply, or a problem details document if enabled with [WithProblemDetails].
 func DefaultMethodNotAllowedHandler(c *Context) {
-	http.Error(c.Writer(), http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	replyError(c, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed), "")
+}
+
+// DefaultFallbackHandler is a simple [HandlerFunc] for fallbacks registered with [Router.AddFallback]. It replies
+// like [DefaultMethodNotAllowedHandler] when called with the [NoMethodHandler] scope, and like [DefaultNotFoundHandler]
+// otherwise, using the problem details settings of the fallback route.
+func DefaultFallbackHandler(c *Context) {
+	if c.Scope() == NoMethodHandler {
+		DefaultMethodNotAllowedHandler(c)
+		return
+	}
+	DefaultNotFoundHandler(c)
 }
 
 // DefaultOptionsHandler is a simple [HandlerFunc] that replies to each request with a "200 OK" reply.
@@ -1055,7 +1331,7 @@ func internalTrailingSlashHandler(c *Context) {
 		path += "?" + q
 	}
 
-	http.Redirect(c.Writer(), req, path, code)
+	replyRedirect(c, path, code)
 }
 
 func internalFixedPathHandler(c *Context) {
@@ -1072,7 +1348,7 @@ func internalFixedPathHandler(c *Context) {
 		cleanedPath += "?" + q
 	}
 
-	http.Redirect(c.Writer(), req, cleanedPath, code)
+	replyRedirect(c, cleanedPath, code)
 }
 
 const (
@@ -1091,15 +1367,31 @@ type parsedRoute struct {
 
 // parseRoute parse and validate the route in a single pass.
 func (fox *Router) parseRoute(url string) (parsedRoute, error) {
+	return parser{
+		maxParams:        fox.maxParams,
+		maxParamKeyBytes: fox.maxParamKeyBytes,
+		allowRegexp:      fox.allowRegexp,
+	}.parse(url)
+}
+
+// parser holds the router options used to parse and validate a route pattern.
+type parser struct {
+	maxParams        int
+	maxParamKeyBytes int
+	allowRegexp      bool
+}
+
+// parse parse and validate the route in a single pass. Errors are reported as [PatternError].
+func (p parser) parse(url string) (parsedRoute, error) {
 	endHost := strings.IndexByte(url, '/')
 	if endHost == -1 {
-		return parsedRoute{}, fmt.Errorf("%w: missing trailing '/' after hostname", ErrInvalidRoute)
+		return parsedRoute{}, newPatternError(url, len(url), "missing trailing '/' after hostname")
 	}
 	if strings.HasPrefix(url, ".") {
-		return parsedRoute{}, fmt.Errorf("%w: illegal leading '.' in hostname label", ErrInvalidRoute)
+		return parsedRoute{}, newPatternError(url, 0, "illegal leading '.' in hostname label")
 	}
 	if strings.HasPrefix(url, "-") {
-		return parsedRoute{}, fmt.Errorf("%w: illegal leading '-' in hostname label", ErrInvalidRoute)
+		return parsedRoute{}, newPatternError(url, 0, "illegal leading '-' in hostname label")
 	}
 
 	var delim byte
@@ -1129,12 +1421,12 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 		case stateParam:
 			if url[i] == '}' {
 				if !inParam {
-					return parsedRoute{}, fmt.Errorf("%w: missing parameter name between '{}'", ErrInvalidRoute)
+					return parsedRoute{}, newPatternError(url, i, "missing parameter name between '{}'")
 				}
 				inParam = false
 
 				if i+1 < len(url) && url[i+1] != delim && url[i+1] != '/' {
-					return parsedRoute{}, fmt.Errorf("%w: illegal character '%s' after '{param}'", ErrInvalidRoute, string(url[i+1]))
+					return parsedRoute{}, newPatternError(url, i+1, "illegal character '%s' after '{param}'", string(url[i+1]))
 				}
 
 				if i < endHost {
@@ -1162,33 +1454,33 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 				continue
 			}
 
-			if i-startParam > fox.maxParamKeyBytes {
-				return parsedRoute{}, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrParamKeyTooLarge)
+			if i-startParam > p.maxParamKeyBytes {
+				return parsedRoute{}, newPatternError(url, startParam, "%w", ErrParamKeyTooLarge)
 			}
 
 			if url[i] == delim || url[i] == '/' || url[i] == '*' || url[i] == '+' || url[i] == '{' {
-				return parsedRoute{}, fmt.Errorf("%w: illegal character '%s' in '{param}'", ErrInvalidRoute, string(url[i]))
+				return parsedRoute{}, newPatternError(url, i, "illegal character '%s' in '{param}'", string(url[i]))
 			}
 			inParam = true
 			i++
 		case stateCatchAll:
 			if url[i] == '}' {
 				if !inParam {
-					return parsedRoute{}, fmt.Errorf("%w: missing parameter name between '%c{}'", ErrInvalidRoute, url[startCatchAll])
+					return parsedRoute{}, newPatternError(url, i, "missing parameter name between '%c{}'", url[startCatchAll])
 				}
 				inParam = false
 
 				if i+1 < len(url) && url[i+1] != delim && url[i+1] != '/' {
-					return parsedRoute{}, fmt.Errorf("%w: illegal character '%s' after '%c{param}'", ErrInvalidRoute, string(url[i+1]), url[startCatchAll])
+					return parsedRoute{}, newPatternError(url, i+1, "illegal character '%s' after '%c{param}'", string(url[i+1]), url[startCatchAll])
 				}
 
 				if previous == stateCatchAll && countStatic <= 1 {
-					return parsedRoute{}, fmt.Errorf("%w: consecutive wildcard not allowed", ErrInvalidRoute)
+					return parsedRoute{}, newPatternError(url, startCatchAll, "consecutive wildcard not allowed")
 				}
 
 				if i < len(url)-1 {
 					if url[startCatchAll] == '*' {
-						return parsedRoute{}, fmt.Errorf("%w: '*{param}' allowed only as suffix", ErrInvalidRoute)
+						return parsedRoute{}, newPatternError(url, startCatchAll, "'*{param}' allowed only as suffix")
 					}
 					// reset
 					startCatchAll = 0
@@ -1217,7 +1509,7 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 				// empty strings, making it impossible to disambiguate routes with different regexps that
 				// both match the same empty-string path.
 				if url[startCatchAll] == '*' {
-					return parsedRoute{}, fmt.Errorf("%w: %w in optional wildcard", ErrInvalidRoute, ErrRegexpNotAllowed)
+					return parsedRoute{}, newPatternError(url, i, "%w in optional wildcard", ErrRegexpNotAllowed)
 				}
 				previous = state
 				state = stateRegex
@@ -1225,39 +1517,39 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 				continue
 			}
 
-			if i-startParam > fox.maxParamKeyBytes {
-				return parsedRoute{}, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrParamKeyTooLarge)
+			if i-startParam > p.maxParamKeyBytes {
+				return parsedRoute{}, newPatternError(url, startParam, "%w", ErrParamKeyTooLarge)
 			}
 
 			if url[i] == delim || url[i] == '/' || url[i] == '*' || url[i] == '+' || url[i] == '{' {
-				return parsedRoute{}, fmt.Errorf("%w: illegal character '%s' in '%c{param}'", ErrInvalidRoute, string(url[i]), url[startCatchAll])
+				return parsedRoute{}, newPatternError(url, i, "illegal character '%s' in '%c{param}'", string(url[i]), url[startCatchAll])
 			}
 			inParam = true
 			i++
 		case stateRegex:
-			if !fox.allowRegexp {
-				return parsedRoute{}, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrRegexpNotAllowed)
+			if !p.allowRegexp {
+				return parsedRoute{}, newPatternError(url, i, "%w", ErrRegexpNotAllowed)
 			}
 			if previous == stateCatchAll && countStatic <= 1 {
-				return parsedRoute{}, fmt.Errorf("%w: consecutive wildcard not allowed", ErrInvalidRoute)
+				return parsedRoute{}, newPatternError(url, startCatchAll, "consecutive wildcard not allowed")
 			}
 
 			idx := braceIndice(url[i:], 1)
 			if idx == -1 {
-				return parsedRoute{}, fmt.Errorf("%w: unbalanced braces in regular expression", ErrInvalidRoute)
+				return parsedRoute{}, newPatternError(url, i, "unbalanced braces in regular expression")
 			}
 			if idx == 0 {
-				return parsedRoute{}, fmt.Errorf("%w: missing regular expression", ErrInvalidRoute)
+				return parsedRoute{}, newPatternError(url, i, "missing regular expression")
 			}
 
 			pattern := url[i : i+idx]
 			re, err := regexp.Compile("^" + pattern + "$")
 			if err != nil {
-				return parsedRoute{}, fmt.Errorf("%w: %w", ErrInvalidRoute, err)
+				return parsedRoute{}, newPatternError(url, i, "%w", err)
 			}
 
 			if re.NumSubexp() > 0 {
-				return parsedRoute{}, fmt.Errorf("%w: illegal capture group '%s': use (?:pattern) instead", ErrInvalidRoute, pattern)
+				return parsedRoute{}, newPatternError(url, i, "illegal capture group '%s': use (?:pattern) instead", pattern)
 			}
 
 			typ := nodeWildcard
@@ -1316,7 +1608,7 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 				startCatchAll = i
 				i++
 				if i < len(url) && url[i] != '{' {
-					return parsedRoute{}, fmt.Errorf("%w: missing '{param}' after '%c' catch-all delimiter", ErrInvalidRoute, url[startCatchAll])
+					return parsedRoute{}, newPatternError(url, startCatchAll, "missing '{param}' after '%c' catch-all delimiter", url[startCatchAll])
 				}
 				startParam = i
 				paramCnt++
@@ -1335,40 +1627,40 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 					case c == '-':
 						// Byte before dash cannot be dot.
 						if last == '.' {
-							return parsedRoute{}, fmt.Errorf("%w: illegal '-' after '.' in hostname label", ErrInvalidRoute)
+							return parsedRoute{}, newPatternError(url, i, "illegal '-' after '.' in hostname label")
 						}
 						partlen++
 						nonNumeric = true
 					case c == '.':
 						// Byte before dot cannot be dot.
 						if last == '.' && url[i-1] != '}' {
-							return parsedRoute{}, fmt.Errorf("%w: unexpected consecutive '.' in hostname", ErrInvalidRoute)
+							return parsedRoute{}, newPatternError(url, i, "unexpected consecutive '.' in hostname")
 						}
 						// Byte before dot cannot be dash.
 						if last == '-' {
-							return parsedRoute{}, fmt.Errorf("%w: illegal '-' before '.' in hostname label", ErrInvalidRoute)
+							return parsedRoute{}, newPatternError(url, i, "illegal '-' before '.' in hostname label")
 						}
 						if partlen > 63 {
-							return parsedRoute{}, fmt.Errorf("%w: hostname label exceed 63 characters", ErrInvalidRoute)
+							return parsedRoute{}, newPatternError(url, i, "hostname label exceed 63 characters")
 						}
 						totallen += partlen + 1 // +1 count the current dot
 						partlen = 0
 					case 'A' <= c && c <= 'Z':
-						return parsedRoute{}, fmt.Errorf("%w: illegal uppercase character '%s' in hostname label", ErrInvalidRoute, string(c))
+						return parsedRoute{}, newPatternError(url, i, "illegal uppercase character '%s' in hostname label", string(c))
 					default:
-						return parsedRoute{}, fmt.Errorf("%w: illegal character '%s' in hostname label", ErrInvalidRoute, string(c))
+						return parsedRoute{}, newPatternError(url, i, "illegal character '%s' in hostname label", string(c))
 					}
 					last = c
 				} else {
 					c := url[i]
 					// reject any ASCII control character.
 					if c < ' ' || c == 0x7f {
-						return parsedRoute{}, fmt.Errorf("%w: illegal control character in path", ErrInvalidRoute)
+						return parsedRoute{}, newPatternError(url, i, "illegal control character in path")
 					}
 
 					// reject any consecutive slash
 					if i > endHost && c == '/' && url[i-1] == '/' {
-						return parsedRoute{}, fmt.Errorf("%w: illegal consecutive slashes in path", ErrInvalidRoute)
+						return parsedRoute{}, newPatternError(url, i, "illegal consecutive slashes in path")
 					}
 
 					// reject dot-based traversal patterns
@@ -1378,26 +1670,26 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 							nextChar := url[nextIdx]
 							switch nextChar {
 							case '/':
-								return parsedRoute{}, fmt.Errorf("%w: illegal path traversal pattern '/./'", ErrInvalidRoute)
+								return parsedRoute{}, newPatternError(url, i, "illegal path traversal pattern '/./'")
 							case '.':
 								nextNextIdx := nextIdx + 1
 								if nextNextIdx < len(url) {
 									if url[nextNextIdx] == '/' {
-										return parsedRoute{}, fmt.Errorf("%w: illegal path traversal pattern '/../'", ErrInvalidRoute)
+										return parsedRoute{}, newPatternError(url, i, "illegal path traversal pattern '/../'")
 									}
 								} else {
-									return parsedRoute{}, fmt.Errorf("%w: illegal path traversal pattern '/..' at end", ErrInvalidRoute)
+									return parsedRoute{}, newPatternError(url, i, "illegal path traversal pattern '/..' at end")
 								}
 							}
 						} else {
-							return parsedRoute{}, fmt.Errorf("%w: illegal path traversal pattern '/.' at end", ErrInvalidRoute)
+							return parsedRoute{}, newPatternError(url, i, "illegal path traversal pattern '/.' at end")
 						}
 					}
 				}
 			}
 
-			if paramCnt > fox.maxParams {
-				return parsedRoute{}, fmt.Errorf("%w: %w", ErrInvalidRoute, ErrTooManyParams)
+			if paramCnt > p.maxParams {
+				return parsedRoute{}, newPatternError(url, startParam, "%w", ErrTooManyParams)
 			}
 			i++
 		}
@@ -1406,32 +1698,32 @@ func (fox *Router) parseRoute(url string) (parsedRoute, error) {
 	if endHost > 0 {
 		totallen += partlen
 		if last == '-' {
-			return parsedRoute{}, fmt.Errorf("%w: illegal trailing '-' in hostname label", ErrInvalidRoute)
+			return parsedRoute{}, newPatternError(url, endHost-1, "illegal trailing '-' in hostname label")
 		}
 		if url[endHost-1] == '.' {
-			return parsedRoute{}, fmt.Errorf("%w: illegal trailing '.' in hostname label", ErrInvalidRoute)
+			return parsedRoute{}, newPatternError(url, endHost-1, "illegal trailing '.' in hostname label")
 		}
 		if !nonNumeric {
-			return parsedRoute{}, fmt.Errorf("%w: invalid all numeric hostname", ErrInvalidRoute)
+			return parsedRoute{}, newPatternError(url, 0, "invalid all numeric hostname")
 		}
 		if partlen > 63 {
-			return parsedRoute{}, fmt.Errorf("%w: hostname label exceed 63 characters", ErrInvalidRoute)
+			return parsedRoute{}, newPatternError(url, endHost, "hostname label exceed 63 characters")
 		}
 		if totallen > 253 {
-			return parsedRoute{}, fmt.Errorf("%w: hostname exceed 253 characters", ErrInvalidRoute)
+			return parsedRoute{}, newPatternError(url, 0, "hostname exceed 253 characters")
 		}
 	}
 
 	if state == stateParam {
-		return parsedRoute{}, fmt.Errorf("%w: unclosed '{param}'", ErrInvalidRoute)
+		return parsedRoute{}, newPatternError(url, startParam, "unclosed '{param}'")
 	}
 
 	if state == stateCatchAll {
 		prev := len(url) - 1
 		if url[prev] == '*' || url[prev] == '+' {
-			return parsedRoute{}, fmt.Errorf("%w: missing '{param}' after '%c' catch-all delimiter", ErrInvalidRoute, url[prev])
+			return parsedRoute{}, newPatternError(url, prev, "missing '{param}' after '%c' catch-all delimiter", url[prev])
 		}
-		return parsedRoute{}, fmt.Errorf("%w: unclosed '%c{param}'", ErrInvalidRoute, url[prev])
+		return parsedRoute{}, newPatternError(url, startCatchAll, "unclosed '%c{param}'", url[prev])
 	}
 
 	if sb.Len() > 0 {
@@ -1502,6 +1794,26 @@ func applyRouteMiddleware(mws []middleware, base HandlerFunc) (HandlerFunc, Hand
 	return rte, all
 }
 
+// serveHEAD calls the handler of a GET route for a HEAD request, discarding the response body.
+func serveHEAD(c *Context, h HandlerFunc) {
+	w := c.w
+	hw := &headWriter{ResponseWriter: w}
+	c.w = hw
+	h(c)
+	hw.commit(true)
+	c.w = w
+}
+
+// addAutoHEAD adds HEAD to the allowed methods if GET is allowed and automatic HEAD handling is enabled.
+func (fox *Router) addAutoHEAD(allowed map[string]struct{}) {
+	if !fox.autoHEAD {
+		return
+	}
+	if _, ok := allowed[http.MethodGet]; ok {
+		allowed[http.MethodHead] = struct{}{}
+	}
+}
+
 type noClientIPResolver struct{}
 
 func (s noClientIPResolver) ClientIP(_ RequestContext) (*net.IPAddr, error) {
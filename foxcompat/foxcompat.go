@@ -0,0 +1,388 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Package foxcompat translates route patterns written for other routers into Fox patterns, to ease the migration of
+// existing services. It supports the httprouter (":id", "*path"), gorilla/mux ("{id:[0-9]+}" and host templates),
+// chi ("{id}", "*") and Go 1.22 [http.ServeMux] ("GET /items/{id}", "{rest...}", "{$}") syntaxes.
+//
+// Each converter returns a [Result] holding the Fox pattern and the methods to register it with. Constructs that
+// translate with a slightly different behavior are reported as [Issue], while constructs that have no equivalent at
+// all are reported as an error wrapping [ErrUnsupported]. Patterns with regular expressions require the router to be
+// created with [fox.AllowRegexpParam].
+//
+// The [ServeMux] registrar accepts [http.ServeMux] patterns directly, and exposes the route parameters to the
+// handlers through [http.Request.PathValue].
+package foxcompat
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fox-toolkit/fox"
+)
+
+var ErrUnsupported = errors.New("unsupported pattern")
+
+// Wildcard is the name given to catch-all parameters that are anonymous in the source syntax, such as the chi "*"
+// wildcard or the subtree matched by a [http.ServeMux] pattern ending with a slash.
+const Wildcard = "wildcard"
+
+// Result is the translation of a pattern into Fox.
+type Result struct {
+	// Pattern is the Fox route pattern.
+	Pattern string
+	// Methods is the list of HTTP methods the route responds to. A nil slice means the route responds to any
+	// method (see [fox.MethodAny]).
+	Methods []string
+	// Issues lists the constructs that were translated with a different behavior.
+	Issues []Issue
+}
+
+// Issue describes a construct of the source pattern that was translated with a different behavior.
+type Issue struct {
+	// Offset is the byte offset of the construct in the source pattern.
+	Offset int
+	// Message describes the difference.
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("offset %d: %s", i.Offset, i.Message)
+}
+
+// FromHTTPRouter translates an httprouter path (e.g. "/users/:id/files/*path") into a Fox pattern. Note that unlike
+// httprouter, the value of a Fox catch-all parameter does not include the leading slash.
+func FromHTTPRouter(path string) (Result, error) {
+	c := converter{src: path}
+	if path == "" || path[0] != '/' {
+		return Result{}, c.unsupported(0, "path must begin with '/'")
+	}
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case ':':
+			end := segmentEnd(path, i)
+			name := path[i+1 : end]
+			if name == "" {
+				return Result{}, c.unsupported(i, "wildcards must be named with a non-empty name")
+			}
+			if strings.ContainsAny(name, ":*") {
+				return Result{}, c.unsupported(i, "only one wildcard per path segment is allowed")
+			}
+			c.sb.WriteString("{" + name + "}")
+			i = end
+		case '*':
+			name := path[i+1:]
+			if name == "" {
+				return Result{}, c.unsupported(i, "wildcards must be named with a non-empty name")
+			}
+			if strings.ContainsAny(name, "/:*") {
+				return Result{}, c.unsupported(i, "catch-all routes are only allowed at the end of the path")
+			}
+			if path[i-1] != '/' {
+				return Result{}, c.unsupported(i, "no '/' before catch-all")
+			}
+			c.sb.WriteString("*{" + name + "}")
+			c.issue(i, "the value of the %q catch-all does not include the leading '/'", name)
+			i = len(path)
+		default:
+			if err := c.static(i, path[i]); err != nil {
+				return Result{}, err
+			}
+			i++
+		}
+	}
+
+	return c.result(nil)
+}
+
+// FromGorillaMux translates a gorilla/mux host and path template (e.g. "{sub}.example.com" and
+// "/users/{id:[0-9]+}") into a Fox pattern. The host may be empty. Variables with the ".*" or ".+" regular expression,
+// which match across slashes in gorilla/mux, are translated to catch-all parameters. Other variables only ever match
+// a single path segment in Fox.
+func FromGorillaMux(host, path string) (Result, error) {
+	c := converter{src: host + path}
+	if path == "" || path[0] != '/' {
+		return Result{}, c.unsupported(len(host), "path must begin with '/'")
+	}
+
+	for i := 0; i < len(host); {
+		if host[i] == '{' {
+			name, expr, end, err := c.variable(i)
+			if err != nil {
+				return Result{}, err
+			}
+			c.param(name, expr)
+			i = end
+			continue
+		}
+		if host[i] == ':' {
+			return Result{}, c.unsupported(i, "port in host template")
+		}
+		if err := c.static(i, host[i]); err != nil {
+			return Result{}, err
+		}
+		i++
+	}
+
+	for i := len(host); i < len(c.src); {
+		if c.src[i] != '{' {
+			if err := c.static(i, c.src[i]); err != nil {
+				return Result{}, err
+			}
+			i++
+			continue
+		}
+
+		name, expr, end, err := c.variable(i)
+		if err != nil {
+			return Result{}, err
+		}
+		switch expr {
+		case ".*":
+			if end == len(c.src) {
+				c.sb.WriteString("*{" + name + "}")
+				break
+			}
+			c.sb.WriteString("+{" + name + "}")
+			c.issue(i, "the %q variable no longer matches an empty value", name)
+		case ".+":
+			c.sb.WriteString("+{" + name + "}")
+		default:
+			if strings.Contains(expr, "/") {
+				c.issue(i, "the %q variable matches a single path segment and cannot match '/'", name)
+			}
+			c.param(name, expr)
+		}
+		i = end
+	}
+
+	return c.result(nil)
+}
+
+// FromChi translates a chi pattern (e.g. "/users/{id:[0-9]+}/*") into a Fox pattern. The chi "*" wildcard is
+// translated to an optional catch-all parameter named [Wildcard].
+func FromChi(pattern string) (Result, error) {
+	c := converter{src: pattern}
+	if pattern == "" || pattern[0] != '/' {
+		return Result{}, c.unsupported(0, "pattern must begin with '/'")
+	}
+
+	for i := 0; i < len(pattern); {
+		switch pattern[i] {
+		case '{':
+			name, expr, end, err := c.variable(i)
+			if err != nil {
+				return Result{}, err
+			}
+			c.param(name, expr)
+			i = end
+		case '*':
+			if i != len(pattern)-1 {
+				return Result{}, c.unsupported(i, "wildcard '*' must be the last value in a route")
+			}
+			c.sb.WriteString("*{" + Wildcard + "}")
+			i++
+		default:
+			if err := c.static(i, pattern[i]); err != nil {
+				return Result{}, err
+			}
+			i++
+		}
+	}
+
+	return c.result(nil)
+}
+
+// FromServeMux translates a [http.ServeMux] pattern (e.g. "GET example.com/items/{id}") into a Fox pattern. As with
+// [http.ServeMux], the GET method also registers HEAD. A pattern ending with a slash matches the whole subtree, which
+// is captured by an optional catch-all parameter named [Wildcard], unless it ends with "{$}".
+func FromServeMux(pattern string) (Result, error) {
+	res, _, err := fromServeMux(pattern)
+	return res, err
+}
+
+func fromServeMux(pattern string) (res Result, anonymous bool, err error) {
+	c := converter{src: pattern}
+
+	var methods []string
+	rest, offset := pattern, 0
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method := pattern[:i]
+		rest = strings.TrimLeft(pattern[i+1:], " \t")
+		offset = len(pattern) - len(rest)
+		switch method {
+		case http.MethodGet:
+			methods = []string{http.MethodGet, http.MethodHead}
+		default:
+			methods = []string{method}
+		}
+	}
+
+	slash := strings.IndexByte(rest, '/')
+	if slash < 0 {
+		return Result{}, false, c.unsupported(offset, "host/path missing '/'")
+	}
+	for i := offset; i < offset+slash; i++ {
+		if err := c.static(i, pattern[i]); err != nil {
+			return Result{}, false, err
+		}
+	}
+
+	exact := false
+	i := offset + slash
+	for i < len(pattern) {
+		// i is at a slash.
+		c.sb.WriteByte('/')
+		i++
+		end := segmentEnd(pattern, i)
+		seg := pattern[i:end]
+		if len(seg) < 2 || seg[0] != '{' || seg[len(seg)-1] != '}' {
+			for j := i; j < end; j++ {
+				if err := c.static(j, pattern[j]); err != nil {
+					return Result{}, false, err
+				}
+			}
+			i = end
+			continue
+		}
+
+		name := seg[1 : len(seg)-1]
+		switch {
+		case name == "$":
+			if end != len(pattern) || pattern[i-1] != '/' {
+				return Result{}, false, c.unsupported(i, "{$} not at end")
+			}
+			exact = true
+		case strings.HasSuffix(name, "..."):
+			if end != len(pattern) {
+				return Result{}, false, c.unsupported(i, "{...} wildcard not at end")
+			}
+			c.sb.WriteString("*{" + strings.TrimSuffix(name, "...") + "}")
+		default:
+			c.sb.WriteString("{" + name + "}")
+		}
+		i = end
+	}
+
+	if !exact && strings.HasSuffix(pattern, "/") {
+		c.sb.WriteString("*{" + Wildcard + "}")
+		anonymous = true
+	}
+
+	res, err = c.result(methods)
+	return res, anonymous, err
+}
+
+// Registrar is implemented by [fox.Router] and [fox.Txn].
+type Registrar interface {
+	Add(methods []string, pattern string, handler fox.HandlerFunc, opts ...fox.RouteOption) (*fox.Route, error)
+}
+
+// ServeMux registers routes using [http.ServeMux] patterns (e.g. "GET example.com/items/{id}"). The route
+// parameters are exposed to the handlers through [http.Request.PathValue], so existing handlers work unchanged.
+type ServeMux struct {
+	r Registrar
+}
+
+// NewServeMux returns a [ServeMux] that registers routes on r.
+func NewServeMux(r Registrar) *ServeMux {
+	return &ServeMux{r: r}
+}
+
+// Handle translates the pattern with [FromServeMux] and registers the handler for it.
+func (mux *ServeMux) Handle(pattern string, handler http.Handler, opts ...fox.RouteOption) (*fox.Route, error) {
+	res, anonymous, err := fromServeMux(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	h := fox.WrapH(handler)
+	return mux.r.Add(res.Methods, res.Pattern, func(c *fox.Context) {
+		req := c.Request()
+		for p := range c.Params() {
+			if anonymous && p.Key == Wildcard {
+				continue
+			}
+			req.SetPathValue(p.Key, p.Value)
+		}
+		h(c)
+	}, opts...)
+}
+
+// HandleFunc translates the pattern with [FromServeMux] and registers the handler function for it.
+func (mux *ServeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), opts ...fox.RouteOption) (*fox.Route, error) {
+	return mux.Handle(pattern, http.HandlerFunc(handler), opts...)
+}
+
+type converter struct {
+	src    string
+	sb     strings.Builder
+	issues []Issue
+}
+
+func (c *converter) issue(offset int, format string, args ...any) {
+	c.issues = append(c.issues, Issue{Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *converter) unsupported(offset int, format string, args ...any) error {
+	return fmt.Errorf("%w: %q at offset %d: %s", ErrUnsupported, c.src, offset, fmt.Sprintf(format, args...))
+}
+
+// static writes a literal character, which must not collide with the Fox pattern syntax.
+func (c *converter) static(offset int, b byte) error {
+	switch b {
+	case '{', '}', '*', '+':
+		return c.unsupported(offset, "literal '%c' has no equivalent", b)
+	}
+	c.sb.WriteByte(b)
+	return nil
+}
+
+// variable parses a "{name}" or "{name:regexp}" variable starting at offset, and returns the offset following it.
+func (c *converter) variable(offset int) (name, expr string, end int, err error) {
+	level := 0
+	for i := offset; i < len(c.src); i++ {
+		switch c.src[i] {
+		case '{':
+			level++
+		case '}':
+			level--
+			if level == 0 {
+				name, expr, _ = strings.Cut(c.src[offset+1:i], ":")
+				if name == "" {
+					return "", "", 0, c.unsupported(offset, "missing variable name")
+				}
+				return name, expr, i + 1, nil
+			}
+		}
+	}
+	return "", "", 0, c.unsupported(offset, "unbalanced braces")
+}
+
+func (c *converter) param(name, expr string) {
+	if expr == "" {
+		c.sb.WriteString("{" + name + "}")
+		return
+	}
+	c.sb.WriteString("{" + name + ":" + expr + "}")
+}
+
+// result validates the translated pattern against the Fox grammar.
+func (c *converter) result(methods []string) (Result, error) {
+	pattern := c.sb.String()
+	if _, err := fox.ParsePattern(pattern, fox.ParseOptions{AllowRegexp: true}); err != nil {
+		return Result{}, fmt.Errorf("%w: %q: %w", ErrUnsupported, c.src, err)
+	}
+	return Result{Pattern: pattern, Methods: methods, Issues: c.issues}, nil
+}
+
+func segmentEnd(s string, offset int) int {
+	if i := strings.IndexByte(s[offset:], '/'); i >= 0 {
+		return offset + i
+	}
+	return len(s)
+}
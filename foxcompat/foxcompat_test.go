@@ -0,0 +1,138 @@
+package foxcompat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCase struct {
+	name    string
+	source  string
+	pattern string
+	methods []string
+	issues  []int
+	err     bool
+}
+
+func runCases(t *testing.T, cases []testCase, convert func(string) (Result, error)) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := convert(tc.source)
+			if tc.err {
+				require.ErrorIs(t, err, ErrUnsupported)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.pattern, res.Pattern)
+			assert.Equal(t, tc.methods, res.Methods)
+			offsets := make([]int, 0, len(res.Issues))
+			for _, issue := range res.Issues {
+				offsets = append(offsets, issue.Offset)
+				assert.NotEmpty(t, issue.String())
+			}
+			if tc.issues == nil {
+				tc.issues = []int{}
+			}
+			assert.Equal(t, tc.issues, offsets)
+		})
+	}
+}
+
+func TestFromHTTPRouter(t *testing.T) {
+	runCases(t, []testCase{
+		{name: "static", source: "/users/me", pattern: "/users/me"},
+		{name: "params", source: "/users/:id/posts/:post", pattern: "/users/{id}/posts/{post}"},
+		{name: "catch-all", source: "/src/*filepath", pattern: "/src/*{filepath}", issues: []int{5}},
+		{name: "param with prefix", source: "/v:version/info", pattern: "/v{version}/info"},
+		{name: "unnamed param", source: "/users/:/x", err: true},
+		{name: "catch-all not at end", source: "/src/*filepath/x", err: true},
+		{name: "no slash before catch-all", source: "/src*filepath", err: true},
+		{name: "literal plus", source: "/c++", err: true},
+		{name: "relative path", source: "users", err: true},
+	}, FromHTTPRouter)
+}
+
+func TestFromGorillaMux(t *testing.T) {
+	runCases(t, []testCase{
+		{name: "params", source: "/users/{id:[0-9]+}/{name}", pattern: "/users/{id:[0-9]+}/{name}"},
+		{name: "optional catch-all", source: "/static/{path:.*}", pattern: "/static/*{path}"},
+		{name: "catch-all", source: "/static/{path:.+}", pattern: "/static/+{path}"},
+		{name: "infix optional catch-all", source: "/static/{path:.*}/raw", pattern: "/static/+{path}/raw", issues: []int{8}},
+		{name: "regexp with slash", source: "/files/{path:[a-z/]+}", pattern: "/files/{path:[a-z/]+}", issues: []int{7}},
+		{name: "regexp with braces", source: "/code/{code:[A-Z]{3}}", pattern: "/code/{code:[A-Z]{3}}"},
+		{name: "param with suffix", source: "/files/{name}.json", err: true},
+		{name: "unbalanced braces", source: "/files/{name", err: true},
+	}, func(path string) (Result, error) {
+		return FromGorillaMux("", path)
+	})
+
+	res, err := FromGorillaMux("{sub:[a-z]+}.example.com", "/users/{id}")
+	require.NoError(t, err)
+	assert.Equal(t, "{sub:[a-z]+}.example.com/users/{id}", res.Pattern)
+
+	_, err = FromGorillaMux("example.com:8080", "/")
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestFromChi(t *testing.T) {
+	runCases(t, []testCase{
+		{name: "params", source: "/users/{id}/{slug:[a-z-]+}", pattern: "/users/{id}/{slug:[a-z-]+}"},
+		{name: "wildcard", source: "/static/*", pattern: "/static/*{wildcard}"},
+		{name: "wildcard not at end", source: "/static/*/x", err: true},
+		{name: "adjacent params", source: "/{a}-{b}", err: true},
+	}, FromChi)
+}
+
+func TestFromServeMux(t *testing.T) {
+	runCases(t, []testCase{
+		{name: "any method", source: "/items/{id}", pattern: "/items/{id}"},
+		{name: "get implies head", source: "GET /items/{id}", pattern: "/items/{id}", methods: []string{http.MethodGet, http.MethodHead}},
+		{name: "method and host", source: "POST  example.com/items/", pattern: "example.com/items/*{wildcard}", methods: []string{http.MethodPost}},
+		{name: "remaining segments", source: "/files/{path...}", pattern: "/files/*{path}"},
+		{name: "exact match", source: "/items/{$}", pattern: "/items/"},
+		{name: "root", source: "/", pattern: "/*{wildcard}"},
+		{name: "exact root", source: "/{$}", pattern: "/"},
+		{name: "remaining segments not at end", source: "/files/{path...}/x", err: true},
+		{name: "partial segment", source: "/items/v{id}", err: true},
+		{name: "missing slash", source: "GET example.com", err: true},
+	}, FromServeMux)
+}
+
+func TestServeMux(t *testing.T) {
+	f := fox.MustRouter()
+	mux := NewServeMux(f)
+
+	_, err := mux.HandleFunc("GET /items/{id}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.PathValue("id") + ":" + r.PathValue("rest")))
+	})
+	require.NoError(t, err)
+	_, err = mux.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("static:" + r.PathValue(Wildcard)))
+	})
+	require.NoError(t, err)
+	_, err = mux.HandleFunc("GET /items/v{id}", func(w http.ResponseWriter, r *http.Request) {})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	cases := []struct {
+		method string
+		target string
+		want   string
+	}{
+		{method: http.MethodGet, target: "/items/42/a/b", want: "42:a/b"},
+		{method: http.MethodHead, target: "/items/42/", want: "42:"},
+		{method: http.MethodPost, target: "/static/css/main.css", want: "static:"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, tc.target)
+		assert.Equal(t, tc.want, w.Body.String(), tc.target)
+	}
+}
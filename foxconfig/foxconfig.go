@@ -0,0 +1,339 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Package foxconfig provides declarative route configuration for Fox. Routes are described in a [Document], which
+// can be decoded from and encoded to JSON, applied to a router within a single transaction, and exported back from
+// the registered routes so the routing table can be versioned. Handlers and middlewares are referenced by name and
+// resolved through a user-supplied [Registry].
+//
+// Only JSON is supported: [Decode] and [Encode] read and write JSON, and no other format is understood.
+package foxconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/fox-toolkit/fox"
+)
+
+var (
+	ErrInvalidDocument    = errors.New("invalid document")
+	ErrUnknownHandler     = errors.New("unknown handler")
+	ErrUnknownMiddleware  = errors.New("unknown middleware")
+	ErrUnsupportedMatcher = errors.New("unsupported matcher")
+)
+
+// Matcher types supported in a [MatcherConfig].
+const (
+	MatcherQuery        = "query"
+	MatcherQueryRegexp  = "query_regexp"
+	MatcherHeader       = "header"
+	MatcherHeaderRegexp = "header_regexp"
+	MatcherClientIP     = "client_ip"
+)
+
+// Trailing slash options supported in a [RouteConfig].
+const (
+	TrailingSlashStrict   = "strict"
+	TrailingSlashRelaxed  = "relaxed"
+	TrailingSlashRedirect = "redirect"
+)
+
+// Document is the root of a route configuration.
+type Document struct {
+	Routes []RouteConfig `json:"routes"`
+}
+
+// RouteConfig describes a single route.
+type RouteConfig struct {
+	// Methods is the list of HTTP methods this route responds to. An empty list registers a method-less route.
+	Methods []string `json:"methods,omitempty"`
+	// Pattern is the route pattern (e.g. "example.com/users/{id}").
+	Pattern string `json:"pattern"`
+	// Name is an optional unique route name.
+	Name string `json:"name,omitempty"`
+	// Handler is the name of the handler in the [Registry].
+	Handler string `json:"handler"`
+	// Middlewares is an optional list of middleware names in the [Registry], chained in order.
+	Middlewares []string `json:"middlewares,omitempty"`
+	// Matchers is an optional list of request matchers.
+	Matchers []MatcherConfig `json:"matchers,omitempty"`
+	// Priority is an optional matchers priority. It defaults to the number of matchers.
+	Priority uint `json:"priority,omitempty"`
+	// TrailingSlash is an optional trailing slash option, one of "strict", "relaxed" or "redirect".
+	// If empty, the router global option applies.
+	TrailingSlash string `json:"trailing_slash,omitempty"`
+	// Annotations are optional key-value metadata attached to the route with [AnnotationKey] keys.
+	Annotations map[string]any `json:"annotations,omitempty"`
+}
+
+// MatcherConfig describes a request matcher.
+type MatcherConfig struct {
+	// Type is one of "query", "query_regexp", "header", "header_regexp" or "client_ip".
+	Type string `json:"type"`
+	// Key is the query parameter or header name. It is ignored for the "client_ip" type.
+	Key string `json:"key,omitempty"`
+	// Value is the expected value, regular expression or ip/cidr depending on the type.
+	Value string `json:"value"`
+}
+
+// Registry resolves handler and middleware names referenced in a [Document].
+type Registry struct {
+	Handlers    map[string]fox.HandlerFunc
+	Middlewares map[string]fox.MiddlewareFunc
+}
+
+// AnnotationKey is the type of annotation keys attached to routes from [RouteConfig.Annotations]. Use it to retrieve
+// configured annotations with [fox.Route.Annotation].
+type AnnotationKey string
+
+type handlerKey struct{}
+
+type middlewaresKey struct{}
+
+// WithHandlerName returns a route option recording the handler name of the route, as referenced by
+// [RouteConfig.Handler]. It is required to export the route with [Export]. Routes registered with [Apply] or [Load]
+// already carry it, so this is intended for routes registered by other means, such as code generated by foxgen.
+func WithHandlerName(name string) fox.RouteOption {
+	return fox.WithAnnotation(handlerKey{}, name)
+}
+
+// WithMiddlewareNames returns a route option recording the middleware names of the route, as referenced by
+// [RouteConfig.Middlewares], so that they are exported with [Export]. See also [WithHandlerName].
+func WithMiddlewareNames(names ...string) fox.RouteOption {
+	return fox.WithAnnotation(middlewaresKey{}, slices.Clone(names))
+}
+
+// RouteError reports the route of a [Document] that caused an error.
+type RouteError struct {
+	// Index is the position of the route in [Document.Routes].
+	Index   int
+	Pattern string
+	Err     error
+}
+
+// Error returns a formatted error message.
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("route %d (%s): %s", e.Index, e.Pattern, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// Decode reads a JSON [Document] from r. Unknown fields are rejected.
+func Decode(r io.Reader) (*Document, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	doc := new(Document)
+	if err := dec.Decode(doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+	return doc, nil
+}
+
+// Encode writes the [Document] to w as indented JSON.
+func Encode(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Options returns the [fox.RouteOption] equivalent to the route configuration, along with the resolved handler.
+func (reg Registry) Options(rc RouteConfig) (fox.HandlerFunc, []fox.RouteOption, error) {
+	handler, ok := reg.Handlers[rc.Handler]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: '%s'", ErrUnknownHandler, rc.Handler)
+	}
+
+	opts := []fox.RouteOption{WithHandlerName(rc.Handler)}
+	if rc.Name != "" {
+		opts = append(opts, fox.WithName(rc.Name))
+	}
+
+	if len(rc.Middlewares) > 0 {
+		mws := make([]fox.MiddlewareFunc, 0, len(rc.Middlewares))
+		for _, name := range rc.Middlewares {
+			m, ok := reg.Middlewares[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: '%s'", ErrUnknownMiddleware, name)
+			}
+			mws = append(mws, m)
+		}
+		opts = append(opts, fox.WithMiddleware(mws...), WithMiddlewareNames(rc.Middlewares...))
+	}
+
+	for _, mc := range rc.Matchers {
+		opt, err := matcherOption(mc)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	if rc.Priority > 0 {
+		opts = append(opts, fox.WithMatcherPriority(rc.Priority))
+	}
+
+	if rc.TrailingSlash != "" {
+		opt, err := parseTrailingSlash(rc.TrailingSlash)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, fox.WithHandleTrailingSlash(opt))
+	}
+
+	for k, v := range rc.Annotations {
+		opts = append(opts, fox.WithAnnotation(AnnotationKey(k), v))
+	}
+
+	return handler, opts, nil
+}
+
+// Apply registers all routes of the [Document] in the provided transaction, resolving handlers and middlewares
+// through the [Registry]. It stops at the first error, which is wrapped in a [RouteError].
+func Apply(txn *fox.Txn, doc *Document, reg Registry) error {
+	for i, rc := range doc.Routes {
+		handler, opts, err := reg.Options(rc)
+		if err == nil {
+			_, err = txn.Add(rc.Methods, rc.Pattern, handler, opts...)
+		}
+		if err != nil {
+			return &RouteError{Index: i, Pattern: rc.Pattern, Err: err}
+		}
+	}
+	return nil
+}
+
+// Load registers all routes of the [Document] on the router within a single transaction. If an error occurs, no
+// route is registered. See also [Apply].
+func Load(f *fox.Router, doc *Document, reg Registry) error {
+	return f.Updates(func(txn *fox.Txn) error {
+		return Apply(txn, doc, reg)
+	})
+}
+
+// Export returns a [Document] describing all routes of the provided [fox.Iter], which can be written as JSON with
+// [Encode]. Only routes registered from a [Document] can be exported, since the handler name is required. The trailing
+// slash option is always exported, since the default of the router loading the document may differ from the one of the
+// exporting router. Only annotations with an [AnnotationKey] key are exported. Routes are exported in [fox.Iter.All]
+// order, which preserves the evaluation order of regexp params when the document is loaded again.
+func Export(it fox.Iter) (*Document, error) {
+	doc := new(Document)
+	for route := range it.All() {
+		rc, err := exportRoute(route)
+		if err != nil {
+			return nil, &RouteError{Index: len(doc.Routes), Pattern: route.Pattern(), Err: err}
+		}
+		doc.Routes = append(doc.Routes, rc)
+	}
+	return doc, nil
+}
+
+func exportRoute(route *fox.Route) (RouteConfig, error) {
+	handler, ok := route.Annotation(handlerKey{}).(string)
+	if !ok {
+		return RouteConfig{}, fmt.Errorf("%w: route has no handler reference", ErrUnknownHandler)
+	}
+
+	rc := RouteConfig{
+		Methods: slices.Collect(route.Methods()),
+		Pattern: route.Pattern(),
+		Name:    route.Name(),
+		Handler: handler,
+	}
+
+	if mws, ok := route.Annotation(middlewaresKey{}).([]string); ok {
+		rc.Middlewares = slices.Clone(mws)
+	}
+
+	for m := range route.Matchers() {
+		mc, err := exportMatcher(m)
+		if err != nil {
+			return RouteConfig{}, err
+		}
+		rc.Matchers = append(rc.Matchers, mc)
+	}
+
+	if priority := route.MatchersPriority(); priority != uint(route.MatchersLen()) {
+		rc.Priority = priority
+	}
+
+	switch route.TrailingSlashOption() {
+	case fox.StrictSlash:
+		rc.TrailingSlash = TrailingSlashStrict
+	case fox.RelaxedSlash:
+		rc.TrailingSlash = TrailingSlashRelaxed
+	case fox.RedirectSlash:
+		rc.TrailingSlash = TrailingSlashRedirect
+	}
+
+	for k, v := range route.Annotations() {
+		if key, ok := k.(AnnotationKey); ok {
+			if rc.Annotations == nil {
+				rc.Annotations = make(map[string]any)
+			}
+			rc.Annotations[string(key)] = v
+		}
+	}
+
+	return rc, nil
+}
+
+func matcherOption(mc MatcherConfig) (fox.RouteOption, error) {
+	switch strings.ToLower(mc.Type) {
+	case MatcherQuery:
+		return fox.WithQueryMatcher(mc.Key, mc.Value), nil
+	case MatcherQueryRegexp:
+		return fox.WithQueryRegexpMatcher(mc.Key, mc.Value), nil
+	case MatcherHeader:
+		return fox.WithHeaderMatcher(mc.Key, mc.Value), nil
+	case MatcherHeaderRegexp:
+		return fox.WithHeaderRegexpMatcher(mc.Key, mc.Value), nil
+	case MatcherClientIP:
+		return fox.WithClientIPMatcher(mc.Value), nil
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedMatcher, mc.Type)
+	}
+}
+
+func exportMatcher(m fox.Matcher) (MatcherConfig, error) {
+	switch m := m.(type) {
+	case fox.QueryMatcher:
+		return MatcherConfig{Type: MatcherQuery, Key: m.Key(), Value: m.Value()}, nil
+	case fox.QueryRegexpMatcher:
+		return MatcherConfig{Type: MatcherQueryRegexp, Key: m.Key(), Value: unanchor(m.Regex().String())}, nil
+	case fox.HeaderMatcher:
+		return MatcherConfig{Type: MatcherHeader, Key: m.Key(), Value: m.Value()}, nil
+	case fox.HeaderRegexpMatcher:
+		return MatcherConfig{Type: MatcherHeaderRegexp, Key: m.Key(), Value: unanchor(m.Regex().String())}, nil
+	case fox.ClientIpMatcher:
+		return MatcherConfig{Type: MatcherClientIP, Value: m.IPNet().String()}, nil
+	default:
+		return MatcherConfig{}, fmt.Errorf("%w: %s", ErrUnsupportedMatcher, m)
+	}
+}
+
+// unanchor returns the regular expression as provided to the matcher constructor, which anchors it with "^" and "$".
+func unanchor(expr string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(expr, "^"), "$")
+}
+
+func parseTrailingSlash(s string) (fox.TrailingSlashOption, error) {
+	switch strings.ToLower(s) {
+	case TrailingSlashStrict:
+		return fox.StrictSlash, nil
+	case TrailingSlashRelaxed:
+		return fox.RelaxedSlash, nil
+	case TrailingSlashRedirect:
+		return fox.RedirectSlash, nil
+	default:
+		return 0, fmt.Errorf("%w: invalid trailing slash option '%s'", ErrInvalidDocument, s)
+	}
+}
@@ -0,0 +1,208 @@
+package foxconfig
+
+import (
+	"bytes"
+	"cmp"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `{
+  "routes": [
+    {
+      "methods": ["GET", "HEAD"],
+      "pattern": "/users/{id}",
+      "name": "users",
+      "handler": "users",
+      "middlewares": ["auth", "version"],
+      "trailing_slash": "relaxed",
+      "annotations": {"team": "identity"}
+    },
+    {
+      "methods": ["GET"],
+      "pattern": "/users/{id}",
+      "handler": "users",
+      "matchers": [
+        {"type": "query", "key": "version", "value": "v2"},
+        {"type": "header_regexp", "key": "X-Tenant", "value": "^a"}
+      ],
+      "priority": 5
+    },
+    {
+      "pattern": "/files/+{path}",
+      "handler": "files",
+      "matchers": [{"type": "client_ip", "value": "10.0.0.0/8"}]
+    }
+  ]
+}`
+
+func testRegistry() Registry {
+	return Registry{
+		Handlers: map[string]fox.HandlerFunc{
+			"users": func(c *fox.Context) {
+				_ = c.String(http.StatusOK, "users:"+c.Param("id"))
+			},
+			"files": func(c *fox.Context) {
+				_ = c.String(http.StatusOK, "files:"+c.Param("path"))
+			},
+		},
+		Middlewares: map[string]fox.MiddlewareFunc{
+			"auth": func(next fox.HandlerFunc) fox.HandlerFunc {
+				return func(c *fox.Context) {
+					c.SetHeader("X-Auth", "ok")
+					next(c)
+				}
+			},
+			"version": func(next fox.HandlerFunc) fox.HandlerFunc {
+				return func(c *fox.Context) {
+					c.SetHeader("X-Version", "1")
+					next(c)
+				}
+			},
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	doc, err := Decode(strings.NewReader(document))
+	require.NoError(t, err)
+
+	f := fox.MustRouter()
+	require.NoError(t, Load(f, doc, testRegistry()))
+	assert.Equal(t, 3, f.Len())
+
+	route := f.Name("users")
+	require.NotNil(t, route)
+	assert.Equal(t, fox.RelaxedSlash, route.TrailingSlashOption())
+	assert.Equal(t, "identity", route.Annotation(AnnotationKey("team")))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "users:42", w.Body.String())
+	assert.Equal(t, "ok", w.Header().Get("X-Auth"))
+	assert.Equal(t, "1", w.Header().Get("X-Version"))
+
+	route = f.Route(fox.MethodGet, "/users/{id}", mustMatcher(fox.MatchQuery("version", "v2")), mustMatcher(fox.MatchHeaderRegexp("X-Tenant", "^a")))
+	require.NotNil(t, route)
+	assert.Equal(t, uint(5), route.MatchersPriority())
+}
+
+func TestLoadError(t *testing.T) {
+	cases := []struct {
+		name    string
+		doc     *Document
+		wantErr error
+		index   int
+	}{
+		{
+			name:    "unknown handler",
+			doc:     &Document{Routes: []RouteConfig{{Pattern: "/foo", Handler: "users"}, {Pattern: "/bar", Handler: "unknown"}}},
+			wantErr: ErrUnknownHandler,
+			index:   1,
+		},
+		{
+			name:    "unknown middleware",
+			doc:     &Document{Routes: []RouteConfig{{Pattern: "/foo", Handler: "users", Middlewares: []string{"unknown"}}}},
+			wantErr: ErrUnknownMiddleware,
+		},
+		{
+			name:    "unsupported matcher",
+			doc:     &Document{Routes: []RouteConfig{{Pattern: "/foo", Handler: "users", Matchers: []MatcherConfig{{Type: "cookie"}}}}},
+			wantErr: ErrUnsupportedMatcher,
+		},
+		{
+			name:    "invalid trailing slash",
+			doc:     &Document{Routes: []RouteConfig{{Pattern: "/foo", Handler: "users", TrailingSlash: "loose"}}},
+			wantErr: ErrInvalidDocument,
+		},
+		{
+			name:    "route conflict",
+			doc:     &Document{Routes: []RouteConfig{{Pattern: "/foo", Handler: "users"}, {Pattern: "/foo", Handler: "files"}}},
+			wantErr: fox.ErrRouteConflict,
+			index:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := fox.MustRouter()
+			err := Load(f, tc.doc, testRegistry())
+			require.ErrorIs(t, err, tc.wantErr)
+			var rErr *RouteError
+			require.ErrorAs(t, err, &rErr)
+			assert.Equal(t, tc.index, rErr.Index)
+			assert.Equal(t, 0, f.Len())
+		})
+	}
+}
+
+func TestDecodeUnknownField(t *testing.T) {
+	_, err := Decode(strings.NewReader(`{"routes": [{"pattern": "/foo", "handler": "foo", "method": "GET"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+}
+
+func TestExport(t *testing.T) {
+	doc, err := Decode(strings.NewReader(document))
+	require.NoError(t, err)
+
+	f := fox.MustRouter()
+	require.NoError(t, Load(f, doc, testRegistry()))
+
+	exported, err := Export(f.Iter())
+	require.NoError(t, err)
+	want := slices.Clone(doc.Routes)
+	for i := range want {
+		// The trailing slash option is always exported.
+		want[i].TrailingSlash = cmp.Or(want[i].TrailingSlash, TrailingSlashStrict)
+	}
+	assert.ElementsMatch(t, want, exported.Routes)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Encode(buf, exported))
+	roundtrip, err := Decode(buf)
+	require.NoError(t, err)
+	assert.Equal(t, exported, roundtrip)
+
+	f.MustAdd(fox.MethodGet, "/code", func(c *fox.Context) {})
+	_, err = Export(f.Iter())
+	assert.ErrorIs(t, err, ErrUnknownHandler)
+}
+
+func TestExportNonStrictRouter(t *testing.T) {
+	doc, err := Decode(strings.NewReader(document))
+	require.NoError(t, err)
+	doc.Routes = append(doc.Routes, RouteConfig{Methods: []string{http.MethodGet}, Pattern: "/strict", Handler: "files", TrailingSlash: TrailingSlashStrict})
+
+	f := fox.MustRouter(fox.WithHandleTrailingSlash(fox.RelaxedSlash))
+	require.NoError(t, Load(f, doc, testRegistry()))
+
+	exported, err := Export(f.Iter())
+	require.NoError(t, err)
+
+	// Loading the exported document in a router with a different default preserves the trailing slash options.
+	f2 := fox.MustRouter(fox.WithHandleTrailingSlash(fox.RedirectSlash))
+	require.NoError(t, Load(f2, exported, testRegistry()))
+	for route := range f.Iter().All() {
+		got := f2.Route(slices.Collect(route.Methods()), route.Pattern(), slices.Collect(route.Matchers())...)
+		require.NotNil(t, got, route.Pattern())
+		assert.Equal(t, route.TrailingSlashOption(), got.TrailingSlashOption(), route.Pattern())
+	}
+	assert.Equal(t, fox.StrictSlash, f2.Route(fox.MethodGet, "/strict").TrailingSlashOption())
+	assert.Equal(t, fox.RelaxedSlash, f2.Route(nil, "/files/+{path}", mustMatcher(fox.MatchClientIP("10.0.0.0/8"))).TrailingSlashOption())
+}
+
+func mustMatcher[T fox.Matcher](m T, err error) fox.Matcher {
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
@@ -11,6 +11,7 @@ const (
 	charsetUTF8                          = "charset=utf-8"
 	MIMEApplicationJSON                  = "application/json"
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + "; " + charsetUTF8
+	MIMEApplicationProblemJSON           = "application/problem+json"
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + charsetUTF8
 	MIMEApplicationXML                   = "application/xml"
@@ -24,6 +25,7 @@ const (
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + charsetUTF8
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
+	MIMETextEventStream                  = "text/event-stream"
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
 )
@@ -45,6 +47,7 @@ const (
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
 	HeaderRetryAfter          = "Retry-After"
+	HeaderTransferEncoding    = "Transfer-Encoding"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
@@ -64,6 +67,8 @@ const (
 	HeaderCacheControl        = "Cache-Control"
 	HeaderConnection          = "Connection"
 	HeaderETag                = "ETag"
+	HeaderLastEventID         = "Last-Event-ID"
+	HeaderXAccelBuffering     = "X-Accel-Buffering"
 
 	// Access control
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
@@ -194,3 +194,47 @@ func (it Iter) Names() iter.Seq[*Route] {
 		}
 	}
 }
+
+// ByPriority returns a range iterator over all routes registered in the routing tree, in the order they are evaluated
+// during lookup: routes with a hostname first, then static segments before params and params before wildcards at
+// each level, and routes sharing the same pattern by method and matchers priority. Note that a route yielded first
+// does not always take precedence, since the lookup only evaluates the routes reachable for a given request. The
+// iterator reflect a snapshot of the routing tree at the time [Iter] is created. This function is safe for concurrent
+// use by multiple goroutine and while mutation on routes are ongoing.
+func (it Iter) ByPriority() iter.Seq[*Route] {
+	return func(yield func(*Route) bool) {
+		root := it.patterns
+		var hosts, paths []*node
+		for _, child := range root.statics {
+			if child.label == slashDelim {
+				paths = append(paths, child)
+			} else {
+				hosts = append(hosts, child)
+			}
+		}
+
+		for _, children := range [][]*node{hosts, root.params, root.wildcards, paths} {
+			for _, child := range children {
+				if !walkByPriority(child, yield) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func walkByPriority(n *node, yield func(*Route) bool) bool {
+	for _, route := range n.routes {
+		if !yield(route) {
+			return false
+		}
+	}
+	for _, children := range [][]*node{n.statics, n.params, n.wildcards} {
+		for _, child := range children {
+			if !walkByPriority(child, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
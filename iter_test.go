@@ -131,6 +131,27 @@ func TestIter_AllBreak(t *testing.T) {
 	assert.Equal(t, 1, iteration)
 }
 
+func TestIter_ByPriority(t *testing.T) {
+	f := MustRouter(AllowRegexpParam(true))
+	r1 := f.MustAdd(MethodGet, "/*{any}", emptyHandler)
+	r2 := f.MustAdd(MethodGet, "/users/{id:[0-9]+}", emptyHandler)
+	r3 := f.MustAdd(MethodGet, "/users/{name}", emptyHandler)
+	r4 := f.MustAdd(MethodGet, "/users/{name}", emptyHandler, WithQueryMatcher("a", "b"))
+	r5 := f.MustAdd(MethodAny, "/users/{name}", emptyHandler)
+	r6 := f.MustAdd(MethodGet, "/users/me", emptyHandler)
+	r7 := f.MustAdd(MethodGet, "{sub}.example.com/", emptyHandler)
+	r8 := f.MustAdd(MethodGet, "example.com/users", emptyHandler)
+
+	assert.Equal(t, []*Route{r8, r7, r6, r2, r4, r3, r5, r1}, slices.Collect(f.Iter().ByPriority()))
+
+	iteration := 0
+	for range f.Iter().ByPriority() {
+		iteration++
+		break
+	}
+	assert.Equal(t, 1, iteration)
+}
+
 func TestIter_NamesBreak(t *testing.T) {
 	f, _ := NewRouter()
 	for _, rte := range routesCases {
@@ -0,0 +1,146 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// lifecycle tracks the in-flight requests of a route and signals when the route has been removed from the
+// routing tree and has no active request left.
+type lifecycle struct {
+	done     chan struct{}
+	inflight atomic.Int64
+	retired  atomic.Bool
+	once     sync.Once
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{done: make(chan struct{})}
+}
+
+// track wraps the handler with in-flight request accounting.
+func (r *Route) track(next HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		r.lc.inflight.Add(1)
+		defer func() {
+			if r.lc.inflight.Add(-1) == 0 && r.lc.retired.Load() {
+				r.finish()
+			}
+		}()
+		next(c)
+	}
+}
+
+// retire marks the route as removed from the routing tree. It is called once every request that may have matched the
+// route has completed (see epoch). If no request is in-flight, the route is finished immediately, otherwise, the last
+// in-flight request finishes it.
+func (r *Route) retire() {
+	r.lc.retired.Store(true)
+	if r.lc.inflight.Load() == 0 {
+		r.finish()
+	}
+}
+
+// finish runs the retire callbacks in a new goroutine and then releases any caller blocked in [Router.Drain].
+func (r *Route) finish() {
+	r.lc.once.Do(func() {
+		go func() {
+			defer close(r.lc.done)
+			for _, fn := range r.onRetire {
+				fn(r)
+			}
+		}()
+	})
+}
+
+// epoch counts the requests served from a version of the routing tree. When a commit replaces the tree, the tracked
+// routes it removes are attached to the epoch of the replaced tree, and are retired once every request served from
+// this tree, or from any older one, has completed. This closes the window between the lookup of a route and the start
+// of its handler, where the route in-flight counter is not yet incremented.
+type epoch struct {
+	next    *epoch
+	retired []*Route
+	// refs holds one reference for each request in-flight, one for the tree as long as it is the current one, and one
+	// for the previous epoch until it has completed.
+	refs atomic.Int64
+}
+
+func newEpoch(refs int64) *epoch {
+	e := new(epoch)
+	e.refs.Store(refs)
+	return e
+}
+
+// acquire registers a request on the epoch. It returns false if the epoch has already completed, in which case
+// the tree has been replaced and the caller should load the new one.
+func (e *epoch) acquire() bool {
+	for {
+		refs := e.refs.Load()
+		if refs == 0 {
+			return false
+		}
+		if e.refs.CompareAndSwap(refs, refs+1) {
+			return true
+		}
+	}
+}
+
+// release drops a reference. When the last one is dropped, the routes retired by the next commit are retired, and
+// the reference held on the next epoch is released in turn.
+func (e *epoch) release() {
+	for e != nil && e.refs.Add(-1) == 0 {
+		for _, route := range e.retired {
+			route.retire()
+		}
+		e = e.next
+	}
+}
+
+// seal is called when the tree of the epoch is replaced by a tree with the next epoch. The provided routes are
+// retired once the epoch completes. It must be called with the write lock held.
+func (e *epoch) seal(next *epoch, retired []*Route) {
+	e.next = next
+	e.retired = retired
+	e.release()
+}
+
+// enter loads the current tree for serving a request. If the router serves tracked routes, the request is also
+// registered on the tree epoch, and the returned epoch must be released once the request completes.
+func (fox *Router) enter() (*iTree, *epoch) {
+	for {
+		tree := fox.getTree()
+		if !fox.tracked.Load() {
+			return tree, nil
+		}
+		if tree.epoch.acquire() {
+			return tree, tree.epoch
+		}
+	}
+}
+
+// Drain blocks until the provided route has been removed or replaced in the routing tree, has no in-flight request
+// left, and all callbacks registered with [WithOnRetire] have returned. This is typically used during blue/green
+// switches to release resources owned by the old handler. Drain returns the context error if ctx is done first. The
+// route must have been created with in-flight tracking enabled (see [WithInflightTracking] and [WithOnRetire]),
+// otherwise Drain returns an error that is [ErrRouteNotTracked].
+func (fox *Router) Drain(ctx context.Context, route *Route) error {
+	if route == nil {
+		return fmt.Errorf("%w: nil route", ErrInvalidRoute)
+	}
+	if route.lc == nil {
+		return fmt.Errorf("%w: %s", ErrRouteNotTracked, route.pattern)
+	}
+
+	select {
+	case <-route.lc.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,208 @@
+package fox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Drain(t *testing.T) {
+	t.Run("drain waits for in-flight requests on replaced route", func(t *testing.T) {
+		var retired atomic.Pointer[Route]
+		f := MustRouter(WithOnRetire(func(route *Route) {
+			retired.Store(route)
+		}))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		old := f.MustAdd(MethodGet, "/foo", func(c *Context) {
+			close(started)
+			<-release
+		})
+
+		go func() {
+			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+			f.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+		<-started
+		assert.Equal(t, 1, old.Inflight())
+
+		_, err := f.Update(MethodGet, "/foo", emptyHandler)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx, old), context.DeadlineExceeded)
+		assert.Nil(t, retired.Load())
+
+		close(release)
+		require.NoError(t, f.Drain(context.Background(), old))
+		assert.Equal(t, old, retired.Load())
+		assert.Equal(t, 0, old.Inflight())
+	})
+
+	t.Run("deleted idle route is retired on commit", func(t *testing.T) {
+		var calls atomic.Int32
+		f := MustRouter()
+		route := f.MustAdd(MethodGet, "/foo", emptyHandler, WithOnRetire(func(route *Route) {
+			calls.Add(1)
+		}))
+		other := f.MustAdd(MethodGet, "/bar", emptyHandler, WithInflightTracking(true))
+
+		require.NoError(t, onlyError(f.Delete(MethodGet, "/foo")))
+		require.NoError(t, f.Drain(context.Background(), route))
+		assert.Equal(t, int32(1), calls.Load())
+
+		txn := f.Txn(true)
+		defer txn.Abort()
+		require.NoError(t, txn.Truncate())
+		require.NoError(t, txn.Commit())
+		require.NoError(t, f.Drain(context.Background(), other))
+	})
+
+	t.Run("route registered again in the same transaction is not retired", func(t *testing.T) {
+		f := MustRouter(WithInflightTracking(true))
+		route := f.MustAdd(MethodGet, "/foo", emptyHandler)
+
+		require.NoError(t, f.Updates(func(txn *Txn) error {
+			if _, err := txn.DeleteRoute(route); err != nil {
+				return err
+			}
+			return txn.AddRoute(route)
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx, route), context.DeadlineExceeded)
+	})
+
+	t.Run("aborted transaction does not retire route", func(t *testing.T) {
+		f := MustRouter(WithInflightTracking(true))
+		route := f.MustAdd(MethodGet, "/foo", emptyHandler)
+
+		txn := f.Txn(true)
+		require.NoError(t, onlyError(txn.Delete(MethodGet, "/foo")))
+		txn.Abort()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx, route), context.DeadlineExceeded)
+	})
+
+	t.Run("route matched before commit is not retired before its handler runs", func(t *testing.T) {
+		var retired atomic.Bool
+		f := MustRouter(WithOnRetire(func(route *Route) {
+			retired.Store(true)
+		}))
+
+		matched := make(chan struct{})
+		release := make(chan struct{})
+		var ranAfterRetire atomic.Bool
+		old := f.MustAdd(MethodGet, "/foo", func(c *Context) {
+			ranAfterRetire.Store(retired.Load())
+		}, WithMatcher(blockingMatcher{matched: matched, release: release}))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+			f.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		// The request has looked up the route but its handler has not started yet.
+		<-matched
+		require.NoError(t, onlyError(f.Delete(MethodGet, "/foo", WithMatcher(blockingMatcher{}))))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx, old), context.DeadlineExceeded)
+
+		close(release)
+		<-done
+		require.NoError(t, f.Drain(context.Background(), old))
+		assert.False(t, ranAfterRetire.Load())
+		assert.True(t, retired.Load())
+	})
+
+	t.Run("retirement waits for requests served from older trees", func(t *testing.T) {
+		f := MustRouter(WithInflightTracking(true))
+		started := make(chan struct{})
+		release := make(chan struct{})
+		f.MustAdd(MethodGet, "/slow", func(c *Context) {
+			close(started)
+			<-release
+		})
+		route := f.MustAdd(MethodGet, "/foo", emptyHandler)
+
+		go func() {
+			req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+			f.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+		<-started
+
+		f.MustAdd(MethodGet, "/bar", emptyHandler)
+		require.NoError(t, onlyError(f.Delete(MethodGet, "/foo")))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Drain(ctx, route), context.DeadlineExceeded)
+
+		close(release)
+		require.NoError(t, f.Drain(context.Background(), route))
+	})
+
+	t.Run("untracked route", func(t *testing.T) {
+		f := MustRouter(WithInflightTracking(true))
+		route := f.MustAdd(MethodGet, "/foo", emptyHandler, WithInflightTracking(false))
+		assert.ErrorIs(t, f.Drain(context.Background(), route), ErrRouteNotTracked)
+		assert.ErrorIs(t, f.Drain(context.Background(), nil), ErrInvalidRoute)
+	})
+
+	t.Run("tracking cannot be disabled for a route with retire callbacks", func(t *testing.T) {
+		var calls atomic.Int32
+		onRetire := WithOnRetire(func(route *Route) {
+			calls.Add(1)
+		})
+
+		global := MustRouter(onRetire, WithInflightTracking(false))
+		global.MustAdd(MethodGet, "/foo", emptyHandler)
+		global.MustAdd(MethodGet, "/bar", emptyHandler, WithInflightTracking(false))
+		perRoute := MustRouter()
+		perRoute.MustAdd(MethodGet, "/foo", emptyHandler, onRetire, WithInflightTracking(false))
+		perRoute.MustAdd(MethodGet, "/bar", emptyHandler, WithInflightTracking(false), onRetire)
+
+		var routes []*Route
+		for _, f := range []*Router{global, perRoute} {
+			for _, pattern := range []string{"/foo", "/bar"} {
+				route, err := f.Delete(MethodGet, pattern)
+				require.NoError(t, err)
+				require.NoError(t, f.Drain(context.Background(), route))
+				routes = append(routes, route)
+			}
+		}
+		assert.Equal(t, int32(len(routes)), calls.Load())
+	})
+}
+
+// blockingMatcher signals when it is evaluated during the route lookup, and blocks until released.
+type blockingMatcher struct {
+	matched chan struct{}
+	release chan struct{}
+}
+
+func (m blockingMatcher) Match(_ RequestContext) bool {
+	close(m.matched)
+	<-m.release
+	return true
+}
+
+func (m blockingMatcher) Equal(other Matcher) bool {
+	_, ok := other.(blockingMatcher)
+	return ok
+}
@@ -0,0 +1,485 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// FindingKind is the kind of issue reported by [Lint].
+type FindingKind uint8
+
+const (
+	// FindingShadowedRoute indicates that a route can never be matched, because a route with the same pattern is
+	// evaluated first and matches every request the shadowed route would match. Routes[0] is the shadowed route and
+	// Routes[1] the shadowing route.
+	FindingShadowedRoute FindingKind = iota
+	// FindingMethodlessShadowed indicates that a route without methods and without matchers is only reachable with
+	// non-standard HTTP methods, because routes with the same pattern and without matchers cover every standard
+	// method. Routes[0] is the shadowed route and the following routes are the shadowing routes.
+	FindingMethodlessShadowed
+	// FindingShadowedRegexp indicates that a param or wildcard is unreachable unless the lookup backtracks, because
+	// a regexp evaluated first at the same level matches every segment it would match. Routes holds the routes
+	// registered under the shadowed param or wildcard.
+	FindingShadowedRegexp
+	// FindingPriorityTie indicates that two routes with the same pattern have the same matchers priority and
+	// overlapping methods and matchers, so the matching route depends on the registration order. Routes[0] is the
+	// route evaluated last.
+	FindingPriorityTie
+)
+
+func (k FindingKind) String() string {
+	switch k {
+	case FindingShadowedRoute:
+		return "shadowed route"
+	case FindingMethodlessShadowed:
+		return "method-less route shadowed"
+	case FindingShadowedRegexp:
+		return "shadowed regexp"
+	case FindingPriorityTie:
+		return "priority tie"
+	default:
+		return "unknown"
+	}
+}
+
+// Severity is the severity of a [Finding].
+type Severity uint8
+
+const (
+	// SeverityWarning indicates a configuration that is likely a mistake, but still reachable in some cases.
+	SeverityWarning Severity = iota
+	// SeverityError indicates a dead configuration.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Finding is an issue reported by [Lint].
+type Finding struct {
+	// Message describes the issue.
+	Message string
+	// Routes holds the affected routes. See [FindingKind] for the meaning of each route.
+	Routes []*Route
+	// Kind is the kind of issue.
+	Kind FindingKind
+	// Severity is the severity of the issue.
+	Severity Severity
+}
+
+func (f Finding) String() string {
+	return f.Severity.String() + ": " + f.Kind.String() + ": " + f.Message
+}
+
+// standardMethods are the methods defined by RFC 9110 and RFC 5789.
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Lint analyzes the routing tree captured by the provided [Iter] and reports logically dead or ambiguous
+// configurations which are accepted at insertion time: routes shadowed by routes with the same pattern, method-less
+// routes shadowed by routes covering every standard method, params and wildcards shadowed by an earlier regexp at the
+// same level, and matchers priority ties with overlapping matchers. Only matchers provided by this package are
+// analyzed, custom matchers are assumed to overlap with everything and to imply nothing but themselves. Findings are
+// reported in tree order. Lint is typically used in tests:
+//
+//	for _, finding := range fox.Lint(f.Iter()) {
+//		t.Error(finding)
+//	}
+func Lint(it Iter) []Finding {
+	var findings []Finding
+	lintNode(it.patterns, &findings)
+	return findings
+}
+
+func lintNode(n *node, findings *[]Finding) {
+	lintRoutes(n, findings)
+	// A param segment never contains a delimiter, while a wildcard may capture several segments.
+	if n.host {
+		lintRegexps(n.params, "param", "\n/.", findings)
+	} else {
+		lintRegexps(n.params, "param", "\n/", findings)
+	}
+	lintRegexps(n.wildcards, "wildcard", "\n", findings)
+
+	for _, children := range [][]*node{n.statics, n.params, n.wildcards} {
+		for _, child := range children {
+			lintNode(child, findings)
+		}
+	}
+}
+
+// lintRoutes reports shadowed routes and priority ties among routes registered at the same node, in evaluation order.
+func lintRoutes(n *node, findings *[]Finding) {
+	for j, later := range n.routes {
+		shadowed := false
+		for _, earlier := range n.routes[:j] {
+			if shadows(earlier, later) {
+				*findings = append(*findings, Finding{
+					Kind:     FindingShadowedRoute,
+					Severity: SeverityError,
+					Routes:   []*Route{later, earlier},
+					Message:  "route " + describeRoute(later) + " is shadowed by " + describeRoute(earlier),
+				})
+				shadowed = true
+				break
+			}
+		}
+		if shadowed {
+			continue
+		}
+
+		if len(later.methods) == 0 && len(later.matchers) == 0 {
+			var covering []*Route
+			for _, method := range standardMethods {
+				idx := slices.IndexFunc(n.routes[:j], func(r *Route) bool {
+					return len(r.matchers) == 0 && slices.Contains(r.methods, method)
+				})
+				if idx < 0 {
+					covering = nil
+					break
+				}
+				if !slices.Contains(covering, n.routes[idx]) {
+					covering = append(covering, n.routes[idx])
+				}
+			}
+			if len(covering) > 0 {
+				*findings = append(*findings, Finding{
+					Kind:     FindingMethodlessShadowed,
+					Severity: SeverityWarning,
+					Routes:   append([]*Route{later}, covering...),
+					Message:  "route " + describeRoute(later) + " is only reachable with non-standard methods",
+				})
+			}
+			continue
+		}
+
+		for _, earlier := range n.routes[:j] {
+			if len(earlier.matchers) > 0 && len(later.matchers) > 0 && earlier.priority == later.priority &&
+				(len(earlier.methods) > 0) == (len(later.methods) > 0) && overlaps(earlier, later) {
+				*findings = append(*findings, Finding{
+					Kind:     FindingPriorityTie,
+					Severity: SeverityWarning,
+					Routes:   []*Route{later, earlier},
+					Message: "route " + describeRoute(later) + " and " + describeRoute(earlier) +
+						" have the same priority and overlapping matchers, " + describeRoute(earlier) + " is evaluated first",
+				})
+			}
+		}
+	}
+}
+
+// lintRegexps reports params or wildcards shadowed by an earlier regexp at the same level. Excluded holds the runes
+// that never appear in a captured segment.
+func lintRegexps(children []*node, kind, excluded string, findings *[]Finding) {
+	for j, later := range children {
+		for _, earlier := range children[:j] {
+			if earlier.regexp == nil || !regexpCovers(earlier, later, excluded) {
+				continue
+			}
+			var routes []*Route
+			collectRoutes(later, &routes)
+			msg := kind
+			if later.regexp != nil {
+				msg += " with regexp " + later.key
+			}
+			*findings = append(*findings, Finding{
+				Kind:     FindingShadowedRegexp,
+				Severity: SeverityWarning,
+				Routes:   routes,
+				Message:  msg + " is shadowed by the regexp " + earlier.key + " unless the lookup backtracks",
+			})
+			break
+		}
+	}
+}
+
+func collectRoutes(n *node, routes *[]*Route) {
+	*routes = append(*routes, n.routes...)
+	for _, children := range [][]*node{n.statics, n.params, n.wildcards} {
+		for _, child := range children {
+			collectRoutes(child, routes)
+		}
+	}
+}
+
+// shadows reports whether every request matching later also matches earlier.
+func shadows(earlier, later *Route) bool {
+	if len(earlier.methods) > 0 {
+		if len(later.methods) == 0 {
+			return false
+		}
+		for _, method := range later.methods {
+			if !slices.Contains(earlier.methods, method) {
+				return false
+			}
+		}
+	}
+
+	for _, em := range earlier.matchers {
+		if !slices.ContainsFunc(later.matchers, func(lm Matcher) bool { return implies(lm, em) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether a request may match both routes.
+func overlaps(a, b *Route) bool {
+	if len(a.methods) > 0 && len(b.methods) > 0 && !slices.ContainsFunc(a.methods, func(m string) bool {
+		return slices.Contains(b.methods, m)
+	}) {
+		return false
+	}
+
+	for _, am := range a.matchers {
+		for _, bm := range b.matchers {
+			if disjoint(am, bm) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// implies reports whether a request satisfying a always satisfies b.
+func implies(a, b Matcher) bool {
+	if a.Equal(b) {
+		return true
+	}
+
+	switch bm := b.(type) {
+	case QueryRegexpMatcher:
+		if am, ok := a.(QueryMatcher); ok {
+			return am.key == bm.key && bm.regex.MatchString(am.value)
+		}
+	case HeaderRegexpMatcher:
+		if am, ok := a.(HeaderMatcher); ok {
+			return am.canonicalKey == bm.canonicalKey && bm.regex.MatchString(am.value)
+		}
+	case ClientIpMatcher:
+		if am, ok := a.(ClientIpMatcher); ok {
+			return netContains(bm.ipNet, am.ipNet)
+		}
+	}
+	return false
+}
+
+// disjoint reports whether no request can satisfy both a and b.
+func disjoint(a, b Matcher) bool {
+	switch am := a.(type) {
+	case QueryMatcher:
+		switch bm := b.(type) {
+		case QueryMatcher:
+			return am.key == bm.key && am.value != bm.value
+		case QueryRegexpMatcher:
+			return am.key == bm.key && !bm.regex.MatchString(am.value)
+		}
+	case HeaderMatcher:
+		switch bm := b.(type) {
+		case HeaderMatcher:
+			return am.canonicalKey == bm.canonicalKey && am.value != bm.value
+		case HeaderRegexpMatcher:
+			return am.canonicalKey == bm.canonicalKey && !bm.regex.MatchString(am.value)
+		}
+	case ClientIpMatcher:
+		if bm, ok := b.(ClientIpMatcher); ok {
+			return !netContains(am.ipNet, bm.ipNet) &&
+				!netContains(bm.ipNet, am.ipNet)
+		}
+	case QueryRegexpMatcher, HeaderRegexpMatcher:
+		return disjoint(b, a)
+	}
+	return false
+}
+
+// netContains reports whether the network a contains the network b.
+func netContains(a, b *net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	return aBits == bBits && aOnes <= bOnes && a.Contains(b.IP)
+}
+
+// regexpCovers reports whether the regexp of earlier matches every segment that later would match. This is a
+// conservative approximation: it only succeeds when earlier matches any segment, or when later matches a finite set of
+// segments which are all matched by earlier.
+func regexpCovers(earlier, later *node, excluded string) bool {
+	if universal(earlier.regexp, excluded) {
+		return true
+	}
+	if later.regexp == nil {
+		return false
+	}
+
+	re, err := syntax.Parse(later.regexp.String(), syntax.Perl)
+	if err != nil {
+		return false
+	}
+	segments, ok := finite(stripAnchors(re.Simplify()), 64)
+	if !ok {
+		return false
+	}
+	for _, segment := range segments {
+		if !earlier.regexp.MatchString(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// universal reports whether re matches any non-empty string that does not contain an excluded rune.
+func universal(re *regexp.Regexp, excluded string) bool {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return false
+	}
+	parsed = stripAnchors(parsed.Simplify())
+
+	switch parsed.Op {
+	case syntax.OpStar, syntax.OpPlus:
+	case syntax.OpRepeat:
+		if parsed.Min > 1 || parsed.Max != -1 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	sub := parsed.Sub[0]
+	switch sub.Op {
+	case syntax.OpAnyChar:
+		return true
+	case syntax.OpAnyCharNotNL:
+		return strings.ContainsRune(excluded, '\n')
+	case syntax.OpCharClass:
+		next := rune(0)
+		for i := 0; i < len(sub.Rune); i += 2 {
+			for r := next; r < sub.Rune[i]; r++ {
+				if !strings.ContainsRune(excluded, r) {
+					return false
+				}
+			}
+			next = sub.Rune[i+1] + 1
+		}
+		return next > unicode.MaxRune
+	default:
+		return false
+	}
+}
+
+// stripAnchors removes the leading and trailing text anchors added at compile time.
+func stripAnchors(re *syntax.Regexp) *syntax.Regexp {
+	if re.Op != syntax.OpConcat {
+		return re
+	}
+	subs := re.Sub
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		subs = subs[1:]
+	}
+	if len(subs) > 0 && subs[len(subs)-1].Op == syntax.OpEndText {
+		subs = subs[:len(subs)-1]
+	}
+	switch len(subs) {
+	case 0:
+		return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	case 1:
+		return subs[0]
+	default:
+		return &syntax.Regexp{Op: syntax.OpConcat, Sub: subs}
+	}
+}
+
+// finite returns every string matched by re if there are at most limit of them.
+func finite(re *syntax.Regexp, limit int) ([]string, bool) {
+	if re.Flags&syntax.FoldCase != 0 {
+		return nil, false
+	}
+
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var out []string
+		for i := 0; i < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(out) == limit {
+					return nil, false
+				}
+				out = append(out, string(r))
+			}
+		}
+		return out, true
+	case syntax.OpAlternate:
+		var out []string
+		for _, sub := range re.Sub {
+			s, ok := finite(sub, limit-len(out))
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s...)
+		}
+		return out, true
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, sub := range re.Sub {
+			s, ok := finite(sub, limit)
+			if !ok || len(out)*len(s) > limit {
+				return nil, false
+			}
+			product := make([]string, 0, len(out)*len(s))
+			for _, prefix := range out {
+				for _, suffix := range s {
+					product = append(product, prefix+suffix)
+				}
+			}
+			out = product
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+func describeRoute(route *Route) string {
+	var sb strings.Builder
+	if len(route.methods) > 0 {
+		sb.WriteString(strings.Join(route.methods, ","))
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(route.pattern)
+	for _, m := range route.matchers {
+		if s, ok := m.(fmt.Stringer); ok {
+			sb.WriteByte(' ')
+			sb.WriteString(s.String())
+		}
+	}
+	return sb.String()
+}
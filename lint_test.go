@@ -0,0 +1,145 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	cases := []struct {
+		name     string
+		routes   func(f *Router)
+		kinds    []FindingKind
+		severity []Severity
+		patterns [][]string
+	}{
+		{
+			name: "no finding",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/users/{id:[0-9]+}", emptyHandler)
+				f.MustAdd(MethodGet, "/users/{name}", emptyHandler)
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithQueryMatcher("a", "1"))
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithQueryMatcher("a", "2"))
+				f.MustAdd(MethodPost, "/foo", emptyHandler, WithHeaderMatcher("x", "1"))
+				f.MustAdd(MethodAny, "/foo", emptyHandler)
+			},
+		},
+		{
+			name: "shadowed by method-less route with fewer matchers",
+			routes: func(f *Router) {
+				f.MustAdd(MethodAny, "/foo", emptyHandler, WithQueryMatcher("a", "1"), WithMatcherPriority(10))
+				f.MustAdd(MethodAny, "/foo", emptyHandler, WithQueryMatcher("a", "1"), WithHeaderMatcher("x", "1"))
+			},
+			kinds:    []FindingKind{FindingShadowedRoute},
+			severity: []Severity{SeverityError},
+			patterns: [][]string{{"/foo", "/foo"}},
+		},
+		{
+			name: "shadowed by implied matchers",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithQueryRegexpMatcher("a", "[0-9]+"), WithClientIPMatcher("10.0.0.0/8"), WithMatcherPriority(5))
+				f.MustAdd([]string{http.MethodGet}, "/foo", emptyHandler, WithQueryMatcher("a", "42"), WithClientIPMatcher("10.1.0.0/16"))
+			},
+			kinds:    []FindingKind{FindingShadowedRoute},
+			severity: []Severity{SeverityError},
+			patterns: [][]string{{"/foo", "/foo"}},
+		},
+		{
+			name: "method-less route covered by standard methods",
+			routes: func(f *Router) {
+				f.MustAdd([]string{
+					http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+					http.MethodDelete, http.MethodConnect, http.MethodOptions,
+				}, "/foo/{id}", emptyHandler)
+				f.MustAdd([]string{http.MethodTrace}, "/foo/{name}", emptyHandler)
+				f.MustAdd(MethodAny, "/foo/{any}", emptyHandler)
+			},
+			kinds:    []FindingKind{FindingMethodlessShadowed},
+			severity: []Severity{SeverityWarning},
+			patterns: [][]string{{"/foo/{any}", "/foo/{id}", "/foo/{name}"}},
+		},
+		{
+			name: "regexp shadowed by universal regexp",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo/{any:[^/]+}", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/{id:[0-9]+}/bar", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/{name}/baz", emptyHandler)
+			},
+			kinds:    []FindingKind{FindingShadowedRegexp, FindingShadowedRegexp},
+			severity: []Severity{SeverityWarning, SeverityWarning},
+			patterns: [][]string{{"/foo/{id:[0-9]+}/bar"}, {"/foo/{name}/baz"}},
+		},
+		{
+			name: "finite regexp shadowed by superset",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo/{id:[a-z]+}", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/{id:(?:bar|baz)}/x", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/{id:(?:bar|42)}/y", emptyHandler)
+			},
+			kinds:    []FindingKind{FindingShadowedRegexp},
+			severity: []Severity{SeverityWarning},
+			patterns: [][]string{{"/foo/{id:(?:bar|baz)}/x"}},
+		},
+		{
+			name: "wildcard shadowed by universal regexp",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo/+{any:.+}", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/+{rest}", emptyHandler)
+			},
+			kinds:    []FindingKind{FindingShadowedRegexp},
+			severity: []Severity{SeverityWarning},
+			patterns: [][]string{{"/foo/+{rest}"}},
+		},
+		{
+			name: "regexp not universal for wildcard",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo/+{any:[^/]+}", emptyHandler)
+				f.MustAdd(MethodGet, "/foo/+{rest}", emptyHandler)
+			},
+		},
+		{
+			name: "priority tie with overlapping matchers",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithQueryMatcher("a", "1"))
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithHeaderMatcher("x", "1"))
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithHeaderMatcher("x", "2"))
+			},
+			kinds:    []FindingKind{FindingPriorityTie, FindingPriorityTie},
+			severity: []Severity{SeverityWarning, SeverityWarning},
+			patterns: [][]string{{"/foo", "/foo"}, {"/foo", "/foo"}},
+		},
+		{
+			name: "priority tie with disjoint client ip",
+			routes: func(f *Router) {
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithClientIPMatcher("10.0.0.0/8"))
+				f.MustAdd(MethodGet, "/foo", emptyHandler, WithClientIPMatcher("192.168.0.0/16"))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := MustRouter(AllowRegexpParam(true))
+			tc.routes(f)
+			findings := Lint(f.Iter())
+			require.Len(t, findings, len(tc.kinds), findings)
+			for i, finding := range findings {
+				assert.Equal(t, tc.kinds[i], finding.Kind)
+				assert.Equal(t, tc.severity[i], finding.Severity)
+				patterns := make([]string, 0, len(finding.Routes))
+				for _, route := range finding.Routes {
+					patterns = append(patterns, route.Pattern())
+				}
+				assert.Equal(t, tc.patterns[i], patterns)
+				assert.NotEmpty(t, finding.String())
+			}
+		})
+	}
+}
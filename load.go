@@ -0,0 +1,436 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fox-toolkit/fox/internal/slicesutil"
+)
+
+// BulkLoader collects the routes of a large route table, typically at startup, and registers them in a single
+// transaction with [Router.Load]. The routing tree is then built bottom-up in one pass, instead of inserting routes
+// one at a time. A BulkLoader must be created with [NewBulkLoader] and is NOT safe for concurrent use.
+type BulkLoader struct {
+	fox    *Router
+	routes []*Route
+}
+
+// NewBulkLoader returns a [BulkLoader] registering routes on the provided [Router]. If size is positive, space is
+// preallocated for this number of routes.
+func NewBulkLoader(fox *Router, size int) *BulkLoader {
+	return &BulkLoader{
+		fox:    fox,
+		routes: make([]*Route, 0, max(size, 0)),
+	}
+}
+
+// Add creates a new route for the given methods, pattern and matchers, and queues it for loading. On success, it
+// returns the new [Route], which is not registered until [BulkLoader.Load] is called. If an error occurs, it returns
+// one of the following:
+//   - [ErrInvalidRoute]: If the provided method or pattern is invalid.
+//   - [ErrInvalidConfig]: If the provided route options are invalid.
+//   - [ErrInvalidMatcher]: If the provided matcher options are invalid.
+func (l *BulkLoader) Add(methods []string, pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	rte, err := l.fox.NewRoute(methods, pattern, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+	l.routes = append(l.routes, rte)
+	return rte, nil
+}
+
+// AddRoute queues the provided [Route] for loading.
+func (l *BulkLoader) AddRoute(route *Route) {
+	l.routes = append(l.routes, route)
+}
+
+// Len returns the number of queued routes.
+func (l *BulkLoader) Len() int {
+	return len(l.routes)
+}
+
+// Load registers all queued routes, in queue order, with [Router.Load]. On success, the queue is cleared. If an
+// error occurs, no route is registered and the queue is left unchanged. See [Router.Load] for the possible errors.
+func (l *BulkLoader) Load() error {
+	if err := l.fox.Load(slices.Values(l.routes)); err != nil {
+		return err
+	}
+	clear(l.routes)
+	l.routes = l.routes[:0]
+	return nil
+}
+
+// bulkNode is a position in the tree under construction, reached from the root by a sequence of tokens. Unlike
+// the radix tree, static tokens are not split, so every static token is a single edge. The nodes of the radix tree
+// are only created once all routes are known, by compressing the static edges of each position.
+type bulkNode struct {
+	// node is the tree node for this position. Its routes, params and wildcards are maintained as routes are
+	// loaded, in the same order as incremental inserts, while its statics are only set by build.
+	node   *node
+	parent *bulkNode
+	// statics holds the static children in insertion order, indexed by token value once there are more than
+	// bulkIndexThreshold, and dynamics the param and wildcard children.
+	statics  []*bulkNode
+	index    map[string]*bulkNode
+	dynamics []*bulkNode
+	// pwLens holds the distinct key length, in increasing order, of the static children having param or wildcard
+	// edges. It is used to compute the depth of a route.
+	pwLens []int
+	// key is the token value of a static position, and host reports whether it belongs to the hostname.
+	key  string
+	host bool
+	typ  nodeType
+}
+
+// bulkIndexThreshold is the number of static children above which a position indexes them by token value.
+const bulkIndexThreshold = 8
+
+// bulkEdge is a static edge to compress into the radix tree.
+type bulkEdge struct {
+	node *node
+	key  string
+	host bool
+}
+
+// bulkLoad holds the state of a bulk build.
+type bulkLoad struct {
+	root     *bulkNode
+	names    map[string]*Route
+	methods  map[string]uint
+	path     []*bulkNode
+	size     int
+	maxDepth int
+	// maxParams, expiry and tracked follow the semantic of the tXn fields of the same name.
+	maxParams int
+	expiry    int64
+	tracked   bool
+}
+
+// emptyForLoad reports whether no route is registered in the transaction, in which case the tree can be bulk built.
+func (t *tXn) emptyForLoad() bool {
+	root := t.patterns
+	return len(root.routes) == 0 && len(root.statics) == 0 && len(root.params) == 0 && len(root.wildcards) == 0 &&
+		len(t.names.statics) == 0
+}
+
+// load builds the tree of the routes yielded by the provided sequence, which must replace an empty tree. It produces
+// exactly the same tree, and fails with exactly the same errors, as inserting each route in sequence order. On error,
+// the tree is built with the routes that precede the failing one.
+func (t *tXn) load(routes iter.Seq[*Route]) error {
+	b := &bulkLoad{
+		root:    &bulkNode{node: new(node), typ: nodeStatic},
+		names:   make(map[string]*Route),
+		methods: make(map[string]uint),
+	}
+
+	var err error
+	for route := range routes {
+		if err = b.insert(route); err != nil {
+			break
+		}
+	}
+
+	if b.size == 0 {
+		return err
+	}
+
+	t.patterns = b.root.build()
+	t.names = buildNames(b.names)
+	t.size += b.size
+	t.maxDepth = max(t.maxDepth, b.maxDepth)
+	t.maxParams = max(t.maxParams, b.maxParams)
+	if b.expiry > 0 && (t.expiry == 0 || b.expiry < t.expiry) {
+		t.expiry = b.expiry
+	}
+	t.tracked = t.tracked || b.tracked
+	if len(b.methods) > 0 {
+		if !t.forked {
+			t.methods = maps.Clone(t.methods)
+			t.forked = true
+		}
+		for method, n := range b.methods {
+			t.methods[method] += n
+		}
+	}
+	return err
+}
+
+// insert validates the route against the routes loaded so far, with the same rules and errors as tXn.insertTokens,
+// and adds it to its position.
+func (b *bulkLoad) insert(route *Route) error {
+	if route == nil {
+		return fmt.Errorf("%w: nil route", ErrInvalidRoute)
+	}
+	if route.fallback {
+		return fmt.Errorf("%w: fallback route cannot be registered as a route", ErrInvalidRoute)
+	}
+
+	// Find the existing positions along the route, path[i] being reached after i tokens.
+	path := append(b.path[:0], b.root)
+	for _, tk := range route.tokens {
+		c := path[len(path)-1].lookup(tk)
+		if c == nil {
+			break
+		}
+		path = append(path, c)
+	}
+	b.path = path
+
+	var p, leaf *bulkNode
+	if last := len(route.tokens); len(path) > last {
+		p, leaf = path[last-1], path[last]
+	} else if len(path) == last {
+		p = path[last-1]
+	}
+
+	if leaf != nil {
+		if idx := slices.IndexFunc(leaf.node.routes, func(r *Route) bool {
+			return r.matchersEqual(route.matchers) && slicesutil.Overlap(r.methods, route.methods)
+		}); idx >= 0 {
+			return &RouteConflictError{New: route, Conflicts: []*Route{leaf.node.routes[idx]}}
+		}
+	}
+
+	var conflicts []*Route
+	if route.catchEmpty && p != nil {
+		for _, r := range p.node.routes {
+			if (len(r.matchers) == 0 || r.matchersEqual(route.matchers)) && slicesutil.Overlap(r.methods, route.methods) {
+				conflicts = append(conflicts, r)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &RouteConflictError{New: route, Conflicts: conflicts, isShadowed: true}
+	}
+
+	if leaf != nil {
+		for _, wildcard := range leaf.node.wildcards {
+			for _, r := range wildcard.routes {
+				if r.catchEmpty && (len(route.matchers) == 0 || route.matchersEqual(r.matchers)) && slicesutil.Overlap(route.methods, r.methods) {
+					conflicts = append(conflicts, r)
+				}
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &RouteConflictError{New: route, Conflicts: conflicts, isShadowed: true}
+	}
+
+	if route.name != "" {
+		if conflict, ok := b.names[route.name]; ok {
+			return &RouteNameConflictError{New: route, Conflict: conflict}
+		}
+		b.names[route.name] = route
+	}
+
+	for _, tk := range route.tokens[len(path)-1:] {
+		path = append(path, path[len(path)-1].newChild(tk))
+	}
+	b.path = path
+	path[len(path)-1].node.addRoute(route)
+
+	b.size++
+	b.maxDepth = max(b.maxDepth, depth(path, route.tokens))
+	b.maxParams = max(b.maxParams, len(route.params))
+	if route.expiry > 0 && (b.expiry == 0 || route.expiry < b.expiry) {
+		b.expiry = route.expiry
+	}
+	b.tracked = b.tracked || route.lc != nil
+	for _, method := range route.methods {
+		b.methods[method]++
+	}
+	return nil
+}
+
+// depth returns the same value as tXn.computePathDepth for a route inserted in the tree loaded so far, given the
+// positions along the route: the number of nodes, along the static segments of the route, having param or wildcard
+// edges.
+func depth(path []*bulkNode, tokens []token) int {
+	var depth int
+	if root := path[0].node; len(root.params) > 0 || len(root.wildcards) > 0 {
+		depth++
+	}
+
+	for i, tk := range tokens {
+		if tk.typ != nodeStatic {
+			continue
+		}
+		n := path[i]
+		for _, l := range n.pwLens {
+			if l > len(tk.value) {
+				break
+			}
+			if c := n.lookupStatic(tk.value[:l]); c != nil && len(c.dynamics) > 0 {
+				depth++
+			}
+		}
+	}
+
+	return depth
+}
+
+// lookup returns the position reached from n by the provided token, or nil if there is none.
+func (n *bulkNode) lookup(tk token) *bulkNode {
+	if tk.typ == nodeStatic {
+		return n.lookupStatic(tk.value)
+	}
+
+	key := canonicalKey(tk)
+	for _, c := range n.dynamics {
+		if c.typ == tk.typ && c.node.key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// lookupStatic returns the static position reached from n by the provided key, or nil if there is none.
+func (n *bulkNode) lookupStatic(key string) *bulkNode {
+	if n.index != nil {
+		return n.index[key]
+	}
+	for _, c := range n.statics {
+		if c.key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// newChild creates the position reached from n by the provided token, which must not exist.
+func (n *bulkNode) newChild(tk token) *bulkNode {
+	c := &bulkNode{parent: n, typ: tk.typ}
+	switch tk.typ {
+	case nodeStatic:
+		c.node = new(node)
+		c.key = tk.value
+		c.host = tk.hsplit
+		n.statics = append(n.statics, c)
+		if n.index != nil {
+			n.index[c.key] = c
+		} else if len(n.statics) > bulkIndexThreshold {
+			n.index = make(map[string]*bulkNode, 2*len(n.statics))
+			for _, s := range n.statics {
+				n.index[s.key] = s
+			}
+		}
+		return c
+	case nodeParam:
+		c.node = &node{key: canonicalKey(tk), regexp: tk.regexp}
+		n.node.addParamEdge(c.node)
+	case nodeWildcard:
+		c.node = &node{key: canonicalKey(tk), regexp: tk.regexp}
+		n.node.addWildcardEdge(c.node)
+	default:
+		panic("internal error: unknown token type")
+	}
+
+	n.dynamics = append(n.dynamics, c)
+	n.markParamOrWildcard()
+	return c
+}
+
+// markParamOrWildcard records in the parent of n that n has param or wildcard edges.
+func (n *bulkNode) markParamOrWildcard() {
+	if n.typ != nodeStatic || n.parent == nil || len(n.dynamics) > 1 {
+		return
+	}
+
+	p := n.parent
+	if idx, found := slices.BinarySearch(p.pwLens, len(n.key)); !found {
+		p.pwLens = slices.Insert(p.pwLens, idx, len(n.key))
+	}
+}
+
+// build sets the static edges of the tree nodes of n and its descendants, and returns the tree node of n.
+func (n *bulkNode) build() *node {
+	for _, c := range n.dynamics {
+		c.build()
+	}
+	if len(n.statics) == 0 {
+		return n.node
+	}
+
+	edges := make([]bulkEdge, 0, len(n.statics))
+	for _, c := range n.statics {
+		c.build()
+		edges = append(edges, bulkEdge{key: c.key, host: c.host, node: c.node})
+	}
+	slices.SortFunc(edges, func(a, b bulkEdge) int {
+		return strings.Compare(a.key, b.key)
+	})
+	n.node.statics = mergeStatics(n.node.statics, compress(edges, 0))
+	return n.node
+}
+
+// buildNames returns the names tree of the provided routes.
+func buildNames(names map[string]*Route) *node {
+	root := new(node)
+	if len(names) == 0 {
+		return root
+	}
+
+	edges := make([]bulkEdge, 0, len(names))
+	for name, route := range names {
+		edges = append(edges, bulkEdge{key: name, node: &node{routes: []*Route{route}}})
+	}
+	slices.SortFunc(edges, func(a, b bulkEdge) int {
+		return strings.Compare(a.key, b.key)
+	})
+	root.statics = compress(edges, 0)
+	return root
+}
+
+// compress returns the radix tree nodes of the provided static edges, sorted by key and sharing the same prefix up
+// to offset. The node of an edge is reused for the radix node ending at its key, and split nodes are created where
+// keys diverge, exactly as incremental inserts would.
+func compress(edges []bulkEdge, offset int) []*node {
+	nodes := make([]*node, 0, 1)
+	for len(edges) > 0 {
+		label := edges[0].key[offset]
+		end := 1
+		for end < len(edges) && edges[end].key[offset] == label {
+			end++
+		}
+		group := edges[:end]
+		edges = edges[end:]
+
+		// Since keys are sorted, the common prefix of the group is the one of its first and last keys.
+		first, last := group[0], group[len(group)-1]
+		prefix := offset + longestPrefix(first.key[offset:], last.key[offset:])
+
+		n := &node{}
+		if len(first.key) == prefix {
+			n = first.node
+			group = group[1:]
+		}
+		n.label = label
+		n.key = first.key[offset:prefix]
+		n.host = first.host
+		if len(group) > 0 {
+			n.statics = mergeStatics(n.statics, compress(group, prefix))
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// mergeStatics returns the static edges of both slices, sorted by label.
+func mergeStatics(statics, others []*node) []*node {
+	if len(statics) == 0 {
+		return others
+	}
+	statics = append(statics, others...)
+	slices.SortFunc(statics, func(a, b *node) int {
+		return cmp.Compare(a.label, b.label)
+	})
+	return statics
+}
@@ -5,6 +5,7 @@
 package fox
 
 import (
+	"crypto/rand"
 	"errors"
 	"log/slog"
 	"time"
@@ -33,14 +34,34 @@ const (
 	// LoggerLocationKey is the key used by the built-in logger middleware for redirect location header.
 	// The associated [slog.Value] is a string.
 	LoggerLocationKey = "location"
+	// LoggerErrorKey is the key used by the built-in logger middleware for the error returned by a [HandlerFuncE].
+	// The associated [slog.Value] is a string.
+	LoggerErrorKey = "error"
+	// LoggerOriginalPathKey is the key used by the built-in logger middleware for the path of a request rerouted with
+	// [Context.Reroute] or [Context.RerouteHost]. The associated [slog.Value] is a string.
+	LoggerOriginalPathKey = "original_path"
+	// LoggerNameKey is the key used by the request logger returned by [Context.Logger] for the route name.
+	// The associated [slog.Value] is a string.
+	LoggerNameKey = "name"
+	// LoggerClientIPKey is the key used by the request logger returned by [Context.Logger] for the client IP.
+	// The associated [slog.Value] is a string.
+	LoggerClientIPKey = "client_ip"
+	// LoggerRequestIDKey is the key used by the request logger returned by [Context.Logger] for the request ID.
+	// The associated [slog.Value] is a string.
+	LoggerRequestIDKey = "request_id"
 )
 
 // Logger returns a middleware that logs request information using the provided [slog.Handler].
 // It logs details such as the remote or client IP, HTTP method, request path, status code and latency.
 // Status codes are logged at different levels: 2xx at INFO, 3xx at DEBUG (with Location header if present),
-// 4xx at WARN, and 5xx at ERROR.
+// 4xx at WARN, and 5xx at ERROR. The error returned by a [HandlerFuncE], if any, and the original path of a rerouted
+// request are logged as well. If the handler is nil, the request logger returned by [Context.Logger] is used, so
+// records share the request ID and the attributes configured with [WithRequestLogger].
 func Logger(handler slog.Handler) MiddlewareFunc {
-	log := slog.New(handler)
+	var log *slog.Logger
+	if handler != nil {
+		log = slog.New(handler)
+	}
 	return func(next HandlerFunc) HandlerFunc {
 		return func(c *Context) {
 			start := time.Now()
@@ -54,24 +75,33 @@ func Logger(handler slog.Handler) MiddlewareFunc {
 				location = c.Writer().Header().Get(HeaderLocation)
 			}
 
-			var ipStr string
-			ip, err := c.ClientIP()
-			if err == nil {
-				ipStr = ip.String()
-			} else if errors.Is(err, ErrNoClientIPResolver) {
-				ipStr = c.RemoteIP().String()
+			ipStr := clientIPString(c)
+
+			var l *slog.Logger
+			if log == nil {
+				// The method and host are already attributes of the request logger.
+				l = c.Logger().With(
+					slog.Int(LoggerStatusKey, c.Writer().Status()),
+					slog.String(LoggerPathKey, c.Path()),
+					slog.Int(LoggerSizeKey, c.Writer().Size()),
+					slog.Duration(LoggerLatencyKey, latency),
+				)
 			} else {
-				ipStr = "unknown"
+				l = log.With(
+					slog.Int(LoggerStatusKey, c.Writer().Status()),
+					slog.String(LoggerMethodKey, c.Method()),
+					slog.String(LoggerHostKey, c.Host()),
+					slog.String(LoggerPathKey, c.Path()),
+					slog.Int(LoggerSizeKey, c.Writer().Size()),
+					slog.Duration(LoggerLatencyKey, latency),
+				)
+			}
+			if c.reroutes > 0 {
+				l = l.With(slog.String(LoggerOriginalPathKey, c.origPath))
+			}
+			if err := c.Err(); err != nil {
+				l = l.With(slog.String(LoggerErrorKey, err.Error()))
 			}
-
-			l := log.With(
-				slog.Int(LoggerStatusKey, c.Writer().Status()),
-				slog.String(LoggerMethodKey, c.Method()),
-				slog.String(LoggerHostKey, c.Host()),
-				slog.String(LoggerPathKey, c.Path()),
-				slog.Int(LoggerSizeKey, c.Writer().Size()),
-				slog.Duration(LoggerLatencyKey, latency),
-			)
 			if location == "" {
 				l.Log(
 					req.Context(),
@@ -105,3 +135,74 @@ func level(status int) slog.Level {
 		return slog.LevelInfo
 	}
 }
+
+// Logger returns a [slog.Logger] for the current request, pre-populated with the route pattern and name (if any),
+// the request method and host, the client IP and a request ID. The request ID is read from the "X-Request-Id" header,
+// or generated otherwise. The [slog.Handler] and additional attributes are configured with [WithRequestLogger], and
+// the handler defaults to the one of [slog.Default]. The logger is created on first use, so handlers that never log
+// don't pay for it, and reused until the matched route changes, e.g. after [Context.Reroute].
+func (c *Context) Logger() *slog.Logger {
+	if c.logger != nil && c.loggerRoute == c.route {
+		return c.logger
+	}
+
+	handler := c.fox.reqLogHandler
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+
+	attrs := make([]slog.Attr, 0, 6)
+	if pattern := c.Pattern(); pattern != "" {
+		attrs = append(attrs, slog.String(LoggerRouteKey, pattern))
+	}
+	if c.route != nil && c.route.name != "" {
+		attrs = append(attrs, slog.String(LoggerNameKey, c.route.name))
+	}
+	attrs = append(attrs,
+		slog.String(LoggerMethodKey, c.Method()),
+		slog.String(LoggerHostKey, c.Host()),
+		slog.String(LoggerClientIPKey, clientIPString(c)),
+		slog.String(LoggerRequestIDKey, c.requestID()),
+	)
+	if c.fox.reqLogAttrs != nil {
+		attrs = append(attrs, c.fox.reqLogAttrs(c)...)
+	}
+
+	c.logger = slog.New(handler.WithAttrs(attrs))
+	c.loggerRoute = c.route
+	return c.logger
+}
+
+// requestID returns the ID of the request, read from the "X-Request-Id" header or generated on first use.
+func (c *Context) requestID() string {
+	if c.reqID == "" {
+		c.reqID = c.Header(HeaderXRequestID)
+		if c.reqID == "" {
+			var u UUID
+			_, _ = rand.Read(u[:])
+			u[6] = (u[6] & 0x0f) | 0x40 // Version 4
+			u[8] = (u[8] & 0x3f) | 0x80 // Variant RFC 9562
+			c.reqID = u.String()
+		}
+	}
+	return c.reqID
+}
+
+// resetLogger clears the request logger and ID.
+func (c *Context) resetLogger() {
+	c.logger = nil
+	c.loggerRoute = nil
+	c.reqID = ""
+}
+
+// clientIPString returns the client IP, or the remote IP if no resolver is configured.
+func clientIPString(c *Context) string {
+	ip, err := c.ClientIP()
+	if err == nil {
+		return ip.String()
+	}
+	if errors.Is(err, ErrNoClientIPResolver) {
+		return c.RemoteIP().String()
+	}
+	return "unknown"
+}
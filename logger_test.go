@@ -2,6 +2,7 @@ package fox
 
 import (
 	"bytes"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -34,6 +35,12 @@ func TestLoggerWithHandler(t *testing.T) {
 	require.NoError(t, onlyError(f.Add(MethodGet, "/failure", func(c *Context) {
 		c.Writer().WriteHeader(http.StatusInternalServerError)
 	})))
+	require.NoError(t, onlyError(f.Add(MethodGet, "/error", HandleE(func(c *Context) error {
+		return NewHTTPError(http.StatusBadRequest, errors.New("boom"))
+	}))))
+	require.NoError(t, onlyError(f.Add(MethodGet, "/legacy", HandleE(func(c *Context) error {
+		return c.Reroute(http.MethodGet, "/success")
+	}))))
 
 	cases := []struct {
 		name string
@@ -55,6 +62,17 @@ func TestLoggerWithHandler(t *testing.T) {
 			req:  httptest.NewRequest(http.MethodGet, "/foobar", nil),
 			want: "time=time level=WARN msg=192.0.2.1 status=404 method=GET host=example.com path=/foobar size=19 latency=latency\n",
 		},
+		{
+			name: "should log handler error",
+			req:  httptest.NewRequest(http.MethodGet, "/error", nil),
+			want: "time=time level=WARN msg=192.0.2.1 status=400 method=GET host=example.com path=/error size=12 latency=latency error=\"Bad Request: boom\"\n",
+		},
+		{
+			name: "should log original path",
+			req:  httptest.NewRequest(http.MethodGet, "/legacy", nil),
+			want: "time=time level=INFO msg=192.0.2.1 status=200 method=GET host=example.com path=/success size=0 latency=latency original_path=/legacy\n" +
+				"time=time level=INFO msg=192.0.2.1 status=200 method=GET host=example.com path=/success size=0 latency=latency original_path=/legacy\n",
+		},
 		{
 			name: "should log debug level",
 			req:  httptest.NewRequest(http.MethodGet, "/success/", nil),
@@ -72,3 +90,79 @@ func TestLoggerWithHandler(t *testing.T) {
 	}
 
 }
+
+func TestContext_Logger(t *testing.T) {
+	type tenantKey struct{}
+	buf := bytes.NewBuffer(nil)
+	handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == "time" || a.Key == "latency" {
+				return slog.String(a.Key, a.Key)
+			}
+			return a
+		},
+	})
+
+	f, err := NewRouter(
+		WithRequestLogger(handler, func(c *Context) []slog.Attr {
+			tenant, _ := c.Get(tenantKey{}).(string)
+			return []slog.Attr{slog.String("tenant", tenant)}
+		}),
+		WithMiddleware(Logger(nil)),
+	)
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		c.Set(tenantKey{}, "acme")
+		l := c.Logger()
+		assert.Same(t, l, c.Logger())
+		l.Info("handling")
+	}, WithName("user"))
+	f.MustAdd(MethodGet, "/legacy/{id}", HandleE(func(c *Context) error {
+		c.Logger().Info("rerouting")
+		return c.Reroute("", "/users/"+c.Param("id"))
+	}))
+
+	t.Run("route with request id header", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		req.Header.Set(HeaderXRequestID, "abc")
+		f.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t,
+			"time=time level=INFO msg=handling route=/users/{id} name=user method=GET host=example.com client_ip=192.0.2.1 request_id=abc tenant=acme\n"+
+				"time=time level=INFO msg=192.0.2.1 route=/users/{id} name=user method=GET host=example.com client_ip=192.0.2.1 request_id=abc tenant=acme status=200 path=/users/42 size=0 latency=latency\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("no route with generated request id", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		f.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Regexp(t,
+			`^time=time level=WARN msg=192.0.2.1 method=GET host=example.com client_ip=192.0.2.1 request_id=[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12} tenant="" status=404 path=/missing size=19 latency=latency\n$`,
+			buf.String(),
+		)
+	})
+
+	t.Run("logger follows reroute with the same request id", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/legacy/42", nil)
+		req.Header.Set(HeaderXRequestID, "abc")
+		f.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Contains(t, buf.String(), "msg=rerouting route=/legacy/{id} method=GET host=example.com client_ip=192.0.2.1 request_id=abc")
+		assert.Contains(t, buf.String(), "msg=handling route=/users/{id} name=user method=GET host=example.com client_ip=192.0.2.1 request_id=abc")
+	})
+}
+
+func TestWithRequestLoggerNilHandler(t *testing.T) {
+	_, err := NewRouter(WithRequestLogger(nil, nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestContext_LoggerDefault(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/foo", func(c *Context) {
+		assert.NotNil(t, c.Logger())
+	})
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo", nil))
+}
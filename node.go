@@ -64,20 +64,27 @@ func (n *node) lookup(method, hostPort, path string, c *Context, lazy bool) (int
 	*c.skipStack = (*c.skipStack)[:0]
 	// The tree for this method, we only have path registered
 	if len(n.params) == 0 && len(n.wildcards) == 0 && len(n.statics) == 1 && n.statics[0].label == slashDelim {
-		return lookupByPath(n, method, path, c, lazy, offsetZero)
+		return lookupPathPhase(n, method, path, c, lazy, offsetZero)
 	}
 
 	host := netutil.StripHostPort(hostPort)
 	if host == "" {
-		return lookupByPath(n, method, path, c, lazy, offsetZero)
+		return lookupPathPhase(n, method, path, c, lazy, offsetZero)
 	}
 
+	var phase int
+	if c.trace != nil {
+		phase = c.trace.phase("hostname", host)
+	}
 	idx, nd, tsr := lookupByHostname(n, method, host, path, c, lazy)
+	if c.trace != nil {
+		c.trace.steps[phase].Matched = nd != nil
+	}
 	if nd == nil {
 		// No match with hostname, fallback to path-only.
 		*c.skipStack = (*c.skipStack)[:0]
 		*c.params = (*c.params)[:0]
-		if i, pathNode, pathTsr := lookupByPath(n, method, path, c, lazy, offsetZero); pathNode != nil {
+		if i, pathNode, pathTsr := lookupPathPhase(n, method, path, c, lazy, offsetZero); pathNode != nil {
 			return i, pathNode, pathTsr
 		}
 	}
@@ -85,6 +92,17 @@ func (n *node) lookup(method, hostPort, path string, c *Context, lazy bool) (int
 	return idx, nd, tsr
 }
 
+// lookupPathPhase is like lookupByPath, but also records the path lookup phase and its outcome in the trace, if any.
+func lookupPathPhase(root *node, method, path string, c *Context, lazy bool, offset int) (int, *node, bool) {
+	if c.trace == nil {
+		return lookupByPath(root, method, path, c, lazy, offset)
+	}
+	phase := c.trace.phase("path", path)
+	idx, n, tsr := lookupByPath(root, method, path, c, lazy, offset)
+	c.trace.steps[phase].Matched = n != nil
+	return idx, n, tsr
+}
+
 func lookupByHostname(root *node, method, host, path string, c *Context, lazy bool) (index int, n *node, tsr bool) {
 	var (
 		charsMatched     int
@@ -115,6 +133,9 @@ Walk:
 						})
 					}
 
+					if c.trace != nil {
+						c.trace.node(StepStatic, child, search[keyLen:])
+					}
 					matched = child
 					search = search[keyLen:]
 					charsMatched += keyLen
@@ -146,8 +167,14 @@ Walk:
 
 			segment := search[:end]
 			for i, paramNode := range params {
-				if paramNode.regexp != nil && !paramNode.regexp.MatchString(segment) {
-					continue
+				if paramNode.regexp != nil {
+					ok := paramNode.regexp.MatchString(segment)
+					if c.trace != nil {
+						c.trace.regexp(paramNode, segment, ok)
+					}
+					if !ok {
+						continue
+					}
 				}
 
 				nextChildIx := i + 1
@@ -164,6 +191,9 @@ Walk:
 					*c.params = append(*c.params, segment)
 				}
 
+				if c.trace != nil {
+					c.trace.node(StepParam, paramNode, segment)
+				}
 				matched = paramNode
 				search = search[end:]
 				charsMatched += end
@@ -200,9 +230,15 @@ Walk:
 						continue
 					}
 
-					if wildcardNode.regexp != nil && !wildcardNode.regexp.MatchString(capturedValue) {
-						searchStart = captureEnd + 1
-						continue
+					if wildcardNode.regexp != nil {
+						ok := wildcardNode.regexp.MatchString(capturedValue)
+						if c.trace != nil {
+							c.trace.regexp(wildcardNode, capturedValue, ok)
+						}
+						if !ok {
+							searchStart = captureEnd + 1
+							continue
+						}
 					}
 
 					// We have a potential match, save backtrack state
@@ -221,6 +257,9 @@ Walk:
 					}
 
 					// Descend into wildcard subtree
+					if c.trace != nil {
+						c.trace.node(StepWildcard, wildcardNode, capturedValue)
+					}
 					matched = wildcardNode
 					search = host[captureEnd:]
 					charsMatched = captureEnd
@@ -250,14 +289,23 @@ Walk:
 					continue // Not a suffix catchall
 				}
 
-				if wildcardNode.regexp != nil && !wildcardNode.regexp.MatchString(search) {
-					continue
+				if wildcardNode.regexp != nil {
+					ok := wildcardNode.regexp.MatchString(search)
+					if c.trace != nil {
+						c.trace.regexp(wildcardNode, search, ok)
+					}
+					if !ok {
+						continue
+					}
 				}
 
 				if !lazy {
 					*c.params = append(*c.params, search)
 				}
 
+				if c.trace != nil {
+					c.trace.node(StepWildcard, wildcardNode, search)
+				}
 				matched = wildcardNode
 				break Walk
 			}
@@ -268,7 +316,7 @@ Walk:
 
 	if _, pathChild := matched.getStaticEdge(slashDelim); pathChild != nil {
 		stackOffset := len(*c.skipStack)
-		idx, subNode, subTsr := lookupByPath(matched, method, path, c, lazy, stackOffset)
+		idx, subNode, subTsr := lookupPathPhase(matched, method, path, c, lazy, stackOffset)
 		if subNode != nil {
 			return idx, subNode, subTsr
 		}
@@ -283,6 +331,9 @@ Backtrack:
 	}
 
 	skipped := c.skipStack.pop()
+	if c.trace != nil {
+		c.trace.node(StepBacktrack, skipped.node, host[skipped.charsMatched:])
+	}
 
 	if skipped.childParamIdx < len(skipped.node.params) {
 		matched = skipped.node
@@ -344,6 +395,9 @@ Walk:
 					}
 
 					parent = matched
+					if c.trace != nil {
+						c.trace.node(StepStatic, child, search[keyLen:])
+					}
 					matched = child
 					search = search[keyLen:]
 					charsMatched += keyLen
@@ -352,6 +406,9 @@ Walk:
 
 				// Child key is /foo/, we can fully match /foo prefix and the remaining is exactly "/".
 				if strings.HasPrefix(child.key, search) && child.key[len(search):] == "/" {
+					if c.trace != nil {
+						c.trace.node(StepTrailingSlash, child, search)
+					}
 					if child.isLeaf() {
 						for i, route := range child.routes {
 							if route.handleSlash != StrictSlash && route.match(method, c) {
@@ -400,8 +457,14 @@ Walk:
 
 			segment := search[:end]
 			for i, paramNode := range params {
-				if paramNode.regexp != nil && !paramNode.regexp.MatchString(segment) {
-					continue
+				if paramNode.regexp != nil {
+					ok := paramNode.regexp.MatchString(segment)
+					if c.trace != nil {
+						c.trace.regexp(paramNode, segment, ok)
+					}
+					if !ok {
+						continue
+					}
 				}
 
 				nextChildIx := i + 1
@@ -420,6 +483,9 @@ Walk:
 				}
 
 				parent = matched
+				if c.trace != nil {
+					c.trace.node(StepParam, paramNode, segment)
+				}
 				matched = paramNode
 				search = search[end:]
 				charsMatched += end
@@ -449,6 +515,9 @@ Walk:
 					if idx < 0 {
 						if len(path[searchStart:]) > 0 {
 							if _, child := wildcardNode.getStaticEdge(slashDelim); child != nil && child.isLeaf() && child.key == "/" {
+								if c.trace != nil {
+									c.trace.node(StepTrailingSlash, child, path[searchStart:])
+								}
 								// We have the path /foo/x/y/z for the route /foo/+{any:[A-z]}/ that may be matched with a ts,
 								// but we need to make sure that the regexp match too.
 								if wildcardNode.regexp != nil && !wildcardNode.regexp.MatchString(path[offset:]) {
@@ -481,9 +550,15 @@ Walk:
 						continue
 					}
 
-					if wildcardNode.regexp != nil && !wildcardNode.regexp.MatchString(capturedValue) {
-						searchStart = captureEnd + 1
-						continue
+					if wildcardNode.regexp != nil {
+						ok := wildcardNode.regexp.MatchString(capturedValue)
+						if c.trace != nil {
+							c.trace.regexp(wildcardNode, capturedValue, ok)
+						}
+						if !ok {
+							searchStart = captureEnd + 1
+							continue
+						}
 					}
 
 					// We have a potential match, save backtrack state
@@ -503,6 +578,9 @@ Walk:
 					}
 
 					// Descend into wildcard subtree
+					if c.trace != nil {
+						c.trace.node(StepWildcard, wildcardNode, capturedValue)
+					}
 					parent = matched
 					matched = wildcardNode
 					search = path[captureEnd:]
@@ -534,10 +612,19 @@ Walk:
 					continue // Not a suffix catchall
 				}
 
-				if wildcardNode.regexp != nil && !wildcardNode.regexp.MatchString(search) {
-					continue
+				if wildcardNode.regexp != nil {
+					ok := wildcardNode.regexp.MatchString(search)
+					if c.trace != nil {
+						c.trace.regexp(wildcardNode, search, ok)
+					}
+					if !ok {
+						continue
+					}
 				}
 
+				if c.trace != nil {
+					c.trace.node(StepWildcard, wildcardNode, search)
+				}
 				for i, route := range wildcardNode.routes {
 					if route.match(method, c) {
 						if !lazy {
@@ -581,12 +668,18 @@ Walk:
 	}
 
 	if _, child := matched.getStaticEdge(slashDelim); child != nil && child.isLeaf() && child.key == "/" {
+		if c.trace != nil {
+			c.trace.node(StepTrailingSlash, child, search)
+		}
 		for i, route := range child.routes {
 			if route.handleSlash != StrictSlash && route.match(method, c) {
 				return i, child, true
 			}
 		}
 	} else if matched.key == "/" && parent != nil && parent.isLeaf() && parent.key != "*" {
+		if c.trace != nil {
+			c.trace.node(StepTrailingSlash, parent, search)
+		}
 		for i, route := range parent.routes {
 			if route.handleSlash != StrictSlash && route.match(method, c) {
 				return i, parent, true
@@ -596,6 +689,9 @@ Walk:
 
 Backtrack:
 	if matched.isLeaf() && matched.key != "*" && search == "/" && !strings.HasSuffix(path, "//") {
+		if c.trace != nil {
+			c.trace.node(StepTrailingSlash, matched, search)
+		}
 		for i, route := range matched.routes {
 			if route.handleSlash != StrictSlash && route.match(method, c) {
 				return i, matched, true
@@ -608,6 +704,9 @@ Backtrack:
 	}
 
 	skipped := c.skipStack.pop()
+	if c.trace != nil {
+		c.trace.node(StepBacktrack, skipped.node, path[skipped.charsMatched:])
+	}
 
 	if skipped.childParamIdx < len(skipped.node.params) {
 		matched = skipped.node
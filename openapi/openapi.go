@@ -0,0 +1,506 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+// Package openapi converts between OpenAPI 3 documents and Fox routes. An [Importer] registers a route for every
+// operation of a [Document], turning path templates and parameter schemas into patterns with typed regexp constraints
+// and operationId into route names. Conversely, [Export] emits an OpenAPI skeleton from the registered routes, so
+// documentation and routing stop drifting.
+//
+// Only the subset of the specification relevant to routing is modeled. Fox specific features that OpenAPI cannot
+// express are carried by "x-fox-*" extensions, so a document exported with [Export] can be imported back:
+//   - x-fox-any: an operation describing a method-less route.
+//   - x-fox-hostname: the hostname part of the route pattern.
+//   - x-fox-wildcard: "+" or "*" on a path parameter captured by a catch-all.
+//   - x-fox-annotations: route annotations with a string key.
+package openapi
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/foxconfig"
+)
+
+// Version is the OpenAPI version of exported documents.
+const Version = "3.0.3"
+
+var (
+	ErrInvalidDocument = errors.New("invalid document")
+	ErrMissingHandler  = errors.New("missing handler")
+	ErrConflict        = errors.New("conflicting operation")
+)
+
+// Regular expressions used as constraints for typed path parameters.
+const (
+	exprInteger = `-?[0-9]+`
+	exprNumber  = `-?[0-9]+(?:\.[0-9]+)?`
+	exprBoolean = `(?:true|false)`
+	exprUUID    = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+	exprDate    = `[0-9]{4}-[0-9]{2}-[0-9]{2}`
+)
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Parameters []Parameter `json:"parameters,omitempty"`
+	Get        *Operation  `json:"get,omitempty"`
+	Put        *Operation  `json:"put,omitempty"`
+	Post       *Operation  `json:"post,omitempty"`
+	Delete     *Operation  `json:"delete,omitempty"`
+	Options    *Operation  `json:"options,omitempty"`
+	Head       *Operation  `json:"head,omitempty"`
+	Patch      *Operation  `json:"patch,omitempty"`
+	Trace      *Operation  `json:"trace,omitempty"`
+	// Any describes a method-less route, which responds to any method.
+	Any *Operation `json:"x-fox-any,omitempty"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	Responses   map[string]Response `json:"responses,omitempty"`
+	// Hostname is the hostname part of the route pattern, if any.
+	Hostname string `json:"x-fox-hostname,omitempty"`
+	// Annotations are the route annotations with a string key.
+	Annotations map[string]any `json:"x-fox-annotations,omitempty"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+	// Wildcard is "+" or "*" if the path parameter is captured by a catch-all.
+	Wildcard string `json:"x-fox-wildcard,omitempty"`
+}
+
+// Schema is the subset of a JSON schema used to constrain path parameters.
+type Schema struct {
+	Type    string `json:"type,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Enum    []any  `json:"enum,omitempty"`
+}
+
+// Response describes a single response of an operation.
+type Response struct {
+	Description string `json:"description"`
+}
+
+// OperationError reports the operation of a [Document] that caused an error.
+type OperationError struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+// Error returns a formatted error message.
+func (e *OperationError) Error() string {
+	method := e.Method
+	if method == "" {
+		method = "x-fox-any"
+	}
+	return fmt.Sprintf("%s %s: %s", method, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Decode reads a JSON OpenAPI [Document] from r. Unknown fields are ignored.
+func Decode(r io.Reader) (*Document, error) {
+	doc := new(Document)
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: unsupported openapi version '%s'", ErrInvalidDocument, doc.OpenAPI)
+	}
+	return doc, nil
+}
+
+// Encode writes the [Document] to w as indented JSON.
+func Encode(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Operations returns an iterator over all operations of the path item, in a fixed method order. The method-less
+// operation is yielded last with an empty method.
+func (p *PathItem) Operations() iter.Seq2[string, *Operation] {
+	return func(yield func(string, *Operation) bool) {
+		for _, op := range []struct {
+			method string
+			op     *Operation
+		}{
+			{http.MethodGet, p.Get},
+			{http.MethodPut, p.Put},
+			{http.MethodPost, p.Post},
+			{http.MethodDelete, p.Delete},
+			{http.MethodOptions, p.Options},
+			{http.MethodHead, p.Head},
+			{http.MethodPatch, p.Patch},
+			{http.MethodTrace, p.Trace},
+			{"", p.Any},
+		} {
+			if op.op != nil && !yield(op.method, op.op) {
+				return
+			}
+		}
+	}
+}
+
+// operation returns a pointer to the operation slot for the given method, or nil if the method is not supported
+// by OpenAPI.
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	case "":
+		return &p.Any
+	default:
+		return nil
+	}
+}
+
+// Importer registers routes from an OpenAPI [Document].
+type Importer struct {
+	// Handlers maps operationId to the handler of the operation.
+	Handlers map[string]fox.HandlerFunc
+	// Fallback is the handler used for operations without operationId or without a matching entry in Handlers.
+	// If nil, such operations fail with [ErrMissingHandler].
+	Fallback fox.HandlerFunc
+	// Untyped disables typed constraints. When false, path parameters with an integer, number, boolean, uuid or
+	// date schema, an enum or a pattern are converted into regexp constraints, which require the router to be
+	// created with [fox.AllowRegexpParam].
+	Untyped bool
+}
+
+// Apply registers a route for every operation of the [Document] in the provided transaction. Paths are processed in
+// lexical order and operations in a fixed method order. It stops at the first error, which is wrapped in an
+// [OperationError].
+func (im Importer) Apply(txn *fox.Txn, doc *Document) error {
+	for _, path := range slices.Sorted(maps.Keys(doc.Paths)) {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		for method, op := range item.Operations() {
+			if err := im.add(txn, method, path, item, op); err != nil {
+				return &OperationError{Method: method, Path: path, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// Load registers a route for every operation of the [Document] on the router within a single transaction. If an error
+// occurs, no route is registered. See also [Importer.Apply].
+func (im Importer) Load(f *fox.Router, doc *Document) error {
+	return f.Updates(func(txn *fox.Txn) error {
+		return im.Apply(txn, doc)
+	})
+}
+
+func (im Importer) add(txn *fox.Txn, method, path string, item *PathItem, op *Operation) error {
+	handler := im.Handlers[op.OperationID]
+	if handler == nil {
+		handler = im.Fallback
+	}
+	if handler == nil {
+		return fmt.Errorf("%w: operationId '%s'", ErrMissingHandler, op.OperationID)
+	}
+
+	pattern, err := im.pattern(op.Hostname, path, mergeParameters(item.Parameters, op.Parameters))
+	if err != nil {
+		return err
+	}
+
+	var opts []fox.RouteOption
+	if op.OperationID != "" {
+		opts = append(opts, fox.WithName(op.OperationID))
+	}
+	for k, v := range op.Annotations {
+		opts = append(opts, fox.WithAnnotation(foxconfig.AnnotationKey(k), v))
+	}
+
+	var methods []string
+	if method != "" {
+		methods = []string{method}
+	}
+	_, err = txn.Add(methods, pattern, handler, opts...)
+	return err
+}
+
+// pattern converts an OpenAPI path template into a route pattern.
+func (im Importer) pattern(host, path string, params map[string]Parameter) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("%w: path must start with '/'", ErrInvalidDocument)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(host)
+	for i := 0; i < len(path); {
+		if path[i] != '{' {
+			sb.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(path[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unbalanced braces in path", ErrInvalidDocument)
+		}
+		name := path[i+1 : i+end]
+		i += end + 1
+
+		p := params[name]
+		switch p.Wildcard {
+		case "":
+		case "+", "*":
+			sb.WriteString(p.Wildcard)
+		default:
+			return "", fmt.Errorf("%w: invalid x-fox-wildcard '%s' for parameter '%s'", ErrInvalidDocument, p.Wildcard, name)
+		}
+
+		sb.WriteByte('{')
+		sb.WriteString(name)
+		if !im.Untyped {
+			if expr := constraint(p.Schema); expr != "" {
+				sb.WriteByte(':')
+				sb.WriteString(expr)
+			}
+		}
+		sb.WriteByte('}')
+	}
+	return sb.String(), nil
+}
+
+// mergeParameters returns the path parameters by name. Operation parameters override path item parameters.
+func mergeParameters(item, op []Parameter) map[string]Parameter {
+	params := make(map[string]Parameter)
+	for _, list := range [][]Parameter{item, op} {
+		for _, p := range list {
+			if p.In == "path" {
+				params[p.Name] = p
+			}
+		}
+	}
+	return params
+}
+
+// constraint returns the regular expression equivalent to the schema, or an empty string if the schema does
+// not constrain the parameter.
+func constraint(s *Schema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Pattern != "" {
+		return unanchor(s.Pattern)
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, regexp.QuoteMeta(fmt.Sprint(v)))
+		}
+		return "(?:" + strings.Join(values, "|") + ")"
+	}
+	switch s.Type {
+	case "integer":
+		return exprInteger
+	case "number":
+		return exprNumber
+	case "boolean":
+		return exprBoolean
+	case "string":
+		switch s.Format {
+		case "uuid":
+			return exprUUID
+		case "date":
+			return exprDate
+		}
+	}
+	return ""
+}
+
+// schema returns the schema equivalent to the regular expression constraint of a path parameter.
+func schema(expr string) *Schema {
+	switch expr {
+	case "":
+		return &Schema{Type: "string"}
+	case exprInteger:
+		return &Schema{Type: "integer"}
+	case exprNumber:
+		return &Schema{Type: "number"}
+	case exprBoolean:
+		return &Schema{Type: "boolean"}
+	case exprUUID:
+		return &Schema{Type: "string", Format: "uuid"}
+	case exprDate:
+		return &Schema{Type: "string", Format: "date"}
+	default:
+		return &Schema{Type: "string", Pattern: "^" + expr + "$"}
+	}
+}
+
+// unanchor strips the leading "^" and trailing "$" of a schema pattern, since route constraints are always anchored.
+func unanchor(expr string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(expr, "^"), "$")
+}
+
+// Export returns an OpenAPI [Document] describing all routes of the provided [fox.Iter]. Route names become
+// operationId, and path parameters are described with a schema derived from their regexp constraint. Matchers cannot
+// be expressed in OpenAPI: when several routes with the same pattern share a method, only the first one in
+// [fox.Iter.All] order is exported. Routes registered for methods not supported by OpenAPI are skipped. OpenAPI paths
+// are keyed by path only, so if routes with different patterns, such as the same path on different hostnames, map to
+// the same path and method, Export returns an error that is [ErrConflict].
+func Export(it fox.Iter, info Info) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	owners := make(map[*Operation]*fox.Route)
+
+	for route := range it.All() {
+		path, params := convertPath(route.Path())
+		op := &Operation{
+			OperationID: route.Name(),
+			Parameters:  params,
+			Responses:   map[string]Response{"default": {Description: "Default response"}},
+			Hostname:    route.Hostname(),
+		}
+		for k, v := range route.Annotations() {
+			if key := reflect.ValueOf(k); key.Kind() == reflect.String {
+				if op.Annotations == nil {
+					op.Annotations = make(map[string]any)
+				}
+				op.Annotations[key.String()] = v
+			}
+		}
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = new(PathItem)
+			doc.Paths[path] = item
+		}
+
+		methods := slices.Collect(route.Methods())
+		if len(methods) == 0 {
+			methods = []string{""}
+		}
+		for _, method := range methods {
+			slot := item.operation(method)
+			if slot == nil {
+				continue
+			}
+			if *slot == nil {
+				*slot = op
+				owners[op] = route
+				continue
+			}
+			if owner := owners[*slot]; owner.Pattern() != route.Pattern() {
+				return nil, fmt.Errorf("%w: %s %s and %s map to the same operation", ErrConflict, cmp.Or(method, "*"), owner.Pattern(), route.Pattern())
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+// convertPath converts the path of a route pattern into an OpenAPI path template, and returns the description
+// of its parameters.
+func convertPath(path string) (string, []Parameter) {
+	var sb strings.Builder
+	var params []Parameter
+	for i := 0; i < len(path); {
+		wildcard := ""
+		if (path[i] == '*' || path[i] == '+') && i+1 < len(path) && path[i+1] == '{' {
+			wildcard = string(path[i])
+			i++
+		}
+		if path[i] != '{' {
+			sb.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		// Find the end of the parameter, skipping over any brace in the regular expression.
+		depth, end, colon := 0, i, -1
+		for ; end < len(path); end++ {
+			switch path[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			case ':':
+				if colon < 0 && depth == 1 {
+					colon = end
+				}
+			}
+			if depth == 0 {
+				break
+			}
+		}
+
+		name, expr := path[i+1:end], ""
+		if colon > 0 {
+			name, expr = path[i+1:colon], path[colon+1:end]
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema(expr),
+			Wildcard: wildcard,
+		})
+		sb.WriteString("{" + name + "}")
+		i = end + 1
+	}
+	return sb.String(), params
+}
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fox-toolkit/fox"
+	"github.com/fox-toolkit/fox/foxconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "users", "version": "1.0.0"},
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}}],
+      "get": {"operationId": "getUser", "responses": {"200": {"description": "ok"}}},
+      "delete": {"operationId": "deleteUser", "responses": {"204": {"description": "deleted"}}}
+    },
+    "/users/{id}/status/{status}": {
+      "put": {
+        "operationId": "setStatus",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}},
+          {"name": "status", "in": "path", "required": true, "schema": {"type": "string", "enum": ["active", "in.active"]}}
+        ],
+        "responses": {"204": {"description": "ok"}}
+      }
+    },
+    "/files/{path}": {
+      "x-fox-any": {
+        "parameters": [{"name": "path", "in": "path", "required": true, "x-fox-wildcard": "+"}],
+        "x-fox-hostname": "static.example.com",
+        "x-fox-annotations": {"team": "storage"}
+      }
+    }
+  }
+}`
+
+func TestImporter_Load(t *testing.T) {
+	doc, err := Decode(strings.NewReader(spec))
+	require.NoError(t, err)
+
+	f := fox.MustRouter(fox.AllowRegexpParam(true))
+	im := Importer{
+		Handlers: map[string]fox.HandlerFunc{
+			"getUser": func(c *fox.Context) {
+				_ = c.String(http.StatusOK, c.Param("id"))
+			},
+		},
+		Fallback: func(c *fox.Context) {
+			c.Writer().WriteHeader(http.StatusNotImplemented)
+		},
+	}
+	require.NoError(t, im.Load(f, doc))
+	assert.Equal(t, 4, f.Len())
+
+	assert.True(t, f.Has(fox.MethodGet, "/users/{id:-?[0-9]+}"))
+	assert.True(t, f.Has(fox.MethodDelete, "/users/{id:-?[0-9]+}"))
+	assert.True(t, f.Has([]string{http.MethodPut}, `/users/{id:`+exprUUID+`}/status/{status:(?:active|in\.active)}`))
+	route := f.Route(fox.MethodAny, "static.example.com/files/+{path}")
+	require.NotNil(t, route)
+	assert.Equal(t, "storage", route.Annotation(foxconfig.AnnotationKey("team")))
+	assert.Equal(t, "/users/{id:-?[0-9]+}", f.Name("getUser").Pattern())
+
+	cases := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "/users/42", http.StatusOK},
+		{http.MethodGet, "/users/abc", http.StatusNotFound},
+		{http.MethodDelete, "/users/42", http.StatusNotImplemented},
+		{http.MethodPut, "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8/status/active", http.StatusNotImplemented},
+		{http.MethodPut, "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8/status/inXactive", http.StatusNotFound},
+		{http.MethodPost, "http://static.example.com/files/a/b", http.StatusNotImplemented},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, tc.want, w.Code, "%s %s", tc.method, tc.target)
+	}
+}
+
+func TestImporter_Untyped(t *testing.T) {
+	doc, err := Decode(strings.NewReader(spec))
+	require.NoError(t, err)
+
+	f := fox.MustRouter()
+	require.NoError(t, Importer{Fallback: func(c *fox.Context) {}, Untyped: true}.Load(f, doc))
+	assert.True(t, f.Has(fox.MethodGet, "/users/{id}"))
+	assert.True(t, f.Has([]string{http.MethodPut}, "/users/{id}/status/{status}"))
+}
+
+func TestImporter_Error(t *testing.T) {
+	doc, err := Decode(strings.NewReader(spec))
+	require.NoError(t, err)
+
+	f := fox.MustRouter(fox.AllowRegexpParam(true))
+	err = Importer{}.Load(f, doc)
+	assert.ErrorIs(t, err, ErrMissingHandler)
+	var opErr *OperationError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, "/files/{path}", opErr.Path)
+	assert.Equal(t, 0, f.Len())
+
+	f = fox.MustRouter()
+	err = Importer{Fallback: func(c *fox.Context) {}}.Load(f, doc)
+	assert.ErrorIs(t, err, fox.ErrRegexpNotAllowed)
+
+	_, err = Decode(strings.NewReader(`{"openapi": "2.0"}`))
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+}
+
+func TestExport(t *testing.T) {
+	doc, err := Decode(strings.NewReader(spec))
+	require.NoError(t, err)
+
+	f := fox.MustRouter(fox.AllowRegexpParam(true))
+	require.NoError(t, Importer{Fallback: func(c *fox.Context) {}}.Load(f, doc))
+	f.MustAdd(fox.MethodGet, "/reports/{year:[0-9]{4}}", func(c *fox.Context) {})
+
+	exported, err := Export(f.Iter(), Info{Title: "users", Version: "2.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, Version, exported.OpenAPI)
+	require.Len(t, exported.Paths, 4)
+
+	users := exported.Paths["/users/{id}"]
+	require.NotNil(t, users)
+	require.NotNil(t, users.Get)
+	assert.Equal(t, "getUser", users.Get.OperationID)
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer"}}}, users.Get.Parameters)
+	assert.NotNil(t, users.Delete)
+
+	status := exported.Paths["/users/{id}/status/{status}"]
+	require.NotNil(t, status)
+	require.NotNil(t, status.Put)
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, status.Put.Parameters[0].Schema)
+	assert.Equal(t, &Schema{Type: "string", Pattern: `^(?:active|in\.active)$`}, status.Put.Parameters[1].Schema)
+
+	files := exported.Paths["/files/{path}"]
+	require.NotNil(t, files)
+	require.NotNil(t, files.Any)
+	assert.Equal(t, "static.example.com", files.Any.Hostname)
+	assert.Equal(t, "+", files.Any.Parameters[0].Wildcard)
+	assert.Equal(t, map[string]any{"team": "storage"}, files.Any.Annotations)
+
+	reports := exported.Paths["/reports/{year}"]
+	require.NotNil(t, reports)
+	assert.Equal(t, &Schema{Type: "string", Pattern: "^[0-9]{4}$"}, reports.Get.Parameters[0].Schema)
+
+	// Round trip
+	buf := new(bytes.Buffer)
+	require.NoError(t, Encode(buf, exported))
+	decoded, err := Decode(buf)
+	require.NoError(t, err)
+
+	f2 := fox.MustRouter(fox.AllowRegexpParam(true))
+	require.NoError(t, Importer{Fallback: func(c *fox.Context) {}}.Load(f2, decoded))
+	for route := range f.Iter().All() {
+		assert.True(t, f2.Has(slices.Collect(route.Methods()), route.Pattern()), route.Pattern())
+	}
+}
+
+func TestExportConflict(t *testing.T) {
+	f := fox.MustRouter()
+	f.MustAdd(fox.MethodGet, "a.example.com/x", func(c *fox.Context) {})
+	f.MustAdd(fox.MethodGet, "b.example.com/x", func(c *fox.Context) {})
+
+	_, err := Export(f.Iter(), Info{Title: "hosts", Version: "1.0.0"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Routes that only differ by their matchers share the same operation.
+	f = fox.MustRouter()
+	f.MustAdd(fox.MethodGet, "/x", func(c *fox.Context) {})
+	f.MustAdd(fox.MethodGet, "/x", func(c *fox.Context) {}, fox.WithQueryMatcher("v", "1"))
+
+	doc, err := Export(f.Iter(), Info{Title: "matchers", Version: "1.0.0"})
+	require.NoError(t, err)
+	require.NotNil(t, doc.Paths["/x"])
+	assert.NotNil(t, doc.Paths["/x"].Get)
+}
@@ -7,7 +7,9 @@ package fox
 import (
 	"cmp"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"time"
 
 	"github.com/fox-toolkit/fox/internal/slogpretty"
 )
@@ -141,6 +143,90 @@ func WithHandleTrailingSlash(opt TrailingSlashOption) interface {
 	})
 }
 
+// WithErrorHandler register an [ErrorHandlerFunc] which is called with the non-nil error returned by a [HandlerFuncE]
+// registered with [HandleE]. By default, the [DefaultErrorHandler] is used.
+//
+// This option can be applied on a per-route basis or globally:
+//   - If applied globally, it affects all routes by default.
+//   - If applied to a specific route, it will override the global setting for that route.
+func WithErrorHandler(handler ErrorHandlerFunc) interface {
+	GlobalOption
+	RouteOption
+} {
+	return optionFunc(func(s sealedOption) error {
+		if handler == nil {
+			return fmt.Errorf("%w: error handler cannot be nil", ErrInvalidConfig)
+		}
+		if s.router != nil {
+			s.router.errorHandler = handler
+			return nil
+		}
+		if s.route != nil {
+			s.route.errorHandler = handler
+		}
+		return nil
+	})
+}
+
+// WithProblemDetails enable RFC 9457 problem details for the responses generated by the router: 404 from
+// [DefaultNotFoundHandler], 405 from [DefaultMethodNotAllowedHandler], trailing slash and path redirects, 500 from
+// [Recovery] and errors handled by [DefaultErrorHandler], including invalid params and client IP. These responses
+// are written as "application/problem+json" documents, unless the request Accept header prefers plain text or does
+// not accept JSON. See also [WithProblemFunc].
+//
+// This option can be applied on a per-route basis or globally:
+//   - If applied globally, it affects all routes by default.
+//   - If applied to a specific route, it will override the global setting for that route.
+//
+// As no route matches them, the 404 and 405 responses use the global setting. To customize a sub-tree, apply this
+// option to a fallback serving it with [DefaultFallbackHandler], or to the [Router] mounted with [Sub].
+func WithProblemDetails(enable bool) interface {
+	GlobalOption
+	RouteOption
+} {
+	return optionFunc(func(s sealedOption) error {
+		if s.router != nil {
+			s.router.problems = enable
+			return nil
+		}
+		if s.route != nil {
+			s.route.problems = enable
+		}
+		return nil
+	})
+}
+
+// WithProblemFunc register a [ProblemFunc] which is called to customize every [Problem] before it is written, e.g.
+// to set a problem type URI or add extension members. Note that this option automatically enable
+// [WithProblemDetails].
+//
+// This option can be applied on a per-route basis or globally:
+//   - If applied globally, it affects all routes by default.
+//   - If applied to a specific route, it will override the global setting for that route.
+//
+// As no route matches them, the 404 and 405 responses use the global setting. To customize a sub-tree, apply this
+// option to a fallback serving it with [DefaultFallbackHandler], or to the [Router] mounted with [Sub].
+func WithProblemFunc(fn ProblemFunc) interface {
+	GlobalOption
+	RouteOption
+} {
+	return optionFunc(func(s sealedOption) error {
+		if fn == nil {
+			return fmt.Errorf("%w: problem func cannot be nil", ErrInvalidConfig)
+		}
+		if s.router != nil {
+			s.router.problemFunc = fn
+			s.router.problems = true
+			return nil
+		}
+		if s.route != nil {
+			s.route.problemFunc = fn
+			s.route.problems = true
+		}
+		return nil
+	})
+}
+
 // WithHandleFixedPath configures how the router handles non-canonical request paths containing
 // extraneous elements like double slashes, dots, or parent directory references.
 //
@@ -163,6 +249,86 @@ func WithHandleFixedPath(opt FixedPathOption) GlobalOption {
 	})
 }
 
+// WithCommitHook register a hook which is called with a read-only view of the pending tree, every time a read-write
+// transaction is committed, either explicitly with [Txn.Commit] or implicitly through [Router.Updates] or any managed
+// mutation such as [Router.Add]. Hooks run in the order they are registered and have full [Txn.Iter] access. If a hook
+// returns an error, the transaction is aborted and the error is returned to the caller. The provided [Txn] must not be
+// retained after the hook returns. This option can be applied multiple times.
+func WithCommitHook(fn func(txn *Txn) error) GlobalOption {
+	return optionFunc(func(s sealedOption) error {
+		if fn == nil {
+			return fmt.Errorf("%w: commit hook cannot be nil", ErrInvalidConfig)
+		}
+		s.router.commitHooks = append(s.router.commitHooks, fn)
+		return nil
+	})
+}
+
+// WithInflightTracking enable per-route in-flight request tracking. A tracked route counts the requests it is currently
+// handling, which allows to wait with [Router.Drain] until a removed or replaced route has no active request left.
+// Tracking adds a small overhead on every request, so it is disabled by default. Tracking cannot be disabled for a
+// route with retire callbacks, since they only fire for tracked routes. See also [WithOnRetire].
+//
+// This option can be applied on a per-route basis or globally:
+//   - If applied globally, it affects all routes by default.
+//   - If applied to a specific route, it will override the global setting for that route.
+func WithInflightTracking(enable bool) interface {
+	GlobalOption
+	RouteOption
+} {
+	return optionFunc(func(s sealedOption) error {
+		if s.router != nil {
+			s.router.tracking = enable
+			return nil
+		}
+		if s.route != nil {
+			if !enable {
+				s.route.lc = nil
+			} else if s.route.lc == nil {
+				s.route.lc = newLifecycle()
+			}
+		}
+		return nil
+	})
+}
+
+// WithOnRetire register a callback which is called once a route has been removed or replaced in the routing tree
+// (e.g. with [Router.Delete], [Router.Update] or [Txn.Truncate]) and has no in-flight request left. This is typically
+// used to release resources owned by the old handler, such as proxy transports or connection pools. The callback runs
+// in its own goroutine. This option implicitly enables [WithInflightTracking], which stays enabled for the route even
+// if tracking is disabled with [WithInflightTracking].
+//
+// A route is retired only once every request served by the router before the commit that removed it has completed,
+// including requests that matched the route but have not yet reached its handler. As a consequence, a long-lived
+// request, such as a streaming response, delays the retirement of all routes removed while it is in-flight. Routes
+// obtained with [Router.Lookup] or [Router.Match] and called manually are not accounted for.
+//
+// This option can be applied on a per-route basis or globally:
+//   - If applied globally, the callback is called for all routes.
+//   - If applied to a specific route, the callback only applies to that route and is called after any global callbacks.
+func WithOnRetire(fn func(route *Route)) interface {
+	GlobalOption
+	RouteOption
+} {
+	return optionFunc(func(s sealedOption) error {
+		if fn == nil {
+			return fmt.Errorf("%w: retire callback cannot be nil", ErrInvalidConfig)
+		}
+		if s.router != nil {
+			s.router.onRetire = append(s.router.onRetire, fn)
+			s.router.tracking = true
+			return nil
+		}
+		if s.route != nil {
+			s.route.onRetire = append(s.route.onRetire, fn)
+			if s.route.lc == nil {
+				s.route.lc = newLifecycle()
+			}
+		}
+		return nil
+	})
+}
+
 // WithMaxRouteParams set the maximum number of parameters allowed in a route. The default max is math.MaxUint8.
 // Routes exceeding this limit will fail with an error that is ErrInvalidRoute and ErrTooManyParams.
 func WithMaxRouteParams(max int) GlobalOption {
@@ -237,8 +403,8 @@ func WithMiddleware(m ...MiddlewareFunc) interface {
 // WithMiddlewareFor attaches middleware to the router for a specified scope. Middlewares provided will be chained
 // in the order they were added. The scope parameter determines which types of handlers the middleware will be applied to.
 // Possible scopes include [RouteHandler] (regular routes), [NoRouteHandler], [NoMethodHandler], [RedirectSlashHandler],
-// [RedirectPathHandler], [OptionsHandler], and any combination of these. Use this option when you need fine-grained control
-// over where the middleware is applied.
+// [RedirectPathHandler], [OptionsHandler], [PreRoutingHandler], and any combination of these. Use this option when you
+// need fine-grained control over where the middleware is applied.
 func WithMiddlewareFor(scope HandlerScope, m ...MiddlewareFunc) GlobalOption {
 	return optionFunc(func(s sealedOption) error {
 		for i := range m {
@@ -251,6 +417,18 @@ func WithMiddlewareFor(scope HandlerScope, m ...MiddlewareFunc) GlobalOption {
 	})
 }
 
+// WithPreRouting attaches middleware that runs for every request before the route lookup, in the order they are added.
+// They may rewrite the method, host and path used for matching by replacing the request with [Context.SetRequest]
+// before calling the next handler, e.g. to strip a prefix, resolve host aliases, apply a method override header or
+// normalize the path. At this stage, [Context.Route] returns nil and [Context.Scope] returns [PreRoutingHandler].
+// A pre-routing middleware may also write the response without calling the next handler. Pre-routing middleware
+// are not applied again to requests rerouted with [Context.Reroute], nor when the router is mounted with [Sub], nor
+// by [Router.Explain]. This is a shorthand for [WithMiddlewareFor] with the [PreRoutingHandler] scope. When no
+// pre-routing middleware is registered, requests are dispatched without any overhead.
+func WithPreRouting(m ...MiddlewareFunc) GlobalOption {
+	return WithMiddlewareFor(PreRoutingHandler, m...)
+}
+
 // WithNoMethod enable to returns 405 Method Not Allowed instead of 404 Not Found
 // when the route exist for another http verb. The "Allow" header it automatically set before calling the
 // handler. Note that this option is automatically enabled when providing a custom handler with the
@@ -274,6 +452,33 @@ func WithAutoOptions(enable bool) GlobalOption {
 	})
 }
 
+// WithAutoHEAD enables automatic handling of HEAD requests. When no HEAD route matches the request, the router serves
+// the matching GET route with a [ResponseWriter] that discards the body, but keeps the headers and sets the
+// Content-Length of the discarded body if the handler did not. HEAD is also included in the "Allow" header of the
+// automatic OPTIONS and 405 Method Not Allowed responses for GET routes. Routes registered explicitly for HEAD take
+// priority. This option is disabled by default.
+func WithAutoHEAD(enable bool) GlobalOption {
+	return optionFunc(func(s sealedOption) error {
+		s.router.autoHEAD = enable
+		return nil
+	})
+}
+
+// WithRequestLogger configures the logger returned by [Context.Logger]. Records are written to the provided
+// [slog.Handler], and attrs, if not nil, is called when the logger is created to add attributes to the built-in
+// ones, such as a tenant or a trace identifier. The [Logger] and [Recovery] middleware created with a nil handler
+// use this logger as well. By default, [Context.Logger] uses the handler of [slog.Default].
+func WithRequestLogger(handler slog.Handler, attrs func(c *Context) []slog.Attr) GlobalOption {
+	return optionFunc(func(s sealedOption) error {
+		if handler == nil {
+			return fmt.Errorf("%w: request logger handler cannot be nil", ErrInvalidConfig)
+		}
+		s.router.reqLogHandler = handler
+		s.router.reqLogAttrs = attrs
+		return nil
+	})
+}
+
 // WithSystemWideOptions enable automatic response for system-wide OPTIONS request (OPTIONS *). When this option is enabled,
 // the router responds with a 200 OK status code and the "Allow" header listing all HTTP methods used across registered routes.
 // Note that to let Fox handle system-wide OPTIONS requests, http.Server.DisableGeneralOptionsHandler must be set to true.
@@ -346,6 +551,31 @@ func WithName(name string) RouteOption {
 	})
 }
 
+// WithTTL sets a time-to-live on a route, counted from the moment the route is created. Once expired, the route
+// stops matching requests immediately and is garbage-collected from the routing tree shortly after by a background
+// write transaction. The duration must be greater than zero. See also [WithExpiry].
+func WithTTL(d time.Duration) RouteOption {
+	return optionFunc(func(s sealedOption) error {
+		if d <= 0 {
+			return fmt.Errorf("%w: ttl must be greater than zero", ErrInvalidConfig)
+		}
+		s.route.expiry = time.Now().Add(d).UnixNano()
+		return nil
+	})
+}
+
+// WithExpiry sets the time at which a route expires. Once expired, the route stops matching requests immediately
+// and is garbage-collected from the routing tree shortly after by a background write transaction. See also [WithTTL].
+func WithExpiry(t time.Time) RouteOption {
+	return optionFunc(func(s sealedOption) error {
+		if t.IsZero() {
+			return fmt.Errorf("%w: zero expiry time", ErrInvalidConfig)
+		}
+		s.route.expiry = t.UnixNano()
+		return nil
+	})
+}
+
 // WithMatcherPriority sets the priority for a route with matchers. When multiple routes share the same pattern
 // (regardless of param names) and have overlapping methods, matchers are evaluated by priority (highest first).
 // Routes with equal priority may be evaluated in any order. Routes without matchers are always evaluated last.
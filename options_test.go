@@ -323,6 +323,331 @@ func TestWithScopedMiddleware(t *testing.T) {
 	assert.True(t, called)
 }
 
+func TestWithPreRouting(t *testing.T) {
+	stripPrefix := MiddlewareFunc(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			assert.Equal(t, PreRoutingHandler, c.Scope())
+			assert.Nil(t, c.Route())
+			assert.Empty(t, c.Pattern())
+			req := c.Request()
+			if path, ok := strings.CutPrefix(req.URL.Path, "/prefix"); ok {
+				req = req.Clone(req.Context())
+				req.URL.Path = path
+				req.URL.RawPath = ""
+				c.SetRequest(req)
+			}
+			next(c)
+		}
+	})
+	methodOverride := MiddlewareFunc(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if m := c.Header("X-HTTP-Method-Override"); m != "" && c.Method() == http.MethodPost {
+				req := c.Request().Clone(c.Request().Context())
+				req.Method = m
+				c.SetRequest(req)
+			}
+			next(c)
+		}
+	})
+	hostAlias := MiddlewareFunc(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.Host() == "alias.com" {
+				req := c.Request().Clone(c.Request().Context())
+				req.Host = "example.com"
+				c.SetRequest(req)
+			}
+			next(c)
+		}
+	})
+	reject := MiddlewareFunc(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.QueryParam("reject") != "" {
+				c.Writer().WriteHeader(http.StatusForbidden)
+				return
+			}
+			next(c)
+		}
+	})
+
+	f := MustRouter(
+		WithPreRouting(stripPrefix, methodOverride),
+		WithMiddlewareFor(PreRoutingHandler, hostAlias, reject),
+		WithMiddleware(func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				assert.NotEqual(t, PreRoutingHandler, c.Scope())
+				next(c)
+			}
+		}),
+	)
+	handler := func(c *Context) {
+		_ = c.String(http.StatusOK, c.Method()+" "+c.Pattern()+" "+c.Param("id"))
+	}
+	f.MustAdd(MethodGet, "/users/{id}", handler)
+	f.MustAdd([]string{http.MethodDelete}, "/users/{id}", handler)
+	f.MustAdd(MethodGet, "example.com/hosts/{id}", handler)
+
+	cases := []struct {
+		name   string
+		req    func() *http.Request
+		want   string
+		status int
+	}{
+		{
+			name:   "unchanged request",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/users/1", nil) },
+			want:   "GET /users/{id} 1",
+			status: http.StatusOK,
+		},
+		{
+			name:   "strip prefix",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/prefix/users/2", nil) },
+			want:   "GET /users/{id} 2",
+			status: http.StatusOK,
+		},
+		{
+			name: "method override",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/prefix/users/3", nil)
+				req.Header.Set("X-HTTP-Method-Override", http.MethodDelete)
+				return req
+			},
+			want:   "DELETE /users/{id} 3",
+			status: http.StatusOK,
+		},
+		{
+			name: "host alias",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/hosts/4", nil)
+				req.Host = "alias.com"
+				return req
+			},
+			want:   "GET example.com/hosts/{id} 4",
+			status: http.StatusOK,
+		},
+		{
+			name:   "short circuit",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/users/5?reject=true", nil) },
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "not found after rewrite",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/prefix/foo", nil) },
+			want:   "404 page not found\n",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, tc.req())
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestWithPreRoutingUnused(t *testing.T) {
+	f := MustRouter(WithMiddleware(Logger(slog.DiscardHandler)), WithMiddlewareFor(AllHandlers, Recovery(slog.DiscardHandler)))
+	assert.Nil(t, f.preRouting)
+	f = MustRouter(WithPreRouting())
+	assert.Nil(t, f.preRouting)
+	_, err := NewRouter(WithPreRouting(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestWithAutoHEAD(t *testing.T) {
+	var status int
+	f := MustRouter(
+		WithAutoHEAD(true),
+		WithNoMethod(true),
+		WithAutoOptions(true),
+		WithMiddleware(func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				next(c)
+				status = c.Writer().Status()
+			}
+		}),
+	)
+	f.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		c.SetHeader("X-Pattern", c.Pattern())
+		_ = c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	f.MustAdd(MethodGet, "/length", func(c *Context) {
+		c.SetHeader(HeaderContentLength, "42")
+		_, _ = c.Writer().Write([]byte("foo"))
+	})
+	f.MustAdd(MethodGet, "/stream", func(c *Context) {
+		_, _ = c.Writer().WriteString("foo")
+		_ = c.Writer().FlushError()
+		_, _ = c.Writer().ReadFrom(strings.NewReader("bar"))
+	})
+	f.MustAdd(MethodGet, "/nocontent", func(c *Context) {
+		c.Writer().WriteHeader(http.StatusNoContent)
+		_, _ = c.Writer().Write([]byte("foo"))
+	})
+	f.MustAdd(MethodGet, "/empty", emptyHandler)
+	f.MustAdd(MethodGet, "/explicit", func(c *Context) {
+		_ = c.String(http.StatusOK, "get")
+	})
+	f.MustAdd([]string{http.MethodHead}, "/explicit", func(c *Context) {
+		c.SetHeader("X-Handler", "head")
+	})
+	f.MustAdd(MethodPost, "/posts", emptyHandler)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		status int
+		header http.Header
+		body   string
+	}{
+		{
+			name:   "head served by get route",
+			method: http.MethodHead,
+			target: "/users/42",
+			status: http.StatusOK,
+			header: http.Header{
+				HeaderContentLength: {"7"},
+				HeaderContentType:   {MIMETextPlainCharsetUTF8},
+				"X-Pattern":         {"/users/{id}"},
+			},
+		},
+		{
+			name:   "get is unchanged",
+			method: http.MethodGet,
+			target: "/users/42",
+			status: http.StatusOK,
+			header: http.Header{
+				HeaderContentType: {MIMETextPlainCharsetUTF8},
+				"X-Pattern":       {"/users/{id}"},
+			},
+			body: "user 42",
+		},
+		{
+			name:   "content length set by handler",
+			method: http.MethodHead,
+			target: "/length",
+			status: http.StatusOK,
+			header: http.Header{HeaderContentLength: {"42"}},
+		},
+		{
+			name:   "flushed response has no content length",
+			method: http.MethodHead,
+			target: "/stream",
+			status: http.StatusOK,
+			header: http.Header{},
+		},
+		{
+			name:   "no content length for 204",
+			method: http.MethodHead,
+			target: "/nocontent",
+			status: http.StatusNoContent,
+			header: http.Header{},
+		},
+		{
+			name:   "empty response",
+			method: http.MethodHead,
+			target: "/empty",
+			status: http.StatusOK,
+			header: http.Header{},
+		},
+		{
+			name:   "explicit head route",
+			method: http.MethodHead,
+			target: "/explicit",
+			status: http.StatusOK,
+			header: http.Header{"X-Handler": {"head"}},
+		},
+		{
+			name:   "method not allowed includes head",
+			method: http.MethodPost,
+			target: "/users/42",
+			status: http.StatusMethodNotAllowed,
+			header: http.Header{
+				HeaderAllow:               {"GET, HEAD, OPTIONS"},
+				HeaderContentType:         {MIMETextPlainCharsetUTF8},
+				HeaderXContentTypeOptions: {"nosniff"},
+			},
+			body: "Method Not Allowed\n",
+		},
+		{
+			name:   "head not allowed for post route",
+			method: http.MethodHead,
+			target: "/posts",
+			status: http.StatusMethodNotAllowed,
+			header: http.Header{
+				HeaderAllow:               {"OPTIONS, POST"},
+				HeaderContentType:         {MIMETextPlainCharsetUTF8},
+				HeaderXContentTypeOptions: {"nosniff"},
+			},
+			body: "Method Not Allowed\n",
+		},
+		{
+			name:   "options includes head",
+			method: http.MethodOptions,
+			target: "/users/42",
+			status: http.StatusNoContent,
+			header: http.Header{HeaderAllow: {"GET, HEAD, OPTIONS"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.status, status)
+			allow := strings.Split(w.Header().Get(HeaderAllow), ", ")
+			slices.Sort(allow)
+			if tc.header.Get(HeaderAllow) != "" {
+				w.Header().Set(HeaderAllow, strings.Join(allow, ", "))
+			}
+			assert.Equal(t, tc.header, w.Header())
+			assert.Equal(t, tc.body, w.Body.String())
+		})
+	}
+
+	t.Run("system-wide options includes head", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "*", nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		allow := strings.Split(w.Header().Get(HeaderAllow), ", ")
+		assert.ElementsMatch(t, []string{http.MethodGet, http.MethodPost, http.MethodHead, http.MethodOptions}, allow)
+	})
+}
+
+func TestWithAutoHEADDisabled(t *testing.T) {
+	f := MustRouter(WithNoMethod(true))
+	f.MustAdd(MethodGet, "/users/{id}", emptyHandler)
+	assert.False(t, f.RouterInfo().AutoHEAD)
+
+	req := httptest.NewRequest(http.MethodHead, "/users/42", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodGet, w.Header().Get(HeaderAllow))
+}
+
+func TestWithAutoHEADSubRouter(t *testing.T) {
+	sub := MustRouter(WithAutoHEAD(true))
+	sub.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		_ = c.String(http.StatusOK, c.Param("org")+"/"+c.Param("id"))
+	})
+
+	f := MustRouter(WithAutoHEAD(true))
+	f.MustAdd(MethodAny, "/orgs/{org}/*{any}", Sub(sub))
+
+	req := httptest.NewRequest(http.MethodHead, "/orgs/fox/users/42", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "6", w.Header().Get(HeaderContentLength))
+	assert.Empty(t, w.Body.String())
+}
+
 func TestInvalidMiddleware(t *testing.T) {
 	_, err := NewRouter(WithMiddleware(Logger(slog.DiscardHandler), nil))
 	assert.ErrorIs(t, err, ErrInvalidConfig)
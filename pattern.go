@@ -0,0 +1,161 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"cmp"
+	"math"
+	"strings"
+)
+
+// SegmentKind is the kind of a [Segment].
+type SegmentKind uint8
+
+const (
+	// SegmentStatic is a literal segment (e.g. "/users/").
+	SegmentStatic SegmentKind = iota
+	// SegmentParam is a named parameter (e.g. "{id}" or "{id:[0-9]+}"), which match a single hostname label or path
+	// segment.
+	SegmentParam
+	// SegmentWildcard is a named catch-all parameter (e.g. "+{path}"), which match one or more hostname labels or path
+	// segments.
+	SegmentWildcard
+	// SegmentOptionalWildcard is a named optional catch-all parameter (e.g. "*{path}"), which match zero or more path
+	// segments. It is only allowed as a suffix.
+	SegmentOptionalWildcard
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentStatic:
+		return "static"
+	case SegmentParam:
+		return "param"
+	case SegmentWildcard:
+		return "wildcard"
+	case SegmentOptionalWildcard:
+		return "optional wildcard"
+	default:
+		return "unknown"
+	}
+}
+
+// Segment is a part of a parsed route [Pattern].
+type Segment struct {
+	// Value is the literal text of a static segment, or the name of a param or wildcard.
+	Value string
+	// Regexp is the regular expression constraint of a param or wildcard, as written in the pattern, or an
+	// empty string if none.
+	Regexp string
+	// Offset is the byte offset of the segment in the pattern.
+	Offset int
+	// End is the byte offset following the segment in the pattern.
+	End int
+	// Kind is the kind of segment.
+	Kind SegmentKind
+}
+
+// Pattern is a parsed route pattern, as returned by [ParsePattern].
+type Pattern struct {
+	// Raw is the pattern as provided to [ParsePattern].
+	Raw string
+	// Host holds the hostname segments, in order. It is empty if the pattern has no hostname.
+	Host []Segment
+	// Path holds the path segments, in order.
+	Path []Segment
+}
+
+// Hostname returns the hostname part of the pattern, or an empty string if the pattern has no hostname.
+func (p *Pattern) Hostname() string {
+	if len(p.Host) == 0 {
+		return ""
+	}
+	return p.Raw[:p.Host[len(p.Host)-1].End]
+}
+
+// Params returns the names of all params and wildcards of the pattern, in order.
+func (p *Pattern) Params() []string {
+	var params []string
+	for _, segments := range [][]Segment{p.Host, p.Path} {
+		for _, seg := range segments {
+			if seg.Kind != SegmentStatic {
+				params = append(params, seg.Value)
+			}
+		}
+	}
+	return params
+}
+
+func (p *Pattern) String() string {
+	return p.Raw
+}
+
+// ParseOptions configures [ParsePattern]. The zero value applies the same defaults as [NewRouter].
+type ParseOptions struct {
+	// MaxParams is the maximum number of params and wildcards allowed in a pattern. See [WithMaxRouteParams].
+	MaxParams int
+	// MaxParamKeyBytes is the maximum number of bytes allowed per param name. See [WithMaxRouteParamKeyBytes].
+	MaxParamKeyBytes int
+	// AllowRegexp enables regular expression constraints on params and wildcards. See [AllowRegexpParam].
+	AllowRegexp bool
+}
+
+// ParsePattern parses and validates a route pattern with the exact same grammar and rules as [Router.Add], and returns
+// its hostname and path segments. If the pattern is invalid, the returned error is a [PatternError], which wraps
+// [ErrInvalidRoute]. This allows tools such as linters, editors, URL builders or converters to work with route patterns
+// without re-implementing the grammar.
+func ParsePattern(pattern string, opts ParseOptions) (*Pattern, error) {
+	parsed, err := parser{
+		maxParams:        cmp.Or(opts.MaxParams, math.MaxUint8),
+		maxParamKeyBytes: cmp.Or(opts.MaxParamKeyBytes, math.MaxUint8),
+		allowRegexp:      opts.AllowRegexp,
+	}.parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pattern{Raw: pattern}
+	offset := 0
+	for _, tk := range parsed.token {
+		seg := Segment{Offset: offset}
+		switch tk.typ {
+		case nodeStatic:
+			seg.Kind = SegmentStatic
+			seg.Value = tk.value
+			seg.End = offset + len(tk.value)
+		case nodeParam:
+			seg.Kind = SegmentParam
+			seg.Value = tk.value
+			// offset is at the opening brace.
+			seg.End = offset + 1 + braceIndice(pattern[offset+1:], 1) + 1
+			seg.Regexp = regexpOf(pattern[offset+1:seg.End-1], tk.value)
+		default:
+			seg.Kind = SegmentWildcard
+			if pattern[offset] == starDelim {
+				seg.Kind = SegmentOptionalWildcard
+			}
+			seg.Value = tk.value
+			// offset is at the catch-all delimiter, followed by the opening brace.
+			seg.End = offset + 2 + braceIndice(pattern[offset+2:], 1) + 1
+			seg.Regexp = regexpOf(pattern[offset+2:seg.End-1], tk.value)
+		}
+		offset = seg.End
+
+		if seg.Offset < parsed.endHost {
+			p.Host = append(p.Host, seg)
+		} else {
+			p.Path = append(p.Path, seg)
+		}
+	}
+
+	return p, nil
+}
+
+// regexpOf returns the regular expression of a param or wildcard, given the content between braces and its name.
+func regexpOf(content, name string) string {
+	expr, _ := strings.CutPrefix(content, name)
+	expr, _ = strings.CutPrefix(expr, ":")
+	return expr
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		host    []Segment
+		path    []Segment
+	}{
+		{
+			name:    "static",
+			pattern: "/users/me",
+			path:    []Segment{{Kind: SegmentStatic, Value: "/users/me", Offset: 0, End: 9}},
+		},
+		{
+			name:    "params and wildcards",
+			pattern: "/users/{id}/files/+{dir}/{name:[a-z]{1,8}}/*{rest}",
+			path: []Segment{
+				{Kind: SegmentStatic, Value: "/users/", Offset: 0, End: 7},
+				{Kind: SegmentParam, Value: "id", Offset: 7, End: 11},
+				{Kind: SegmentStatic, Value: "/files/", Offset: 11, End: 18},
+				{Kind: SegmentWildcard, Value: "dir", Offset: 18, End: 24},
+				{Kind: SegmentStatic, Value: "/", Offset: 24, End: 25},
+				{Kind: SegmentParam, Value: "name", Regexp: "[a-z]{1,8}", Offset: 25, End: 42},
+				{Kind: SegmentStatic, Value: "/", Offset: 42, End: 43},
+				{Kind: SegmentOptionalWildcard, Value: "rest", Offset: 43, End: 50},
+			},
+		},
+		{
+			name:    "hostname",
+			pattern: "{sub}.example.com/+{any:.+}",
+			host: []Segment{
+				{Kind: SegmentParam, Value: "sub", Offset: 0, End: 5},
+				{Kind: SegmentStatic, Value: ".example.com", Offset: 5, End: 17},
+			},
+			path: []Segment{
+				{Kind: SegmentStatic, Value: "/", Offset: 17, End: 18},
+				{Kind: SegmentWildcard, Value: "any", Regexp: ".+", Offset: 18, End: 27},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParsePattern(tc.pattern, ParseOptions{AllowRegexp: true})
+			require.NoError(t, err)
+			assert.Equal(t, tc.host, p.Host)
+			assert.Equal(t, tc.path, p.Path)
+			assert.Equal(t, tc.pattern, p.String())
+			for _, seg := range append(p.Host, p.Path...) {
+				if seg.Kind == SegmentStatic {
+					assert.Equal(t, seg.Value, tc.pattern[seg.Offset:seg.End])
+				}
+			}
+		})
+	}
+
+	p, err := ParsePattern("{sub}.example.com/users/{id}", ParseOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "{sub}.example.com", p.Hostname())
+	assert.Equal(t, []string{"sub", "id"}, p.Params())
+}
+
+func TestParsePattern_Error(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		opts    ParseOptions
+		offset  int
+		reason  string
+		cause   error
+	}{
+		{name: "unclosed param", pattern: "/users/{id", offset: 7, reason: "unclosed '{param}'"},
+		{name: "illegal character after param", pattern: "/users/{id}abc", offset: 11, reason: "illegal character 'a' after '{param}'"},
+		{name: "regexp not allowed", pattern: "/users/{id:[0-9]+}", offset: 11, reason: "regexp not allowed", cause: ErrRegexpNotAllowed},
+		{name: "optional wildcard as infix", pattern: "/foo/*{any}/bar", opts: ParseOptions{AllowRegexp: true}, offset: 5, reason: "'*{param}' allowed only as suffix"},
+		{name: "too many params", pattern: "/{a}/{b}", opts: ParseOptions{MaxParams: 1}, offset: 5, reason: "too many params", cause: ErrTooManyParams},
+		{name: "uppercase hostname", pattern: "exAmple.com/", offset: 2, reason: "illegal uppercase character 'A' in hostname label"},
+		{name: "consecutive slashes", pattern: "/foo//bar", offset: 5, reason: "illegal consecutive slashes in path"},
+		{name: "missing slash", pattern: "example.com", offset: 11, reason: "missing trailing '/' after hostname"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePattern(tc.pattern, tc.opts)
+			require.Error(t, err)
+			var perr *PatternError
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, tc.pattern, perr.Pattern)
+			assert.Equal(t, tc.offset, perr.Offset)
+			assert.Equal(t, tc.reason, perr.Reason)
+			assert.Equal(t, "invalid route: "+tc.reason, err.Error())
+			assert.ErrorIs(t, err, ErrInvalidRoute)
+			if tc.cause != nil {
+				assert.ErrorIs(t, err, tc.cause)
+			}
+		})
+	}
+
+	f := MustRouter()
+	_, err := f.Add(MethodGet, "/users/{id", emptyHandler)
+	var perr *PatternError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, 7, perr.Offset)
+	assert.ErrorIs(t, err, ErrInvalidRoute)
+}
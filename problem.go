@@ -0,0 +1,176 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Problem is a problem details object, as defined by RFC 9457. When enabled with [WithProblemDetails], the responses
+// generated by the router (404, 405, redirects, 500 from [Recovery] and errors handled by [DefaultErrorHandler]) are
+// written as "application/problem+json" documents, if the client accepts it.
+type Problem struct {
+	// Extensions holds additional members, serialized at the top level of the document. Extension members named
+	// after standard members are ignored.
+	Extensions map[string]any
+	// Type is a URI reference that identifies the problem type. It defaults to "about:blank".
+	Type string
+	// Title is a short, human-readable summary of the problem type. It defaults to the status text.
+	Title string
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference that identifies this occurrence of the problem. It defaults to the request path.
+	Instance string
+	// Status is the HTTP status code.
+	Status int
+}
+
+// MarshalJSON implements [json.Marshaler], flattening the extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]any, len(p.Extensions)+5)
+	maps.Copy(doc, p.Extensions)
+	doc["type"] = p.Type
+	doc["title"] = p.Title
+	doc["status"] = p.Status
+	delete(doc, "detail")
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	delete(doc, "instance")
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+	return json.Marshal(doc)
+}
+
+// ProblemFunc is a function type that customizes a [Problem] before it is written, e.g. to set a problem type URI
+// or add extension members such as a trace identifier.
+type ProblemFunc func(c *Context, p *Problem)
+
+// replyError writes an error response with the provided status. If problem details are enabled for the route or
+// router, and the client accepts it, the response is a problem details document. Otherwise, the message is written
+// as plain text. The detail is only used in problem details documents.
+func replyError(c *Context, status int, msg, detail string) {
+	replyProblem(c, status, msg, detail, nil)
+}
+
+// replyProblem is like replyError, but also adds the provided extension members to the problem details document.
+func replyProblem(c *Context, status int, msg, detail string, extensions map[string]any) {
+	fn, ok := problemFor(c)
+	if !ok {
+		http.Error(c.Writer(), msg, status)
+		return
+	}
+
+	p := &Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Instance:   c.Path(),
+		Extensions: extensions,
+	}
+	if fn != nil {
+		fn(c, p)
+	}
+
+	buf, err := json.Marshal(p)
+	if err != nil {
+		http.Error(c.Writer(), msg, status)
+		return
+	}
+
+	h := c.Writer().Header()
+	h.Del(HeaderContentLength)
+	h.Set(HeaderContentType, MIMEApplicationProblemJSON)
+	h.Set(HeaderXContentTypeOptions, "nosniff")
+	c.Writer().WriteHeader(status)
+	_, _ = c.Writer().Write(buf)
+}
+
+// replyRedirect redirects the request to the provided url with the provided status. If problem details are enabled
+// for the route or router, and the client accepts it, the body is a problem details document. Otherwise, it is
+// written by [http.Redirect].
+func replyRedirect(c *Context, url string, status int) {
+	if _, ok := problemFor(c); !ok {
+		http.Redirect(c.Writer(), c.Request(), url, status)
+		return
+	}
+	c.Writer().Header().Set(HeaderLocation, url)
+	replyError(c, status, http.StatusText(status), "")
+}
+
+// problemFor reports whether the response should be a problem details document, according to the settings of the
+// route or router and the Accept header, and returns the [ProblemFunc] to apply, if any.
+func problemFor(c *Context) (ProblemFunc, bool) {
+	enabled, fn := c.fox.problems, c.fox.problemFunc
+	if c.route != nil {
+		enabled, fn = c.route.problems, c.route.problemFunc
+	}
+	if !enabled || !acceptsProblem(c.Header(HeaderAccept)) {
+		return nil, false
+	}
+	return fn, true
+}
+
+// acceptsProblem reports whether a problem details document is acceptable according to the Accept header, and
+// preferred over plain text. An empty header accepts any media type. As specified by RFC 9110, the quality value
+// of the most specific matching media range applies.
+func acceptsProblem(accept string) bool {
+	if accept == "" {
+		return true
+	}
+
+	var problem, text quality
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			k, v, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		switch mediaType {
+		case MIMEApplicationProblemJSON, MIMEApplicationJSON:
+			problem.set(2, q)
+		case "application/*":
+			problem.set(1, q)
+		case MIMETextPlain:
+			text.set(2, q)
+		case "text/*":
+			text.set(1, q)
+		case "*/*":
+			problem.set(0, q)
+			text.set(0, q)
+		}
+	}
+
+	return problem.q > 0 && problem.q >= text.q
+}
+
+// quality is the quality value of the most specific media range matching a media type.
+type quality struct {
+	q           float64
+	specificity int
+	matched     bool
+}
+
+func (mq *quality) set(specificity int, q float64) {
+	switch {
+	case !mq.matched || specificity > mq.specificity:
+		mq.q, mq.specificity, mq.matched = q, specificity, true
+	case specificity == mq.specificity:
+		mq.q = max(mq.q, q)
+	}
+}
@@ -0,0 +1,271 @@
+package fox
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProblemDetails(t *testing.T) {
+	f := MustRouter(
+		WithProblemDetails(true),
+		WithNoMethod(true),
+		WithMiddleware(Recovery(slog.NewTextHandler(io.Discard, nil))),
+	)
+	f.MustAdd(MethodGet, "/users/{id}", HandleE(func(c *Context) error {
+		return &HTTPError{Status: http.StatusConflict, Message: "user is locked", Header: http.Header{"X-Reason": {"locked"}}}
+	}))
+	f.MustAdd(MethodGet, "/panic", func(c *Context) {
+		panic("boom")
+	})
+	f.MustAdd(MethodGet, "/text", HandleE(func(c *Context) error {
+		return errors.New("boom")
+	}), WithProblemDetails(false))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		accept string
+		want   map[string]any
+		status int
+		body   string
+	}{
+		{
+			name:   "not found",
+			method: http.MethodGet,
+			path:   "/foo",
+			status: http.StatusNotFound,
+			want:   map[string]any{"type": "about:blank", "title": "Not Found", "status": float64(404), "instance": "/foo"},
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPost,
+			path:   "/panic",
+			accept: "application/json",
+			status: http.StatusMethodNotAllowed,
+			want:   map[string]any{"type": "about:blank", "title": "Method Not Allowed", "status": float64(405), "instance": "/panic"},
+		},
+		{
+			name:   "recovery",
+			method: http.MethodGet,
+			path:   "/panic",
+			accept: "text/html, */*;q=0.8",
+			status: http.StatusInternalServerError,
+			want:   map[string]any{"type": "about:blank", "title": "Internal Server Error", "status": float64(500), "instance": "/panic"},
+		},
+		{
+			name:   "http error",
+			method: http.MethodGet,
+			path:   "/users/42",
+			status: http.StatusConflict,
+			want:   map[string]any{"type": "about:blank", "title": "Conflict", "status": float64(409), "detail": "user is locked", "instance": "/users/42"},
+		},
+		{
+			name:   "plain text accepted",
+			method: http.MethodGet,
+			path:   "/users/42",
+			accept: "text/plain",
+			status: http.StatusConflict,
+			body:   "user is locked\n",
+		},
+		{
+			name:   "disabled for route",
+			method: http.MethodGet,
+			path:   "/text",
+			status: http.StatusInternalServerError,
+			body:   "Internal Server Error\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set(HeaderAccept, tc.accept)
+			}
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			if tc.want == nil {
+				assert.Equal(t, MIMETextPlainCharsetUTF8, w.Header().Get(HeaderContentType))
+				assert.Equal(t, tc.body, w.Body.String())
+				return
+			}
+			assert.Equal(t, MIMEApplicationProblemJSON, w.Header().Get(HeaderContentType))
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/panic", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, "GET", w.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, "locked", w.Header().Get("X-Reason"))
+}
+
+func TestWithProblemFunc(t *testing.T) {
+	withTrace := func(name string) ProblemFunc {
+		return func(c *Context, p *Problem) {
+			p.Type = "https://example.com/problems/" + name
+			p.Extensions = map[string]any{"trace_id": "abc", "status": "ignored"}
+		}
+	}
+
+	sub := MustRouter(WithProblemFunc(withTrace("api")))
+	sub.MustAdd(MethodGet, "/users", emptyHandler)
+
+	f := MustRouter(WithProblemFunc(withTrace("root")))
+	f.MustAdd(MethodAny, "/api/*{any}", Sub(sub))
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/foo", want: `{"instance":"/foo","status":404,"title":"Not Found","trace_id":"abc","type":"https://example.com/problems/root"}`},
+		{path: "/api/foo", want: `{"instance":"/api/foo","status":404,"title":"Not Found","trace_id":"abc","type":"https://example.com/problems/api"}`},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, tc.want, w.Body.String())
+	}
+
+	_, err := NewRouter(WithProblemFunc(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	cases := map[string]bool{
+		"":                                   true,
+		"*/*":                                true,
+		"application/problem+json":           true,
+		"application/json;q=0.5, text/plain": false,
+		"text/plain;q=0.5, application/*":    true,
+		"text/plain":                         false,
+		"application/json;q=0, */*":          false,
+		"text/*;q=0.1, */*;q=0.5":            true,
+		"image/png":                          false,
+	}
+	for accept, want := range cases {
+		assert.Equal(t, want, acceptsProblem(accept), accept)
+	}
+}
+
+func TestWithProblemDetailsRedirect(t *testing.T) {
+	f := MustRouter(WithProblemDetails(true), WithHandleTrailingSlash(RedirectSlash))
+	f.MustAdd(MethodGet, "/users/", emptyHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?page=2", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/users/?page=2", w.Header().Get(HeaderLocation))
+	assert.Equal(t, MIMEApplicationProblemJSON, w.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{"instance":"/users","status":301,"title":"Moved Permanently","type":"about:blank"}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/users?page=2", nil)
+	req.Header.Set(HeaderAccept, "text/html")
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/users/?page=2", w.Header().Get(HeaderLocation))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get(HeaderContentType))
+}
+
+func TestWithProblemDetailsInvalidClientIP(t *testing.T) {
+	errNoHeader := errors.New("no header")
+	f := MustRouter(
+		WithProblemDetails(true),
+		WithClientIPResolver(ClientIPResolverFunc(func(c RequestContext) (*net.IPAddr, error) {
+			return nil, errNoHeader
+		})),
+	)
+	var ipErr error
+	f.MustAdd(MethodGet, "/ip", HandleE(func(c *Context) error {
+		_, ipErr = c.ClientIP()
+		return ipErr
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.ErrorIs(t, ipErr, ErrInvalidClientIP)
+	assert.ErrorIs(t, ipErr, errNoHeader)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"detail":"invalid client IP","instance":"/ip","status":400,"title":"Bad Request","type":"about:blank"}`, w.Body.String())
+}
+
+func TestWithProblemDetailsFallback(t *testing.T) {
+	f := MustRouter(WithNoMethod(true))
+	f.MustAdd(MethodGet, "/api/users", emptyHandler)
+	f.MustAdd(MethodGet, "/web/users", emptyHandler)
+	require.NoError(t, onlyError(f.AddFallback("/api/*{any}", DefaultFallbackHandler, WithProblemFunc(func(c *Context, p *Problem) {
+		p.Type = "https://example.com/problems/api"
+	}))))
+
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "not found in sub-tree",
+			method:      http.MethodGet,
+			path:        "/api/missing",
+			status:      http.StatusNotFound,
+			contentType: MIMEApplicationProblemJSON,
+			body:        `{"instance":"/api/missing","status":404,"title":"Not Found","type":"https://example.com/problems/api"}`,
+		},
+		{
+			name:        "method not allowed in sub-tree",
+			method:      http.MethodPost,
+			path:        "/api/users",
+			status:      http.StatusMethodNotAllowed,
+			contentType: MIMEApplicationProblemJSON,
+			body:        `{"instance":"/api/users","status":405,"title":"Method Not Allowed","type":"https://example.com/problems/api"}`,
+		},
+		{
+			name:        "outside of the sub-tree",
+			method:      http.MethodPost,
+			path:        "/web/users",
+			status:      http.StatusMethodNotAllowed,
+			contentType: MIMETextPlainCharsetUTF8,
+			body:        "Method Not Allowed\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.contentType, w.Header().Get(HeaderContentType))
+			if tc.contentType == MIMEApplicationProblemJSON {
+				assert.JSONEq(t, tc.body, w.Body.String())
+				return
+			}
+			assert.Equal(t, tc.body, w.Body.String())
+		})
+	}
+}
@@ -43,9 +43,13 @@ var reqHeaderSep = []byte("\r\n")
 type RecoveryFunc func(c *Context, err any)
 
 // RecoveryWithFunc returns a middleware that recovers from any panics, logs the error, request details, and stack trace
-// using the provided [slog.Handler] and then calls the handle function to handle the recovery.
+// using the provided [slog.Handler] and then calls the handle function to handle the recovery. If the handler is nil,
+// the request logger returned by [Context.Logger] is used.
 func RecoveryWithFunc(handler slog.Handler, handle RecoveryFunc) MiddlewareFunc {
-	slogger := slog.New(handler)
+	var slogger *slog.Logger
+	if handler != nil {
+		slogger = slog.New(handler)
+	}
 	return func(next HandlerFunc) HandlerFunc {
 		return func(c *Context) {
 			defer recovery(slogger, c, handle)
@@ -55,15 +59,17 @@ func RecoveryWithFunc(handler slog.Handler, handle RecoveryFunc) MiddlewareFunc
 }
 
 // Recovery returns a middleware that recovers from any panics, logs the error, request details, and stack trace
-// using the provided [slog.Handler] and writes a 500 status code response if a panic occurs.
+// using the provided [slog.Handler] and writes a 500 status code response if a panic occurs. If the handler is nil,
+// the request logger returned by [Context.Logger] is used.
 func Recovery(handler slog.Handler) MiddlewareFunc {
 	return RecoveryWithFunc(handler, DefaultHandleRecovery)
 }
 
 // DefaultHandleRecovery is a default implementation of the [RecoveryFunc].
-// It responds with a status code 500 and writes a generic error message.
+// It responds with a status code 500 and writes a generic error message, or a problem details document if enabled
+// with [WithProblemDetails].
 func DefaultHandleRecovery(c *Context, _ any) {
-	http.Error(c.Writer(), http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	replyError(c, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), "")
 }
 
 func recovery(logger *slog.Logger, c *Context, handle RecoveryFunc) {
@@ -106,17 +112,23 @@ func recovery(logger *slog.Logger, c *Context, handle RecoveryFunc) {
 			params = slices.AppendSeq(params, mapParamsToAttr(c.Params()))
 		}
 
+		attrs := make([]any, 0, 3)
 		pattern := c.Pattern()
-		if pattern == "" {
-			pattern = scopeToString(c.Scope())
+		if logger == nil {
+			logger = c.Logger()
+			// The pattern, if any, is already an attribute of the request logger.
+			if pattern == "" {
+				attrs = append(attrs, slog.String(LoggerRouteKey, scopeToString(c.Scope())))
+			}
+		} else {
+			if pattern == "" {
+				pattern = scopeToString(c.Scope())
+			}
+			attrs = append(attrs, slog.String(LoggerRouteKey, pattern))
 		}
+		attrs = append(attrs, slog.Group(LoggerParamsKey, params...), slog.Any(LoggerPanicKey, err))
 
-		logger.Error(
-			sb.String(),
-			slog.String(LoggerRouteKey, pattern),
-			slog.Group(LoggerParamsKey, params...),
-			slog.Any(LoggerPanicKey, err),
-		)
+		logger.Error(sb.String(), attrs...)
 
 		if !c.Writer().Written() && !connIsBroken(err) {
 			handle(c, err)
@@ -186,6 +198,8 @@ func scopeToString(scope HandlerScope) string {
 		strScope = "RedirectPathHandler"
 	case NoRouteHandler:
 		strScope = "NoRouteHandler"
+	case PreRoutingHandler:
+		strScope = "PreRoutingHandler"
 	default:
 		strScope = "UnknownHandler"
 	}
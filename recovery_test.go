@@ -2,6 +2,7 @@ package fox
 
 import (
 	"bytes"
+	"encoding/json"
 	"log/slog"
 	"net"
 	"net/http"
@@ -75,6 +76,31 @@ func TestRecoveryMiddleware(t *testing.T) {
 	assert.NotEqual(t, weBuf.Len(), 0)
 }
 
+func TestRecoveryMiddlewareWithRequestLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	f, err := NewRouter(
+		WithRequestLogger(slog.NewJSONHandler(buf, nil), nil),
+		WithMiddleware(Recovery(nil)),
+	)
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "/users/{id}", record[LoggerRouteKey])
+	assert.Equal(t, "abc", record[LoggerRequestIDKey])
+	assert.Equal(t, "boom", record[LoggerPanicKey])
+	assert.Equal(t, map[string]any{"id": "42"}, record[LoggerParamsKey])
+}
+
 func TestRecoveryMiddlewareOtherScope(t *testing.T) {
 	woBuf := bytes.NewBuffer(nil)
 	weBuf := bytes.NewBuffer(nil)
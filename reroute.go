@@ -0,0 +1,95 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxReroutes is the maximum number of times a request can be rerouted with [Context.Reroute] or
+// [Context.RerouteHost], to prevent rerouting loops.
+const MaxReroutes = 10
+
+// Reroute serves the request as if it had been received with the provided method and path, without a round trip
+// to the client. It is a shorthand for [Context.RerouteHost] with the current request host.
+func (c *Context) Reroute(method, path string) error {
+	return c.RerouteHost(method, c.req.Host, path)
+}
+
+// RerouteHost serves the request as if it had been received with the provided method, host and path, without a
+// round trip to the client. This is useful for URL rewriting or legacy aliases. An empty method keeps the current
+// one. The path must be absolute and may include a query string, which replaces the current one.
+//
+// The lookup runs from the beginning, on the same routing tree snapshot as the original request, with the same
+// [Context] and [ResponseWriter]. Once RerouteHost returns, [Context.Route], [Context.Pattern], [Context.Request]
+// and the params reflect the rerouted request, and [Context.OriginalPath] returns the path of the original one.
+// As the whole dispatch runs again, middleware registered globally are applied again, and errors of the rerouted
+// request are handled by its own chain, so they are not returned again to an enclosing [MiddlewareFuncE] of the
+// original route. When called from a handler of a sub-router mounted with [Sub], the request is served by the
+// sub-router as if it had received it directly: the path is relative to the sub-router, and the pattern prefix and
+// params of the parent routes no longer apply, so [Context.Pattern] and the params only reflect the sub-router route.
+// The handler should not write the response before rerouting. If the request has already been rerouted [MaxReroutes]
+// times, it returns [ErrTooManyReroutes] without serving the request.
+func (c *Context) RerouteHost(method, host, path string) error {
+	if c.reroutes >= MaxReroutes {
+		return fmt.Errorf("%w: maximum of %d reached", ErrTooManyReroutes, MaxReroutes)
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return fmt.Errorf("invalid reroute path %q: must be an absolute path", path)
+	}
+	target, err := url.Parse(path)
+	if err != nil {
+		return fmt.Errorf("invalid reroute path %q: %w", path, err)
+	}
+
+	if c.reroutes == 0 {
+		c.origPath = c.Path()
+	}
+	c.reroutes++
+
+	req := c.req.WithContext(c.req.Context())
+	if method != "" {
+		req.Method = method
+	}
+	req.Host = host
+	req.Pattern = ""
+	u := *c.req.URL
+	u.Path = target.Path
+	u.RawPath = target.RawPath
+	if target.RawQuery != "" || target.ForceQuery {
+		u.RawQuery = target.RawQuery
+		u.ForceQuery = target.ForceQuery
+	}
+	req.URL = &u
+
+	c.req = req
+	c.cachedQueries = nil
+	c.scope = RouteHandler
+	c.err = nil
+	*c.params = (*c.params)[:0]
+	*c.subPatterns = (*c.subPatterns)[:0]
+
+	errScope := c.errScope
+	c.errScope = 0
+	c.fox.serve(c)
+	c.errScope = errScope
+	if errScope > 0 {
+		// Already handled by the rerouted chain.
+		c.err = nil
+	}
+	return nil
+}
+
+// OriginalPath returns the path of the request before it was rerouted with [Context.Reroute] or
+// [Context.RerouteHost], in the same form as [Context.Path]. If the request has not been rerouted, it returns
+// [Context.Path].
+func (c *Context) OriginalPath() string {
+	if c.reroutes == 0 {
+		return c.Path()
+	}
+	return c.origPath
+}
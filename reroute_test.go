@@ -0,0 +1,193 @@
+package fox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_Reroute(t *testing.T) {
+	key := NewContextKey[string]("key")
+	report := func(c *Context) {
+		v, _ := key.Get(c)
+		_ = c.String(http.StatusOK, c.Method()+" "+c.Host()+" "+c.Pattern()+" "+c.Path()+" "+c.OriginalPath()+" "+
+			c.Param("id")+" "+c.QueryParam("q")+" "+v)
+	}
+
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/v2/users/{id}", report)
+	f.MustAdd([]string{http.MethodPost}, "/v2/users/{id}", report)
+	f.MustAdd(MethodGet, "api.example.com/users/{id}", report)
+	f.MustAdd(MethodGet, "/legacy/users/{id}", HandleE(func(c *Context) error {
+		key.Set(c, "value")
+		return c.Reroute(http.MethodGet, "/v2/users/"+c.Param("id"))
+	}))
+	f.MustAdd(MethodGet, "/legacy/method/{id}", HandleE(func(c *Context) error {
+		return c.Reroute(http.MethodPost, "/v2/users/"+c.Param("id")+"?q=new")
+	}))
+	f.MustAdd(MethodGet, "/legacy/host/{id}", HandleE(func(c *Context) error {
+		return c.RerouteHost("", "api.example.com", "/users/"+c.Param("id"))
+	}))
+	f.MustAdd(MethodGet, "/legacy/twice/{id}", HandleE(func(c *Context) error {
+		return c.Reroute("", "/legacy/users/"+c.Param("id"))
+	}))
+	f.MustAdd(MethodGet, "/legacy/missing", HandleE(func(c *Context) error {
+		return c.Reroute("", "/missing")
+	}))
+
+	cases := []struct {
+		name   string
+		target string
+		want   string
+		code   int
+	}{
+		{
+			name:   "reroute with params",
+			target: "/legacy/users/42?q=foo",
+			want:   "GET example.com /v2/users/{id} /v2/users/42 /legacy/users/42 42 foo value",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "reroute with method and query",
+			target: "/legacy/method/42?q=foo",
+			want:   "POST example.com /v2/users/{id} /v2/users/42 /legacy/method/42 42 new ",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "reroute with host",
+			target: "/legacy/host/42",
+			want:   "GET api.example.com api.example.com/users/{id} /users/42 /legacy/host/42 42  ",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "reroute twice keeps the original path",
+			target: "/legacy/twice/42",
+			want:   "GET example.com /v2/users/{id} /v2/users/42 /legacy/twice/42 42  value",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "reroute to a missing route",
+			target: "/legacy/missing",
+			want:   "404 page not found\n",
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+			assert.Equal(t, tc.target, req.URL.RequestURI())
+		})
+	}
+}
+
+func TestContext_RerouteLoop(t *testing.T) {
+	calls := 0
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/loop", HandleE(func(c *Context) error {
+		calls++
+		return c.Reroute(http.MethodGet, "/loop")
+	}))
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/loop", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MaxReroutes+1, calls)
+}
+
+func TestContext_RerouteInvalidPath(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/", func(c *Context) {
+		assert.ErrorContains(t, c.Reroute(http.MethodGet, "foo"), "must be an absolute path")
+		assert.ErrorContains(t, c.Reroute(http.MethodGet, "//foo"), "must be an absolute path")
+		assert.ErrorContains(t, c.Reroute(http.MethodGet, "/%zz"), "invalid URL escape")
+		assert.Equal(t, "/", c.OriginalPath())
+		assert.Equal(t, "/", c.Pattern())
+	})
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestContext_RerouteFromNotFound(t *testing.T) {
+	f := MustRouter(WithNoRouteHandler(func(c *Context) {
+		if c.OriginalPath() == c.Path() {
+			_ = c.Reroute("", "/fallback")
+			return
+		}
+		DefaultNotFoundHandler(c)
+	}))
+	f.MustAdd(MethodGet, "/fallback", func(c *Context) {
+		_ = c.String(http.StatusOK, c.OriginalPath())
+	})
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo/bar", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/foo/bar", w.Body.String())
+}
+
+func TestContext_RerouteSubRouter(t *testing.T) {
+	sub := MustRouter()
+	sub.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		_ = c.String(http.StatusOK, c.Pattern()+" "+c.Path()+" "+c.Param("id")+" "+c.Param("tenant")+" "+
+			c.OriginalPath())
+	})
+	sub.MustAdd(MethodGet, "/legacy/{id}", HandleE(func(c *Context) error {
+		assert.Equal(t, "/{tenant}/api/legacy/{id}", c.Pattern())
+		return c.Reroute("", "/users/"+c.Param("id"))
+	}))
+
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/{tenant}/api/*{mount}", Sub(sub))
+
+	req := httptest.NewRequest(http.MethodGet, "/acme/api/legacy/42", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/users/{id} /users/42 42  /acme/api/legacy/42", w.Body.String())
+}
+
+func TestContext_RerouteWrapME(t *testing.T) {
+	var observed []error
+	var handled int
+	observer := WrapME(func(next HandlerFuncE) HandlerFuncE {
+		return func(c *Context) error {
+			err := next(c)
+			observed = append(observed, err)
+			return err
+		}
+	})
+
+	f, err := NewRouter(
+		WithMiddleware(observer),
+		WithErrorHandler(func(c *Context, err error) {
+			handled++
+			DefaultErrorHandler(c, err)
+		}),
+	)
+	require.NoError(t, err)
+	f.MustAdd(MethodGet, "/v2/users", HandleE(func(c *Context) error {
+		return NewHTTPError(http.StatusTeapot, nil)
+	}))
+	f.MustAdd(MethodGet, "/legacy/users", HandleE(func(c *Context) error {
+		return c.Reroute("", "/v2/users")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/legacy/users", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, 1, handled)
+	require.Len(t, observed, 2)
+	assert.Error(t, observed[0])
+	assert.NoError(t, observed[1])
+}
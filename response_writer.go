@@ -13,12 +13,16 @@ import (
 	"net/http"
 	"path"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-var _ ResponseWriter = (*recorder)(nil)
+var (
+	_ ResponseWriter = (*recorder)(nil)
+	_ ResponseWriter = (*headWriter)(nil)
+)
 
 var copyBufPool = sync.Pool{
 	New: func() any {
@@ -281,6 +285,108 @@ func (r *recorder) EnableFullDuplex() error {
 	return ErrNotSupported()
 }
 
+// headWriter is a [ResponseWriter] used to serve HEAD requests with the handler of a GET route. It discards the body
+// and delays the response header until the handler returns or flushes, so the Content-Length of the discarded body
+// can be set, as the GET response would have had.
+type headWriter struct {
+	ResponseWriter
+	size    int
+	status  int
+	pending bool
+}
+
+// Status recorded after Write or WriteHeader.
+func (w *headWriter) Status() int {
+	if w.pending {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Written returns true if the response has been written.
+func (w *headWriter) Written() bool {
+	return w.pending || w.ResponseWriter.Written()
+}
+
+// Unwrap returns the underlying [ResponseWriter].
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader records the status code, which is sent once the handler returns.
+func (w *headWriter) WriteHeader(code int) {
+	if w.pending {
+		caller := relevantCaller()
+		log.Printf("http: superfluous response.WriteHeader call from %s (%s:%d)", caller.Function, path.Base(caller.File), caller.Line)
+		return
+	}
+	if w.ResponseWriter.Written() || (code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols) {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	w.pending = true
+}
+
+// Write discards the data, and records its length.
+func (w *headWriter) Write(buf []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.size += len(buf)
+	return len(buf), nil
+}
+
+// WriteString discards the string, and records its length.
+func (w *headWriter) WriteString(s string) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.size += len(s)
+	return len(s), nil
+}
+
+// ReadFrom reads data from src until EOF or error, and discards it.
+func (w *headWriter) ReadFrom(src io.Reader) (n int64, err error) {
+	bufp := copyBufPool.Get().(*[]byte)
+	buf := *bufp
+	n, err = io.CopyBuffer(onlyWrite{w}, src, buf)
+	copyBufPool.Put(bufp)
+	return
+}
+
+// FlushError sends the response header, and flushes it to the client. As the body may not be complete, the
+// Content-Length is not set.
+func (w *headWriter) FlushError() error {
+	w.commit(false)
+	return w.ResponseWriter.FlushError()
+}
+
+// commit sends the pending response header. If complete is true, the Content-Length of the discarded body is set,
+// unless the handler did.
+func (w *headWriter) commit(complete bool) {
+	if !w.pending {
+		return
+	}
+	w.pending = false
+	h := w.ResponseWriter.Header()
+	if complete && w.size > 0 && bodyAllowedForStatus(w.status) && h.Get(HeaderContentLength) == "" && h.Get(HeaderTransferEncoding) == "" {
+		h.Set(HeaderContentLength, strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// bodyAllowedForStatus reports whether a given response status code permits a body. See RFC 9110, section 6.4.1.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
 type noUnwrap struct {
 	ResponseWriter
 }
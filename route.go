@@ -5,26 +5,37 @@ import (
 	"iter"
 	"slices"
 	"strings"
+	"time"
 )
 
 // Route represents an immutable HTTP route with associated handlers and settings.
 type Route struct {
-	clientip    ClientIPResolver
-	hbase       HandlerFunc
-	hself       HandlerFunc
-	hall        HandlerFunc
-	annots      map[any]any
-	pattern     string
-	name        string
-	methods     []string
-	mws         []middleware
-	params      []string
-	tokens      []token
-	matchers    []Matcher
-	hostEnd     int
-	priority    uint
-	handleSlash TrailingSlashOption
-	catchEmpty  bool
+	clientip     ClientIPResolver
+	errorHandler ErrorHandlerFunc
+	problemFunc  ProblemFunc
+	hbase        HandlerFunc
+	hself        HandlerFunc
+	hall         HandlerFunc
+	hnoRoute     HandlerFunc
+	hnoMethod    HandlerFunc
+	annots       map[any]any
+	pattern      string
+	name         string
+	methods      []string
+	mws          []middleware
+	params       []string
+	tokens       []token
+	matchers     []Matcher
+	lc           *lifecycle
+	onRetire     []func(route *Route)
+	hostEnd      int
+	mwsLen       int
+	expiry       int64
+	priority     uint
+	handleSlash  TrailingSlashOption
+	catchEmpty   bool
+	problems     bool
+	fallback     bool
 }
 
 // Handle calls the handler with the provided [Context]. See also [Route.HandleMiddleware].
@@ -73,12 +84,29 @@ func (r *Route) Name() string {
 	return r.name
 }
 
+// Fallback reports whether the route is a fallback handler registered with [Router.AddFallback].
+func (r *Route) Fallback() bool {
+	return r.fallback
+}
+
 // Annotation returns the value associated with this [Route] for key, or nil if no value is associated with key.
 // Successive calls to Annotation with the same key returns the same result.
 func (r *Route) Annotation(key any) any {
 	return r.annots[key]
 }
 
+// Annotations returns an iterator over all annotations attached to this [Route], in no particular order.
+// See also [Route.Annotation].
+func (r *Route) Annotations() iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		for k, v := range r.annots {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
 // TrailingSlashOption returns the configured [TrailingSlashOption] for this [Route].
 func (r *Route) TrailingSlashOption() TrailingSlashOption {
 	return r.handleSlash
@@ -108,6 +136,12 @@ func (r *Route) Params() iter.Seq[string] {
 	}
 }
 
+// MiddlewaresLen returns the number of middleware applied to this [Route], including global middleware with the
+// [RouteHandler] scope.
+func (r *Route) MiddlewaresLen() int {
+	return r.mwsLen
+}
+
 // MatchersLen returns the number of matchers for this [Route].
 func (r *Route) MatchersLen() int {
 	return len(r.matchers)
@@ -124,6 +158,30 @@ func (r *Route) Matchers() iter.Seq[Matcher] {
 	}
 }
 
+// Expiry returns the time at which this [Route] expires, or the zero [time.Time] if the route never expires.
+// See [WithTTL] and [WithExpiry].
+func (r *Route) Expiry() time.Time {
+	if r.expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, r.expiry)
+}
+
+// Expired reports whether this [Route] has expired. An expired route no longer matches any request, even
+// if it is still registered in the routing tree.
+func (r *Route) Expired() bool {
+	return r.expiry > 0 && time.Now().UnixNano() >= r.expiry
+}
+
+// Inflight returns the number of requests currently being handled by this [Route]. It always returns 0 if the
+// route has not been created with in-flight tracking enabled. See [WithInflightTracking].
+func (r *Route) Inflight() int {
+	if r.lc == nil {
+		return 0
+	}
+	return int(r.lc.inflight.Load())
+}
+
 // MatchersPriority returns the matchers priority for this [Route].
 func (r *Route) MatchersPriority() uint {
 	return r.priority
@@ -135,9 +193,17 @@ func (r *Route) String() string {
 	return sb.String()
 }
 
-// match reports whether the request satisfies this route's method constraint (if any)
-// and all attached matchers.
-func (r *Route) match(method string, c RequestContext) bool {
+// match reports whether the route is not expired and the request satisfies this route's method constraint
+// (if any) and all attached matchers.
+func (r *Route) match(method string, c *Context) bool {
+	if c.trace != nil {
+		return r.explainMatch(method, c)
+	}
+
+	if r.expiry > 0 && time.Now().UnixNano() >= r.expiry {
+		return false
+	}
+
 	// Fast path for common cases: no methods or single method
 	methods := r.methods
 	switch len(methods) {
@@ -1,10 +1,13 @@
 package fox
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -115,3 +118,108 @@ func TestRoute_String(t *testing.T) {
 		assert.Equal(t, "method:* pattern:/foo/bar", r.String())
 	})
 }
+
+func TestRoute_Expiry(t *testing.T) {
+	t.Run("route without ttl never expires", func(t *testing.T) {
+		f := MustRouter()
+		r := f.MustAdd(MethodGet, "/foo", emptyHandler)
+		assert.True(t, r.Expiry().IsZero())
+		assert.False(t, r.Expired())
+	})
+
+	t.Run("expired route stops matching and is collected", func(t *testing.T) {
+		f := MustRouter()
+		f.MustAdd(MethodGet, "/foo", emptyHandler, WithTTL(50*time.Millisecond))
+		f.MustAdd(MethodGet, "/foo", emptyHandler, WithQueryMatcher("a", "b"), WithTTL(time.Hour))
+		f.MustAdd(MethodGet, "/bar", emptyHandler)
+
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		require.Eventually(t, func() bool {
+			return f.Len() == 2
+		}, time.Second, 10*time.Millisecond)
+
+		w = httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.False(t, f.Has(MethodGet, "/foo"))
+		m, err := MatchQuery("a", "b")
+		require.NoError(t, err)
+		assert.NotNil(t, f.Route(MethodGet, "/foo", m))
+	})
+
+	t.Run("expired route does not match before collection", func(t *testing.T) {
+		f := MustRouter()
+		r := f.MustAdd(MethodGet, "/foo", emptyHandler, WithExpiry(time.Now().Add(time.Hour)))
+		assert.False(t, r.Expired())
+		r.expiry = time.Now().Add(-time.Second).UnixNano()
+		assert.True(t, r.Expired())
+
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("collection is retried when rejected by a commit hook", func(t *testing.T) {
+		var reject atomic.Bool
+		reject.Store(true)
+		f := MustRouter(WithCommitHook(func(txn *Txn) error {
+			if reject.Load() {
+				return errors.New("rejected")
+			}
+			return nil
+		}))
+		reject.Store(false)
+		f.MustAdd(MethodGet, "/foo", emptyHandler, WithTTL(20*time.Millisecond))
+		f.MustAdd(MethodGet, "/bar", emptyHandler)
+		reject.Store(true)
+
+		time.Sleep(2 * janitorMinBackoff)
+		assert.Equal(t, 2, f.Len())
+
+		reject.Store(false)
+		require.Eventually(t, func() bool {
+			return f.Len() == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.False(t, f.Has(MethodGet, "/foo"))
+	})
+
+	t.Run("close stops the janitor", func(t *testing.T) {
+		f := MustRouter()
+		f.MustAdd(MethodGet, "/foo", emptyHandler, WithTTL(20*time.Millisecond))
+		require.NoError(t, f.Close())
+		require.NoError(t, f.Close())
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 1, f.Len())
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		f := MustRouter()
+		_, err := f.Add(MethodGet, "/foo", emptyHandler, WithTTL(0))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		_, err = f.Add(MethodGet, "/foo", emptyHandler, WithExpiry(time.Time{}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
+
+func TestRoute_Annotations(t *testing.T) {
+	type k1 struct{}
+	type k2 struct{}
+	f := MustRouter()
+	r := f.MustAdd(MethodGet, "/foo", emptyHandler, WithAnnotation(k1{}, "a"), WithAnnotation(k2{}, 2))
+
+	got := make(map[any]any)
+	for k, v := range r.Annotations() {
+		got[k] = v
+	}
+	assert.Equal(t, map[any]any{k1{}: "a", k2{}: 2}, got)
+}
@@ -0,0 +1,252 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventWriter writes Server-Sent Events to the client, as specified by the HTML Living Standard. It is created with
+// [Context.SSE]. Every message is flushed as soon as it is written. All methods are safe for concurrent use, but the
+// EventWriter must not be used after the handler returns, and [EventWriter.Close] must be called before, typically
+// with defer, to stop the heartbeat.
+type EventWriter struct {
+	w         ResponseWriter
+	ctx       context.Context
+	stop      chan struct{}
+	buf       []byte
+	lastWrite time.Time
+	timeout   time.Duration
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	closed    bool
+}
+
+// SSE starts a Server-Sent Events stream, and returns an [EventWriter] to send events to the client. It replies with a
+// "200 OK" status, the "text/event-stream" content type and headers disabling caching and proxy buffering, and flushes
+// them immediately. As event streams are long-lived, the write deadline of the connection, such as the one set by
+// [http.Server.WriteTimeout], is cleared. Use [EventWriter.SetWriteTimeout] to bound each write instead. The stream
+// ends when the handler returns, or when the client disconnects, which is reported by [EventWriter.Done]. If the
+// [ResponseWriter] does not support flushing, SSE returns an error matching [http.ErrNotSupported]. The id of the last
+// event received by a reconnecting client is available in the "Last-Event-ID" request header.
+func (c *Context) SSE() (*EventWriter, error) {
+	h := c.w.Header()
+	h.Set(HeaderContentType, MIMETextEventStream)
+	h.Set(HeaderCacheControl, "no-cache")
+	h.Set(HeaderXAccelBuffering, "no")
+	h.Del(HeaderContentLength)
+	// Connection-specific headers are not allowed in HTTP/2 and HTTP/3.
+	if c.req.ProtoMajor == 1 {
+		h.Set(HeaderConnection, "keep-alive")
+	}
+
+	if err := c.w.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	c.w.WriteHeader(http.StatusOK)
+	if err := c.w.FlushError(); err != nil {
+		return nil, err
+	}
+
+	return &EventWriter{
+		w:         c.w,
+		ctx:       c.req.Context(),
+		lastWrite: time.Now(),
+	}, nil
+}
+
+// Send writes an event with the provided type, id and data. An empty event type dispatches a "message" event on the
+// client, and an empty id leaves the client last event id unchanged. Multi-line data is split into several data
+// fields. It returns an error if the event type or id contains a line break, if the client has disconnected, or if the
+// EventWriter is closed.
+func (e *EventWriter) Send(event, id, data string) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return fmt.Errorf("invalid event type %q: must not contain line breaks", event)
+	}
+	if strings.ContainsAny(id, "\r\n\x00") {
+		return fmt.Errorf("invalid event id %q: must not contain line breaks or NULL", id)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buf = e.buf[:0]
+	if id != "" {
+		e.buf = append(e.buf, "id: "...)
+		e.buf = append(e.buf, id...)
+		e.buf = append(e.buf, '\n')
+	}
+	if event != "" {
+		e.buf = append(e.buf, "event: "...)
+		e.buf = append(e.buf, event...)
+		e.buf = append(e.buf, '\n')
+	}
+	e.buf = appendField(e.buf, "data: ", data)
+	e.buf = append(e.buf, '\n')
+	return e.write()
+}
+
+// Retry writes a hint telling the client how long to wait before reconnecting if the connection is lost. It returns an
+// error if the client has disconnected, or if the EventWriter is closed.
+func (e *EventWriter) Retry(d time.Duration) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buf = append(e.buf[:0], "retry: "...)
+	e.buf = strconv.AppendInt(e.buf, d.Milliseconds(), 10)
+	e.buf = append(e.buf, "\n\n"...)
+	return e.write()
+}
+
+// Comment writes a comment, which is ignored by the client but keeps the connection alive. Multi-line comments are
+// split into several comment lines. It returns an error if the client has disconnected, or if the EventWriter is
+// closed.
+func (e *EventWriter) Comment(text string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.buf = appendField(e.buf[:0], ": ", text)
+	e.buf = append(e.buf, '\n')
+	return e.write()
+}
+
+// Heartbeat starts sending an empty comment to the client whenever nothing has been written for the provided interval,
+// so that idle connections are not closed by proxies. It stops when the EventWriter is closed or the client
+// disconnects. Calling Heartbeat again replaces the previous interval, and a non-positive interval stops it.
+func (e *EventWriter) Heartbeat(interval time.Duration) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	stop := e.stop
+	e.stop = nil
+	if interval > 0 {
+		e.stop = make(chan struct{})
+		e.wg.Add(1)
+		go e.heartbeat(interval, e.stop)
+	}
+	e.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+}
+
+// SetWriteTimeout sets the maximum duration of each write. Before every write, the write deadline of the connection
+// is extended by d, so a stream is never interrupted while the client keeps reading, but a stalled client is detected.
+// A zero value means no deadline, which is the default. It has no effect if the [ResponseWriter] does not support
+// write deadlines.
+func (e *EventWriter) SetWriteTimeout(d time.Duration) {
+	e.mu.Lock()
+	e.timeout = d
+	e.mu.Unlock()
+}
+
+// Done returns a channel that is closed when the client disconnects, or when the request context is canceled.
+func (e *EventWriter) Done() <-chan struct{} {
+	return e.ctx.Done()
+}
+
+// Close stops the heartbeat, and waits for it to return. Any subsequent write returns [ErrEventStreamClosed]. It is
+// safe to call Close multiple times.
+func (e *EventWriter) Close() error {
+	e.mu.Lock()
+	e.closed = true
+	stop := e.stop
+	e.stop = nil
+	e.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	e.wg.Wait()
+	return nil
+}
+
+func (e *EventWriter) heartbeat(interval time.Duration, stop <-chan struct{}) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-e.ctx.Done():
+			return
+		case now := <-ticker.C:
+			e.mu.Lock()
+			select {
+			case <-stop:
+				// Stopped while waiting for the lock.
+				e.mu.Unlock()
+				return
+			default:
+			}
+			var err error
+			if now.Sub(e.lastWrite) >= interval {
+				e.buf = append(e.buf[:0], ":\n\n"...)
+				err = e.write()
+			}
+			e.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// write sends the buffered message to the client and flushes it. The caller must hold the lock.
+func (e *EventWriter) write() error {
+	if e.closed {
+		return ErrEventStreamClosed
+	}
+	if err := e.ctx.Err(); err != nil {
+		return err
+	}
+
+	if e.timeout > 0 {
+		if err := e.w.SetWriteDeadline(time.Now().Add(e.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+
+	if _, err := e.w.Write(e.buf); err != nil {
+		return err
+	}
+	e.lastWrite = time.Now()
+	return e.w.FlushError()
+}
+
+// appendField appends a field line for every line of value, normalizing line breaks as required by the event
+// stream format.
+func appendField(buf []byte, prefix, value string) []byte {
+	for {
+		i := strings.IndexAny(value, "\r\n")
+		if i < 0 {
+			break
+		}
+		buf = append(buf, prefix...)
+		buf = append(buf, value[:i]...)
+		buf = append(buf, '\n')
+		if value[i] == '\r' && i+1 < len(value) && value[i+1] == '\n' {
+			i++
+		}
+		value = value[i+1:]
+	}
+	buf = append(buf, prefix...)
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
@@ -0,0 +1,147 @@
+package fox
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_SSE(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/events", HandleE(func(c *Context) error {
+		sse, err := c.SSE()
+		if err != nil {
+			return err
+		}
+		defer sse.Close()
+
+		require.NoError(t, sse.Retry(3*time.Second))
+		require.NoError(t, sse.Send("", "", "hello"))
+		require.NoError(t, sse.Send("update", "1", "line1\nline2\r\nline3\rline4"))
+		require.NoError(t, sse.Comment("ping"))
+		assert.Error(t, sse.Send("bad\nevent", "", "data"))
+		assert.Error(t, sse.Send("", "bad\nid", "data"))
+
+		require.NoError(t, sse.Close())
+		assert.ErrorIs(t, sse.Send("", "", "closed"), ErrEventStreamClosed)
+		return nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, MIMETextEventStream, w.Header().Get(HeaderContentType))
+	assert.Equal(t, "no-cache", w.Header().Get(HeaderCacheControl))
+	assert.Equal(t, "no", w.Header().Get(HeaderXAccelBuffering))
+	assert.Equal(t, "keep-alive", w.Header().Get(HeaderConnection))
+	assert.Equal(t, "retry: 3000\n\n"+
+		"data: hello\n\n"+
+		"id: 1\nevent: update\ndata: line1\ndata: line2\ndata: line3\ndata: line4\n\n"+
+		": ping\n\n", w.Body.String())
+}
+
+func TestContext_SSEFlushNotSupported(t *testing.T) {
+	f := MustRouter()
+	var err error
+	f.MustAdd(MethodGet, "/events", func(c *Context) {
+		c.SetWriter(noFlushWriter{c.Writer()})
+		_, err = c.SSE()
+	})
+
+	f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestEventWriter_Heartbeat(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/events", func(c *Context) {
+		sse, err := c.SSE()
+		require.NoError(t, err)
+		defer sse.Close()
+
+		sse.Heartbeat(10 * time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Contains(t, w.Body.String(), ":\n\n")
+}
+
+func TestEventWriter_ClientDisconnect(t *testing.T) {
+	f := MustRouter()
+	done := make(chan error, 1)
+	f.MustAdd(MethodGet, "/events", func(c *Context) {
+		sse, err := c.SSE()
+		require.NoError(t, err)
+		defer sse.Close()
+
+		<-sse.Done()
+		done <- sse.Send("", "", "gone")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/events", nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	f.ServeHTTP(httptest.NewRecorder(), req)
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestEventWriter_WriteTimeout(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/events", func(c *Context) {
+		sse, err := c.SSE()
+		require.NoError(t, err)
+		defer sse.Close()
+
+		sse.SetWriteTimeout(time.Second)
+		for i := range 5 {
+			time.Sleep(30 * time.Millisecond)
+			if err := sse.Send("tick", "", string(rune('0'+i))); err != nil {
+				return
+			}
+		}
+	})
+
+	srv := httptest.NewUnstartedServer(f)
+	// The stream outlives the server write timeout.
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			events = append(events, data)
+		}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, events)
+}
+
+type noFlushWriter struct {
+	ResponseWriter
+}
+
+func (w noFlushWriter) FlushError() error {
+	return http.ErrNotSupported
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 Sylvain Müller. All rights reserved.
+// Mount of this source code is governed by a Apache-2.0 license that can be found
+// at https://github.com/fox-toolkit/fox/blob/master/LICENSE.txt.
+
+package fox
+
+import (
+	"context"
+	"reflect"
+	"slices"
+)
+
+type keyValue struct {
+	key   any
+	value any
+}
+
+// Set stores the value for the key in the [Context], replacing any existing value. It allows middlewares to pass data
+// to handlers without allocating a new [http.Request]. The key must be comparable and should be of a custom type to
+// avoid collisions, as with [context.WithValue]. Values are cleared once the request is handled, and are visible to
+// [http.Handler] wrapped with [WrapH] or [WrapM] through the request context. See also [ContextKey].
+func (c *Context) Set(key, value any) {
+	if key == nil {
+		panic("fox: nil key")
+	}
+	if !reflect.TypeOf(key).Comparable() {
+		panic("fox: key is not comparable")
+	}
+	for i := range c.store {
+		if c.store[i].key == key {
+			c.store[i].value = value
+			return
+		}
+	}
+	c.store = append(c.store, keyValue{key: key, value: value})
+}
+
+// Get returns the value stored for the key in the [Context] with [Context.Set], or nil if none.
+func (c *Context) Get(key any) any {
+	v, _ := c.Lookup(key)
+	return v
+}
+
+// Lookup returns the value stored for the key in the [Context] with [Context.Set], and reports whether it was found.
+func (c *Context) Lookup(key any) (any, bool) {
+	for i := range c.store {
+		if c.store[i].key == key {
+			return c.store[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// resetStore clears the stored values, keeping the capacity for the next request.
+func (c *Context) resetStore() {
+	clear(c.store)
+	c.store = c.store[:0]
+}
+
+// ContextKey is a typed key for values stored in a [Context]. Each key created with [NewContextKey] is unique.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns a new [ContextKey] for values of type T. The name is only used for debugging.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// Set stores the value in the [Context].
+func (k *ContextKey[T]) Set(c *Context, value T) {
+	c.Set(k, value)
+}
+
+// Get returns the value stored in the [Context], and reports whether it was found.
+func (k *ContextKey[T]) Get(c *Context) (T, bool) {
+	v, ok := c.Lookup(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
+
+// Value returns the value stored in the [context.Context] of a request handled by an [http.Handler] wrapped with
+// [WrapH] or [WrapM], and reports whether it was found.
+func (k *ContextKey[T]) Value(ctx context.Context) (T, bool) {
+	t, ok := ctx.Value(k).(T)
+	return t, ok
+}
+
+func (k *ContextKey[T]) String() string {
+	return "fox.ContextKey(" + k.name + ")"
+}
+
+// storeContext exposes a snapshot of the values stored in a [Context] to a [context.Context].
+type storeContext struct {
+	context.Context
+	store []keyValue
+}
+
+func (ctx *storeContext) Value(key any) any {
+	for i := range ctx.store {
+		if ctx.store[i].key == key {
+			return ctx.store[i].value
+		}
+	}
+	return ctx.Context.Value(key)
+}
+
+// withStore returns a copy of the parent context that exposes the values stored in the [Context], if any. The values
+// are copied, since the parent context may outlive the [Context].
+func (c *Context) withStore(parent context.Context) context.Context {
+	if len(c.store) == 0 {
+		return parent
+	}
+	return &storeContext{Context: parent, store: slices.Clone(c.store)}
+}
@@ -0,0 +1,123 @@
+package fox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeKey string
+
+func TestContext_SetGet(t *testing.T) {
+	_, c := NewTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Nil(t, c.Get(storeKey("foo")))
+	c.Set(storeKey("foo"), "bar")
+	c.Set(storeKey("baz"), 42)
+	c.Set(storeKey("foo"), "qux")
+	assert.Equal(t, "qux", c.Get(storeKey("foo")))
+	v, ok := c.Lookup(storeKey("baz"))
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	assert.Len(t, c.store, 2)
+
+	cp := c.Clone()
+	c.Set(storeKey("foo"), "changed")
+	assert.Equal(t, "qux", cp.Get(storeKey("foo")))
+
+	c.reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	_, ok = c.Lookup(storeKey("foo"))
+	assert.False(t, ok)
+
+	assert.PanicsWithValue(t, "fox: nil key", func() { c.Set(nil, 1) })
+	assert.PanicsWithValue(t, "fox: key is not comparable", func() { c.Set([]string{}, 1) })
+}
+
+func TestContextKey(t *testing.T) {
+	userKey := NewContextKey[string]("user")
+	otherKey := NewContextKey[string]("user")
+	assert.Equal(t, "fox.ContextKey(user)", userKey.String())
+
+	_, c := NewTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	_, ok := userKey.Get(c)
+	assert.False(t, ok)
+
+	userKey.Set(c, "john")
+	user, ok := userKey.Get(c)
+	assert.True(t, ok)
+	assert.Equal(t, "john", user)
+	_, ok = otherKey.Get(c)
+	assert.False(t, ok)
+}
+
+func TestContext_StoreBridge(t *testing.T) {
+	userKey := NewContextKey[string]("user")
+	auth := func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			userKey.Set(c, "john")
+			c.Set(storeKey("role"), "admin")
+			next(c)
+		}
+	}
+
+	sub := MustRouter()
+	sub.MustAdd(MethodGet, "/users/{id}", func(c *Context) {
+		user, _ := userKey.Get(c)
+		_ = c.String(http.StatusOK, "sub:"+user+":"+c.Param("id"))
+	})
+
+	f := MustRouter(WithMiddleware(auth))
+	f.MustAdd(MethodGet, "/wraph/{id}", WrapH(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := userKey.Value(r.Context())
+		role, _ := r.Context().Value(storeKey("role")).(string)
+		_, _ = w.Write([]byte(user + ":" + role + ":" + ParamsFromContext(r.Context()).Get("id")))
+	})))
+	f.MustAdd(MethodGet, "/wrapm", func(c *Context) {
+		user, _ := userKey.Get(c)
+		_ = c.String(http.StatusOK, "handler:"+user)
+	}, WithMiddleware(WrapM(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := userKey.Value(r.Context())
+			w.Header().Set("X-User", user)
+			next.ServeHTTP(w, r)
+		})
+	})))
+	f.MustAdd(MethodGet, "/api/*{any}", Sub(sub))
+
+	cases := []struct {
+		path   string
+		body   string
+		header string
+	}{
+		{path: "/wraph/42", body: "john:admin:42"},
+		{path: "/wrapm", body: "handler:john", header: "john"},
+		{path: "/api/users/42", body: "sub:john:42"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.body, w.Body.String())
+			assert.Equal(t, tc.header, w.Header().Get("X-User"))
+		})
+	}
+}
+
+func BenchmarkContext_SetGet(b *testing.B) {
+	_, c := NewTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	key := NewContextKey[int]("n")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key.Set(c, i)
+		_, _ = key.Get(c)
+		c.resetStore()
+	}
+}
@@ -19,6 +19,8 @@ type iTree struct {
 	fox       *Router
 	patterns  *node
 	names     *node
+	fallbacks *node
+	epoch     *epoch
 	methods   map[string]uint
 	size      int
 	maxParams int
@@ -30,6 +32,7 @@ func (t *iTree) txn() *tXn {
 		tree:      t,
 		patterns:  t.patterns,
 		names:     t.names,
+		fallbacks: t.fallbacks,
 		methods:   t.methods,
 		size:      t.size,
 		maxParams: t.maxParams,
@@ -46,6 +49,15 @@ func (t *iTree) lookupByPath(method, path string, c *Context, lazy bool) (int, *
 	return lookupByPath(t.patterns, method, path, c, lazy, offsetZero)
 }
 
+// lookupFallback performs a lookup in the fallbacks tree. If pathOnly is true, the hostname is ignored.
+func (t *iTree) lookupFallback(method, hostPort, path string, c *Context, pathOnly bool) (int, *node, bool) {
+	if pathOnly {
+		*c.skipStack = (*c.skipStack)[:0]
+		return lookupByPath(t.fallbacks, method, path, c, false, offsetZero)
+	}
+	return t.fallbacks.lookup(method, hostPort, path, c, false)
+}
+
 func (t *iTree) allocateContext() *Context {
 	patterns := make([]string, 0)
 	params := make([]string, 0, t.maxParams)
@@ -65,27 +77,40 @@ func (t *iTree) allocateContext() *Context {
 }
 
 type tXn struct {
-	tree      *iTree
-	writable  *simplelru.LRU[*node, struct{}]
+	tree     *iTree
+	writable *simplelru.LRU[*node, struct{}]
+	// owned, when not nil, replaces the writable cache with an unbounded set of nodes owned by the transaction.
+	// This is used during bulk load, where the number of modified nodes largely exceeds the cache size.
+	owned     map[*node]struct{}
 	patterns  *node
 	names     *node
+	fallbacks *node
 	methods   map[string]uint
 	size      int
 	maxParams int
 	maxDepth  int
-	forked    bool
-	mode      insertMode
+	// expiry is the earliest expiration time (unix nano) of the routes written in this transaction, or 0 if none.
+	expiry int64
+	// retired holds the tracked routes removed or replaced in this transaction.
+	retired []*Route
+	// tracked reports whether a tracked route has been written in this transaction.
+	tracked bool
+	forked  bool
+	mode    insertMode
 }
 
 func (t *tXn) commit() *iTree {
 	tc := &iTree{
 		patterns:  t.patterns,
 		names:     t.names,
+		fallbacks: t.fallbacks,
 		methods:   t.methods,
 		fox:       t.tree.fox,
 		size:      t.size,
 		maxParams: t.maxParams,
 		maxDepth:  t.maxDepth,
+		// One reference for being the current tree, and one for the epoch of the tree it replaces.
+		epoch: newEpoch(2),
 	}
 	tc.pool = sync.Pool{
 		New: func() any {
@@ -93,6 +118,7 @@ func (t *tXn) commit() *iTree {
 		},
 	}
 	t.writable = nil
+	clear(t.owned)
 	t.forked = false
 	return tc
 }
@@ -102,15 +128,20 @@ func (t *tXn) commit() *iTree {
 // in different tree on commit.
 func (t *tXn) clone() *tXn {
 	t.writable = nil
+	clear(t.owned)
 	t.forked = false
 	tx := &tXn{
 		tree:      t.tree,
 		patterns:  t.patterns,
 		names:     t.names,
+		fallbacks: t.fallbacks,
 		methods:   t.methods,
 		size:      t.size,
 		maxParams: t.maxParams,
 		maxDepth:  t.maxDepth,
+		expiry:    t.expiry,
+		retired:   slices.Clip(t.retired),
+		tracked:   t.tracked,
 	}
 	return tx
 }
@@ -119,6 +150,7 @@ func (t *tXn) clone() *tXn {
 // will not be reflected on the snapshot.
 func (t *tXn) snapshot() (patterns, names *node, methods map[string]uint) {
 	t.writable = nil
+	clear(t.owned)
 	t.forked = false
 	return t.patterns, t.names, t.methods
 }
@@ -256,6 +288,9 @@ func (t *tXn) deleteNameIn(root, n *node, search string) *node {
 
 // insert performs a recursive copy-on-write insertion.
 func (t *tXn) insert(route *Route, mode insertMode) error {
+	if route.fallback {
+		return fmt.Errorf("%w: fallback route cannot be registered as a route", ErrInvalidRoute)
+	}
 	t.mode = mode
 
 	newRoot, err := t.insertTokens(nil, t.patterns, route.tokens, route)
@@ -264,8 +299,12 @@ func (t *tXn) insert(route *Route, mode insertMode) error {
 	}
 	if newRoot != nil {
 		t.patterns = newRoot
+		t.tracked = t.tracked || route.lc != nil
 		t.maxDepth = max(t.maxDepth, t.computePathDepth(newRoot, route.tokens))
 		t.maxParams = max(t.maxParams, len(route.params))
+		if route.expiry > 0 && (t.expiry == 0 || route.expiry < t.expiry) {
+			t.expiry = route.expiry
+		}
 		t.size++
 		if len(route.methods) > 0 && t.mode == modeInsert {
 			if !t.forked {
@@ -281,6 +320,23 @@ func (t *tXn) insert(route *Route, mode insertMode) error {
 	return nil
 }
 
+// insertFallback performs a recursive copy-on-write insertion in the fallbacks tree.
+func (t *tXn) insertFallback(route *Route, mode insertMode) error {
+	t.mode = mode
+
+	newRoot, err := t.insertTokens(nil, t.fallbacks, route.tokens, route)
+	if err != nil {
+		return err
+	}
+	if newRoot != nil {
+		t.fallbacks = newRoot
+		t.tracked = t.tracked || route.lc != nil
+		t.maxDepth = max(t.maxDepth, t.computePathDepth(newRoot, route.tokens))
+		t.maxParams = max(t.maxParams, len(route.params))
+	}
+	return nil
+}
+
 func (t *tXn) insertTokens(p, n *node, tokens []token, route *Route) (*node, error) {
 	// Base case: no tokens left, attach route
 	if len(tokens) == 0 {
@@ -375,6 +431,7 @@ func (t *tXn) insertTokens(p, n *node, tokens []token, route *Route) (*node, err
 					t.deleteName(n.routes[idx])
 				}
 
+				t.retire(oldRoute)
 				nc := t.writeNode(n)
 				nc.replaceRoute(idx, route)
 				return nc, nil
@@ -388,6 +445,7 @@ func (t *tXn) insertTokens(p, n *node, tokens []token, route *Route) (*node, err
 				}
 			}
 
+			t.retire(oldRoute)
 			nc := t.writeNode(n)
 			nc.replaceRoute(idx, route)
 			return nc, nil
@@ -616,6 +674,7 @@ func (t *tXn) delete(route *Route) (*Route, bool) {
 	}
 
 	if oldRoute != nil {
+		t.retire(oldRoute)
 		t.size--
 		for _, method := range route.methods {
 			t.methods[method]--
@@ -629,6 +688,21 @@ func (t *tXn) delete(route *Route) (*Route, bool) {
 	return nil, false
 }
 
+// deleteFallback performs a recursive copy-on-write deletion in the fallbacks tree.
+func (t *tXn) deleteFallback(route *Route) (*Route, bool) {
+	newRoot, oldRoute := t.deleteTokens(t.fallbacks, t.fallbacks, route.tokens, route)
+	if newRoot != nil {
+		t.fallbacks = newRoot
+	}
+
+	if oldRoute != nil {
+		t.retire(oldRoute)
+		return oldRoute, true
+	}
+
+	return nil, false
+}
+
 func (t *tXn) deleteTokens(root, n *node, tokens []token, route *Route) (*node, *Route) {
 	if len(tokens) == 0 {
 		if !n.isLeaf() {
@@ -793,16 +867,64 @@ func (t *tXn) deleteWildcard(root, n *node, key string, remaining []token, route
 }
 
 func (t *tXn) truncate() {
+	t.retireAll(t.patterns)
+	t.retireAll(t.fallbacks)
 	t.patterns = new(node)
 	t.names = new(node)
+	t.fallbacks = new(node)
 	t.methods = make(map[string]uint)
 	t.maxDepth = 0
 	t.maxParams = 0
 	t.size = 0
 	t.writable = nil
+	clear(t.owned)
 	t.forked = true
 }
 
+// retire records a tracked route removed or replaced in this transaction.
+func (t *tXn) retire(route *Route) {
+	if route.lc != nil {
+		t.retired = append(t.retired, route)
+	}
+}
+
+// retireAll records all tracked routes reachable from n.
+func (t *tXn) retireAll(n *node) {
+	for _, route := range n.routes {
+		t.retire(route)
+	}
+	for _, child := range n.statics {
+		t.retireAll(child)
+	}
+	for _, child := range n.params {
+		t.retireAll(child)
+	}
+	for _, child := range n.wildcards {
+		t.retireAll(child)
+	}
+}
+
+// countNodes returns the number of nodes in the tree rooted at n.
+func countNodes(n *node) int {
+	count := 1
+	for _, children := range [][]*node{n.statics, n.params, n.wildcards} {
+		for _, child := range children {
+			count += countNodes(child)
+		}
+	}
+	return count
+}
+
+// has reports whether the exact route is registered in the tree.
+func (t *iTree) has(route *Route) bool {
+	root := t.patterns
+	if route.fallback {
+		root = t.fallbacks
+	}
+	matched := root.searchPattern(route.pattern)
+	return matched != nil && slices.Contains(matched.routes, route)
+}
+
 func (t *tXn) computePathDepth(root *node, tokens []token) int {
 	var depth int
 	current := root
@@ -842,6 +964,15 @@ func (t *tXn) computePathDepth(root *node, tokens []token) int {
 }
 
 func (t *tXn) writeNode(n *node) *node {
+	if t.owned != nil {
+		if _, ok := t.owned[n]; ok {
+			return n
+		}
+		nc := copyNode(n)
+		t.owned[nc] = struct{}{}
+		return nc
+	}
+
 	if t.writable == nil {
 		lru, err := simplelru.NewLRU[*node, struct{}](defaultModifiedCache, nil)
 		if err != nil {
@@ -854,6 +985,13 @@ func (t *tXn) writeNode(n *node) *node {
 		return n
 	}
 
+	nc := copyNode(n)
+	t.writable.Add(nc, struct{}{})
+	return nc
+}
+
+// copyNode returns a shallow copy of n with its own routes and children slices.
+func copyNode(n *node) *node {
 	nc := &node{
 		label:  n.label,
 		key:    n.key,
@@ -878,7 +1016,6 @@ func (t *tXn) writeNode(n *node) *node {
 		copy(nc.wildcards, n.wildcards)
 	}
 
-	t.writable.Add(nc, struct{}{})
 	return nc
 }
 
@@ -2,6 +2,7 @@ package fox
 
 import (
 	"fmt"
+	"iter"
 	"net/http"
 	"slices"
 	"strings"
@@ -69,6 +70,49 @@ func (txn *Txn) AddRoute(route *Route) error {
 	return txn.rootTxn.insert(route, modeInsert)
 }
 
+// Load registers all routes yielded by the provided sequence. It produces exactly the same tree, and fails with
+// exactly the same errors, as calling [Txn.AddRoute] for each route in sequence order, but is much faster for very
+// large route tables. If no route is registered in the transaction, typically at startup, the routes are validated
+// in sequence order and the tree is built bottom-up in a single pass, by sorting the static segments of the
+// patterns. Otherwise, routes are inserted one at a time, but nodes created by Load are owned by the transaction and
+// mutated in place, instead of being copied on every insertion. The sequence order determines the evaluation order
+// of regexp params and of routes with equal priority. Load stops at the first error, which is one of the following:
+//   - [ErrRouteConflict]: If the route conflict with others.
+//   - [ErrRouteNameExist]: If the route name is already registered.
+//   - [ErrInvalidRoute]: If the route is missing.
+//   - [ErrReadOnlyTxn]: On write in a read-only transaction.
+//
+// Routes loaded before the error remain registered in the transaction; use [Txn.Savepoint] to undo them.
+// This function is NOT thread-safe and should be run serially, along with all other [Txn] APIs.
+// See also [NewBulkLoader].
+func (txn *Txn) Load(routes iter.Seq[*Route]) error {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+	if !txn.write {
+		return ErrReadOnlyTxn
+	}
+
+	if txn.rootTxn.emptyForLoad() {
+		return txn.rootTxn.load(routes)
+	}
+
+	txn.rootTxn.owned = make(map[*node]struct{})
+	defer func() {
+		txn.rootTxn.owned = nil
+	}()
+
+	for route := range routes {
+		if route == nil {
+			return fmt.Errorf("%w: nil route", ErrInvalidRoute)
+		}
+		if err := txn.rootTxn.insert(route, modeInsert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Update override an existing route for the given methods, pattern and matchers. On success, it returns the newly registered [Route].
 // If an error occurs, it returns one of the following:
 //   - [ErrRouteNotFound]: If the route does not exist.
@@ -213,7 +257,8 @@ func (txn *Txn) DeleteRoute(route *Route) (*Route, error) {
 	return rte, nil
 }
 
-// Truncate remove all routes registered in the router. Truncating on a read-only transaction returns ErrReadOnlyTxn.
+// Truncate remove all routes and fallbacks registered in the router. Truncating on a read-only transaction returns
+// ErrReadOnlyTxn.
 func (txn *Txn) Truncate() error {
 	if txn.rootTxn == nil {
 		panic(ErrSettledTxn)
@@ -357,26 +402,56 @@ func (txn *Txn) Len() int {
 	return txn.rootTxn.size
 }
 
-// Commit finalize the transaction. This is a noop for read transactions, already aborted or
-// committed transactions. This function is NOT thread-safe and should be run serially,
-// along with all other [Txn] APIs.
-func (txn *Txn) Commit() {
+// Commit finalize the transaction. Before the pending tree is published, every hook registered with [WithCommitHook]
+// is called with a read-only view of it. If a hook returns an error, the transaction is aborted and the error is
+// returned. This is a noop for read transactions, already aborted or committed transactions. This function is NOT
+// thread-safe and should be run serially, along with all other [Txn] APIs.
+func (txn *Txn) Commit() error {
 	// Noop for a read transaction
 	if !txn.write {
-		return
+		return nil
 	}
 
 	// Check if already aborted or committed
 	if txn.rootTxn == nil {
-		return
+		return nil
+	}
+
+	if len(txn.fox.commitHooks) > 0 {
+		view := &Txn{fox: txn.fox, rootTxn: txn.rootTxn}
+		for _, hook := range txn.fox.commitHooks {
+			if err := hook(view); err != nil {
+				view.rootTxn = nil
+				txn.Abort()
+				return err
+			}
+		}
+		view.rootTxn = nil
 	}
 
 	newRoot := txn.rootTxn.commit()
-	txn.fox.tree.Store(newRoot)
+	if txn.rootTxn.tracked {
+		// Must be set before the tree is published, so that every request able to match a tracked route is counted.
+		txn.fox.tracked.Store(true)
+	}
+	oldRoot := txn.fox.tree.Swap(newRoot)
+	if txn.rootTxn.expiry > 0 {
+		txn.fox.scheduleJanitor(txn.rootTxn.expiry)
+	}
+	retired := make([]*Route, 0, len(txn.rootTxn.retired))
+	for _, route := range txn.rootTxn.retired {
+		// The route may have been registered again in the same transaction.
+		if !newRoot.has(route) {
+			retired = append(retired, route)
+		}
+	}
+	// Routes are retired once all requests served from the previous trees have completed.
+	oldRoot.epoch.seal(newRoot.epoch, retired)
 
 	// Clear the txn
 	txn.rootTxn = nil
 	txn.fox.mu.Unlock()
+	return nil
 }
 
 // Abort cancel the transaction. This is a noop for read transactions, already aborted or
@@ -412,6 +487,48 @@ func (txn *Txn) Snapshot() *Txn {
 	}
 }
 
+// Savepoint marks a point-in-time state within a [Txn] that can be restored later with [Txn.RollbackTo].
+type Savepoint struct {
+	txn   *Txn
+	state *tXn
+}
+
+// Savepoint captures the current state of the transaction, including any uncommitted writes. Further mutations on
+// the transaction can be undone with [Txn.RollbackTo], without discarding the work done before the savepoint. A
+// savepoint can be rolled back to any number of times, until the transaction is committed or aborted. This function
+// is NOT thread-safe and should be run serially, along with all other [Txn] APIs.
+func (txn *Txn) Savepoint() *Savepoint {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+
+	return &Savepoint{
+		txn:   txn,
+		state: txn.rootTxn.clone(),
+	}
+}
+
+// RollbackTo restores the transaction to the state captured by the provided [Savepoint], discarding all mutations
+// made after it. Savepoints created after sp remain valid. If an error occurs, it returns one of the following:
+//   - [ErrInvalidSavepoint]: If the savepoint is nil or belongs to another transaction.
+//   - [ErrReadOnlyTxn]: On rollback in a read-only transaction.
+//
+// This function is NOT thread-safe and should be run serially, along with all other [Txn] APIs.
+func (txn *Txn) RollbackTo(sp *Savepoint) error {
+	if txn.rootTxn == nil {
+		panic(ErrSettledTxn)
+	}
+	if !txn.write {
+		return ErrReadOnlyTxn
+	}
+	if sp == nil || sp.txn != txn {
+		return fmt.Errorf("%w: savepoint does not belong to this transaction", ErrInvalidSavepoint)
+	}
+
+	txn.rootTxn = sp.state.clone()
+	return nil
+}
+
 func validMethod(method string) bool {
 	/*
 	     Method         = "OPTIONS"                ; Section 9.2
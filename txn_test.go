@@ -2,8 +2,11 @@ package fox
 
 import (
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/fox-toolkit/fox/internal/iterutil"
@@ -662,3 +665,331 @@ func TestTxn_HasWithMatchers(t *testing.T) {
 		return nil
 	}))
 }
+
+func TestTxn_CommitHook(t *testing.T) {
+	errUnnamed := errors.New("all routes must be named")
+	requireName := func(txn *Txn) error {
+		for route := range txn.Iter().All() {
+			if route.Name() == "" {
+				return fmt.Errorf("%w: %s", errUnnamed, route.Pattern())
+			}
+		}
+		return nil
+	}
+
+	t.Run("managed mutation rejected by hook", func(t *testing.T) {
+		f := MustRouter(WithCommitHook(requireName))
+		require.NoError(t, onlyError(f.Add(MethodGet, "/foo", emptyHandler, WithName("foo"))))
+		route, err := f.Add(MethodGet, "/bar", emptyHandler)
+		assert.ErrorIs(t, err, errUnnamed)
+		assert.Nil(t, route)
+		assert.Equal(t, 1, f.Len())
+		assert.False(t, f.Has(MethodGet, "/bar"))
+
+		// The lock must be released after a rejected commit.
+		require.NoError(t, onlyError(f.Update(MethodGet, "/foo", emptyHandler, WithName("foo"))))
+	})
+
+	t.Run("hook observes the pending tree", func(t *testing.T) {
+		var seen []string
+		f := MustRouter(WithCommitHook(func(txn *Txn) error {
+			seen = seen[:0]
+			for route := range txn.Iter().All() {
+				seen = append(seen, route.Pattern())
+			}
+			assert.ErrorIs(t, onlyError(txn.Add(MethodGet, "/baz", emptyHandler)), ErrReadOnlyTxn)
+			return nil
+		}))
+		require.NoError(t, f.Updates(func(txn *Txn) error {
+			if _, err := txn.Add(MethodGet, "/foo", emptyHandler); err != nil {
+				return err
+			}
+			_, err := txn.Add(MethodGet, "/bar", emptyHandler)
+			return err
+		}))
+		assert.ElementsMatch(t, []string{"/foo", "/bar"}, seen)
+	})
+
+	t.Run("hooks run in order and stop on first error", func(t *testing.T) {
+		var calls []int
+		f := MustRouter(
+			WithCommitHook(func(txn *Txn) error {
+				calls = append(calls, 1)
+				return nil
+			}),
+			WithCommitHook(func(txn *Txn) error {
+				calls = append(calls, 2)
+				return errUnnamed
+			}),
+			WithCommitHook(func(txn *Txn) error {
+				calls = append(calls, 3)
+				return nil
+			}),
+		)
+		txn := f.Txn(true)
+		defer txn.Abort()
+		require.NoError(t, onlyError(txn.Add(MethodGet, "/foo", emptyHandler)))
+		assert.ErrorIs(t, txn.Commit(), errUnnamed)
+		assert.Equal(t, []int{1, 2}, calls)
+		assert.Equal(t, 0, f.Len())
+		assert.Panics(t, func() {
+			txn.Has(MethodGet, "/foo")
+		})
+	})
+
+	t.Run("nil hook", func(t *testing.T) {
+		_, err := NewRouter(WithCommitHook(nil))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
+
+func TestTxn_Savepoint(t *testing.T) {
+	f := MustRouter()
+	f.MustAdd(MethodGet, "/foo", emptyHandler, WithName("foo"))
+
+	txn := f.Txn(true)
+	defer txn.Abort()
+
+	require.NoError(t, onlyError(txn.Add(MethodGet, "/tenant1/a", emptyHandler)))
+	sp := txn.Savepoint()
+
+	require.NoError(t, onlyError(txn.Add(MethodGet, "/tenant2/a", emptyHandler, WithName("tenant2"))))
+	require.NoError(t, onlyError(txn.Delete(MethodGet, "/foo")))
+	assert.ErrorIs(t, onlyError(txn.Add(MethodGet, "/tenant1/a", emptyHandler)), ErrRouteConflict)
+	require.NoError(t, txn.RollbackTo(sp))
+
+	assert.True(t, txn.Has(MethodGet, "/tenant1/a"))
+	assert.True(t, txn.Has(MethodGet, "/foo"))
+	assert.False(t, txn.Has(MethodGet, "/tenant2/a"))
+	assert.Nil(t, txn.Name("tenant2"))
+	assert.NotNil(t, txn.Name("foo"))
+	assert.Equal(t, 2, txn.Len())
+
+	// A savepoint can be restored multiple times.
+	require.NoError(t, onlyError(txn.Add(MethodGet, "/tenant3/a", emptyHandler)))
+	require.NoError(t, txn.RollbackTo(sp))
+	assert.False(t, txn.Has(MethodGet, "/tenant3/a"))
+
+	require.NoError(t, onlyError(txn.Add(MethodGet, "/tenant4/a", emptyHandler)))
+	require.NoError(t, txn.Commit())
+
+	assert.Equal(t, 3, f.Len())
+	assert.True(t, f.Has(MethodGet, "/foo"))
+	assert.True(t, f.Has(MethodGet, "/tenant1/a"))
+	assert.True(t, f.Has(MethodGet, "/tenant4/a"))
+	assert.False(t, f.Has(MethodGet, "/tenant2/a"))
+
+	assert.Panics(t, func() {
+		_ = txn.RollbackTo(sp)
+	})
+}
+
+func TestTxn_RollbackToInvalidSavepoint(t *testing.T) {
+	f := MustRouter()
+
+	txn := f.Txn(true)
+	defer txn.Abort()
+	assert.ErrorIs(t, txn.RollbackTo(nil), ErrInvalidSavepoint)
+	assert.ErrorIs(t, txn.RollbackTo(txn.Snapshot().Savepoint()), ErrInvalidSavepoint)
+
+	rtxn := f.Txn(false)
+	defer rtxn.Abort()
+	assert.ErrorIs(t, rtxn.RollbackTo(rtxn.Savepoint()), ErrReadOnlyTxn)
+}
+
+func TestTxn_Load(t *testing.T) {
+	mustNewRoute := func(f *Router, methods []string, pattern string, opts ...RouteOption) *Route {
+		route, err := f.NewRoute(methods, pattern, emptyHandler, opts...)
+		require.NoError(t, err)
+		return route
+	}
+	newRoutes := func(f *Router) []*Route {
+		var routes []*Route
+		for _, rte := range githubAPI {
+			routes = append(routes, mustNewRoute(f, []string{rte.method}, rte.path, WithName(rte.method+":"+rte.path)))
+		}
+		for _, rte := range wildcardHostnames {
+			routes = append(routes, mustNewRoute(f, []string{rte.method}, rte.path+"/foo"))
+		}
+		for _, pattern := range []string{"/regexp/{id:[0-9]+}", "/regexp/{id:[a-z]+}", "/regexp/{id}", "/regexp/+{any:[A-Z]+}"} {
+			routes = append(routes, mustNewRoute(f, MethodGet, pattern))
+		}
+		routes = append(routes, mustNewRoute(f, MethodGet, "/regexp/{id}", WithQueryMatcher("a", "b")))
+		return routes
+	}
+
+	t.Run("same tree as incremental insert", func(t *testing.T) {
+		f1 := MustRouter(AllowRegexpParam(true))
+		routes := newRoutes(f1)
+		for _, route := range routes {
+			require.NoError(t, f1.AddRoute(route))
+		}
+
+		f2 := MustRouter(AllowRegexpParam(true))
+		require.NoError(t, onlyError(f2.Add(MethodGet, "/regexp/{id:[0-9]+}/exist", emptyHandler)))
+		require.NoError(t, onlyError(f2.Delete(MethodGet, "/regexp/{id:[0-9]+}/exist")))
+		require.NoError(t, f2.Load(slices.Values(routes)))
+
+		tree1, tree2 := f1.getTree(), f2.getTree()
+		assert.Equal(t, tree1.patterns.String(), tree2.patterns.String())
+		assert.Equal(t, tree1.names.String(), tree2.names.String())
+		assert.Equal(t, tree1.methods, tree2.methods)
+		assert.Equal(t, tree1.size, tree2.size)
+		assert.Equal(t, tree1.maxDepth, tree2.maxDepth)
+		assert.Equal(t, tree1.maxParams, tree2.maxParams)
+
+		// Loaded nodes must not be shared with later transactions.
+		require.NoError(t, onlyError(f2.Add(MethodGet, "/regexp/new", emptyHandler)))
+		assert.Equal(t, tree1.patterns.String(), tree2.patterns.String())
+	})
+
+	t.Run("same error as incremental insert", func(t *testing.T) {
+		f1 := MustRouter(AllowRegexpParam(true))
+		routes := newRoutes(f1)
+		routes = slices.Insert(routes, 10, mustNewRoute(f1, []string{githubAPI[0].method}, githubAPI[0].path))
+
+		var want error
+		for _, route := range routes {
+			if want = f1.AddRoute(route); want != nil {
+				break
+			}
+		}
+		require.ErrorIs(t, want, ErrRouteConflict)
+
+		f2 := MustRouter(AllowRegexpParam(true))
+		err := f2.Load(slices.Values(routes))
+		assert.ErrorIs(t, err, ErrRouteConflict)
+		assert.Equal(t, want.Error(), err.Error())
+		assert.Equal(t, 0, f2.Len())
+	})
+
+	t.Run("bulk build matches incremental insert in any order", func(t *testing.T) {
+		f := MustRouter(AllowRegexpParam(true))
+		routes := newRoutes(f)
+		for _, rte := range slices.Concat(staticRoutes, overlappingRoutes) {
+			routes = append(routes, mustNewRoute(f, []string{rte.method}, rte.path))
+		}
+		for _, rte := range staticHostnames {
+			routes = append(routes, mustNewRoute(f, []string{rte.method}, rte.path+"/"))
+		}
+		for _, pattern := range []string{
+			"{sub}.example.com/users", "{sub}.example.com/users/{id}", "a.com/x", "a.com.uk/x", "a.com/x/{id}",
+			"/files/*{path}", "/files/+{path:[a-z]+}", "/files/{name}/+{rest}", "/files/{name}/{id:[0-9]+}/meta",
+		} {
+			routes = append(routes, mustNewRoute(f, MethodGet, pattern, WithName(pattern)))
+		}
+		routes = append(routes,
+			mustNewRoute(f, MethodAny, "/files/{name}/meta"),
+			mustNewRoute(f, MethodAny, "/files/{name}/meta", WithHeaderMatcher("X-Version", "2")),
+			mustNewRoute(f, MethodGet, "/files/{name}/meta", WithQueryMatcher("v", "1")),
+			mustNewRoute(f, MethodGet, "/files/{name}/meta", WithQueryMatcher("v", "1"), WithHeaderMatcher("X-Version", "2")),
+		)
+
+		rng := rand.New(rand.NewPCG(1, 2))
+		for range 5 {
+			f1 := MustRouter(AllowRegexpParam(true))
+			for _, route := range routes {
+				require.NoError(t, f1.AddRoute(route))
+			}
+			f2 := MustRouter(AllowRegexpParam(true))
+			require.NoError(t, f2.Load(slices.Values(routes)))
+
+			tree1, tree2 := f1.getTree(), f2.getTree()
+			assert.Equal(t, tree1.patterns, tree2.patterns)
+			assert.Equal(t, tree1.names, tree2.names)
+			assert.Equal(t, tree1.methods, tree2.methods)
+			assert.Equal(t, tree1.size, tree2.size)
+			assert.Equal(t, tree1.maxDepth, tree2.maxDepth)
+			assert.Equal(t, tree1.maxParams, tree2.maxParams)
+
+			rng.Shuffle(len(routes), func(i, j int) { routes[i], routes[j] = routes[j], routes[i] })
+		}
+	})
+
+	t.Run("bulk build fails with the same error as incremental insert", func(t *testing.T) {
+		f := MustRouter()
+		fallback, err := f.NewRoute(nil, "/fallback/*{any}", emptyHandler)
+		require.NoError(t, err)
+		fallback.fallback = true
+
+		cases := []struct {
+			name   string
+			routes []*Route
+		}{
+			{
+				name:   "duplicate route",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo/{id}"), mustNewRoute(f, MethodGet, "/bar"), mustNewRoute(f, MethodGet, "/foo/{id}")},
+			},
+			{
+				name:   "catch-empty after exact route",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo/"), mustNewRoute(f, MethodGet, "/foo/*{any}")},
+			},
+			{
+				name:   "exact route after catch-empty",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo/*{any}"), mustNewRoute(f, MethodGet, "/foo/")},
+			},
+			{
+				name:   "name conflict",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo", WithName("foo")), mustNewRoute(f, MethodGet, "/bar", WithName("foo"))},
+			},
+			{
+				name:   "nil route",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo"), nil, mustNewRoute(f, MethodGet, "/bar")},
+			},
+			{
+				name:   "fallback route",
+				routes: []*Route{mustNewRoute(f, MethodGet, "/foo"), fallback},
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				txn1 := MustRouter().Txn(true)
+				defer txn1.Abort()
+				var want error
+				for _, route := range tc.routes {
+					if want = txn1.AddRoute(route); want != nil {
+						break
+					}
+				}
+				require.Error(t, want)
+
+				txn2 := MustRouter().Txn(true)
+				defer txn2.Abort()
+				err := txn2.Load(slices.Values(tc.routes))
+				require.Error(t, err)
+				assert.Equal(t, want.Error(), err.Error())
+				assert.Equal(t, txn1.rootTxn.patterns, txn2.rootTxn.patterns)
+				assert.Equal(t, txn1.rootTxn.names, txn2.rootTxn.names)
+				assert.Equal(t, txn1.Len(), txn2.Len())
+			})
+		}
+	})
+
+	t.Run("bulk loader", func(t *testing.T) {
+		f := MustRouter()
+		loader := NewBulkLoader(f, 2)
+		require.NoError(t, onlyError(loader.Add(MethodGet, "/foo", emptyHandler)))
+		assert.ErrorIs(t, onlyError(loader.Add(MethodGet, "/{", emptyHandler)), ErrInvalidRoute)
+		loader.AddRoute(mustNewRoute(f, MethodGet, "/bar"))
+		assert.Equal(t, 2, loader.Len())
+		assert.Equal(t, 0, f.Len())
+
+		require.NoError(t, loader.Load())
+		assert.Equal(t, 0, loader.Len())
+		assert.Equal(t, 2, f.Len())
+
+		loader.AddRoute(mustNewRoute(f, MethodGet, "/foo"))
+		assert.ErrorIs(t, loader.Load(), ErrRouteConflict)
+		assert.Equal(t, 1, loader.Len())
+		assert.Equal(t, 2, f.Len())
+	})
+
+	t.Run("nil route and read-only", func(t *testing.T) {
+		f := MustRouter()
+		assert.ErrorIs(t, f.Load(slices.Values([]*Route{nil})), ErrInvalidRoute)
+		assert.ErrorIs(t, f.View(func(txn *Txn) error {
+			return txn.Load(slices.Values([]*Route{}))
+		}), ErrReadOnlyTxn)
+	})
+}